@@ -17,7 +17,7 @@ const docTemplate = `{
     "paths": {
         "/cmd": {
             "get": {
-                "description": "Show command description by id",
+                "description": "Show command description by id with the last 1000 lines of its output.\nThe whole output is read by ranges from /cmd/{id}/output.",
                 "consumes": [
                     "application/json"
                 ],
@@ -35,6 +35,16 @@ const docTemplate = `{
                         "name": "id",
                         "in": "query",
                         "required": true
+                    },
+                    {
+                        "enum": [
+                            "absolute",
+                            "relative"
+                        ],
+                        "type": "string",
+                        "description": "View of output timestamps, capture time by default or seconds from the start of the run",
+                        "name": "time",
+                        "in": "query"
                     }
                 ],
                 "responses": {
@@ -50,6 +60,12 @@ const docTemplate = `{
                             "$ref": "#/definitions/handler.responseErr"
                         }
                     },
+                    "404": {
+                        "description": "Command not found",
+                        "schema": {
+                            "$ref": "#/definitions/handler.responseErr"
+                        }
+                    },
                     "500": {
                         "description": "Internal server error",
                         "schema": {
@@ -59,35 +75,84 @@ const docTemplate = `{
                 }
             }
         },
-        "/create": {
-            "post": {
-                "description": "Run new command and add it to DB",
-                "consumes": [
-                    "application/json"
-                ],
+        "/cmd/{id}/artifacts": {
+            "get": {
+                "description": "Show files collected from the workspace of the command by its artifact patterns, ordered by name.\nName is the path of the file relative to the workspace.",
                 "produces": [
                     "application/json"
                 ],
                 "tags": [
                     "commands"
                 ],
-                "summary": "Create new command",
+                "summary": "Show artifacts of command",
                 "parameters": [
                     {
-                        "description": "Script for execution",
-                        "name": "command",
-                        "in": "body",
-                        "required": true,
+                        "type": "integer",
+                        "description": "Command id",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "Sucess",
                         "schema": {
-                            "$ref": "#/definitions/handler.createRequest"
+                            "$ref": "#/definitions/handler.artifactsRespOK"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad request",
+                        "schema": {
+                            "$ref": "#/definitions/handler.responseErr"
+                        }
+                    },
+                    "404": {
+                        "description": "Command not found",
+                        "schema": {
+                            "$ref": "#/definitions/handler.responseErr"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal server error",
+                        "schema": {
+                            "$ref": "#/definitions/handler.responseErr"
                         }
                     }
+                }
+            }
+        },
+        "/cmd/{id}/artifacts/{artifactID}": {
+            "get": {
+                "description": "Download the file collected from the workspace of the command, range requests are supported.",
+                "produces": [
+                    "application/octet-stream"
+                ],
+                "tags": [
+                    "commands"
+                ],
+                "summary": "Download artifact of command",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "Command id",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "integer",
+                        "description": "Artifact id",
+                        "name": "artifactID",
+                        "in": "path",
+                        "required": true
+                    }
                 ],
                 "responses": {
-                    "201": {
-                        "description": "Sucess",
+                    "200": {
+                        "description": "Artifact file",
                         "schema": {
-                            "$ref": "#/definitions/handler.idRespOK"
+                            "type": "file"
                         }
                     },
                     "400": {
@@ -96,6 +161,12 @@ const docTemplate = `{
                             "$ref": "#/definitions/handler.responseErr"
                         }
                     },
+                    "404": {
+                        "description": "Artifact not found",
+                        "schema": {
+                            "$ref": "#/definitions/handler.responseErr"
+                        }
+                    },
                     "500": {
                         "description": "Internal server error",
                         "schema": {
@@ -105,33 +176,108 @@ const docTemplate = `{
                 }
             }
         },
-        "/create/upload": {
-            "post": {
-                "description": "Run new command from file and add it to DB",
-                "consumes": [
-                    "multipart/form-data"
+        "/cmd/{id}/attach": {
+            "get": {
+                "description": "Attach to the command over WebSocket.\nOutput and status are sent as JSON messages, {\"type\":\"input\",\"data\":\"...\"} writes to stdin\nand {\"type\":\"eof\"} closes stdin of the interactive command.",
+                "tags": [
+                    "commands"
                 ],
+                "summary": "Attach to command",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "Command id",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "101": {
+                        "description": "Switching Protocols",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad request",
+                        "schema": {
+                            "$ref": "#/definitions/handler.responseErr"
+                        }
+                    },
+                    "404": {
+                        "description": "Command not found",
+                        "schema": {
+                            "$ref": "#/definitions/handler.responseErr"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal server error",
+                        "schema": {
+                            "$ref": "#/definitions/handler.responseErr"
+                        }
+                    }
+                }
+            }
+        },
+        "/cmd/{id}/output": {
+            "get": {
+                "description": "Show the range of the command's output lines with their numbers.\nLines start from the line since, tail selects the last lines instead of offset.",
                 "produces": [
                     "application/json"
                 ],
                 "tags": [
                     "commands"
                 ],
-                "summary": "Create new command from file",
+                "summary": "Show output of command",
                 "parameters": [
                     {
-                        "type": "file",
-                        "description": "Upload file",
-                        "name": "file",
-                        "in": "formData",
+                        "type": "integer",
+                        "description": "Command id",
+                        "name": "id",
+                        "in": "path",
                         "required": true
+                    },
+                    {
+                        "type": "integer",
+                        "description": "Number of the first line",
+                        "name": "since",
+                        "in": "query"
+                    },
+                    {
+                        "type": "integer",
+                        "description": "Count of lines to skip",
+                        "name": "offset",
+                        "in": "query"
+                    },
+                    {
+                        "type": "integer",
+                        "description": "Count of lines, 1000 by default, 10000 at most",
+                        "name": "limit",
+                        "in": "query"
+                    },
+                    {
+                        "type": "integer",
+                        "description": "Count of the last lines",
+                        "name": "tail",
+                        "in": "query"
+                    },
+                    {
+                        "enum": [
+                            "absolute",
+                            "relative"
+                        ],
+                        "type": "string",
+                        "description": "View of output timestamps, capture time by default or seconds from the start of the run",
+                        "name": "time",
+                        "in": "query"
                     }
                 ],
                 "responses": {
-                    "201": {
+                    "200": {
                         "description": "Sucess",
                         "schema": {
-                            "$ref": "#/definitions/handler.idRespOK"
+                            "$ref": "#/definitions/handler.outputRespOK"
                         }
                     },
                     "400": {
@@ -140,6 +286,12 @@ const docTemplate = `{
                             "$ref": "#/definitions/handler.responseErr"
                         }
                     },
+                    "404": {
+                        "description": "Command not found",
+                        "schema": {
+                            "$ref": "#/definitions/handler.responseErr"
+                        }
+                    },
                     "500": {
                         "description": "Internal server error",
                         "schema": {
@@ -149,33 +301,79 @@ const docTemplate = `{
                 }
             }
         },
-        "/list": {
+        "/cmd/{id}/output.ndjson": {
             "get": {
-                "description": "Show last n commands",
-                "consumes": [
-                    "application/json"
+                "description": "Download all output of the command as a file, it's compressed with gzip if the client accepts it.\nText file has lines as they were printed, every line of NDJSON file is the output line object.",
+                "produces": [
+                    "text/plain",
+                    "application/x-ndjson"
+                ],
+                "tags": [
+                    "commands"
+                ],
+                "summary": "Download output of command",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "Command id",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    }
                 ],
+                "responses": {
+                    "200": {
+                        "description": "Output file",
+                        "schema": {
+                            "type": "file"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad request",
+                        "schema": {
+                            "$ref": "#/definitions/handler.responseErr"
+                        }
+                    },
+                    "404": {
+                        "description": "Command not found",
+                        "schema": {
+                            "$ref": "#/definitions/handler.responseErr"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal server error",
+                        "schema": {
+                            "$ref": "#/definitions/handler.responseErr"
+                        }
+                    }
+                }
+            }
+        },
+        "/cmd/{id}/output.txt": {
+            "get": {
+                "description": "Download all output of the command as a file, it's compressed with gzip if the client accepts it.\nText file has lines as they were printed, every line of NDJSON file is the output line object.",
                 "produces": [
-                    "application/json"
+                    "text/plain",
+                    "application/x-ndjson"
                 ],
                 "tags": [
                     "commands"
                 ],
-                "summary": "Show commands",
+                "summary": "Download output of command",
                 "parameters": [
                     {
                         "type": "integer",
-                        "description": "Limit for commands",
-                        "name": "limit",
-                        "in": "query",
+                        "description": "Command id",
+                        "name": "id",
+                        "in": "path",
                         "required": true
                     }
                 ],
                 "responses": {
                     "200": {
-                        "description": "Sucess",
+                        "description": "Output file",
                         "schema": {
-                            "$ref": "#/definitions/handler.commandsRespOK"
+                            "type": "file"
                         }
                     },
                     "400": {
@@ -184,6 +382,12 @@ const docTemplate = `{
                             "$ref": "#/definitions/handler.responseErr"
                         }
                     },
+                    "404": {
+                        "description": "Command not found",
+                        "schema": {
+                            "$ref": "#/definitions/handler.responseErr"
+                        }
+                    },
                     "500": {
                         "description": "Internal server error",
                         "schema": {
@@ -193,9 +397,9 @@ const docTemplate = `{
                 }
             }
         },
-        "/stop": {
-            "put": {
-                "description": "Stop command's execution by id",
+        "/cmd/{id}/rerun": {
+            "post": {
+                "description": "Run new command with the stored script and options of the command by id.\nThe new command is linked to the original one as its parent.",
                 "consumes": [
                     "application/json"
                 ],
@@ -205,37 +409,96 @@ const docTemplate = `{
                 "tags": [
                     "commands"
                 ],
-                "summary": "Stop one command",
+                "summary": "Rerun command",
                 "parameters": [
                     {
+                        "type": "integer",
                         "description": "Command id",
                         "name": "id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "description": "Secret environment variables",
+                        "name": "secrets",
                         "in": "body",
-                        "required": true,
                         "schema": {
-                            "$ref": "#/definitions/handler.stopCommandRequest"
+                            "$ref": "#/definitions/handler.rerunRequest"
                         }
                     }
                 ],
                 "responses": {
-                    "202": {
+                    "201": {
                         "description": "Sucess",
                         "schema": {
                             "$ref": "#/definitions/handler.idRespOK"
                         }
                     },
-                    "304": {
-                        "description": "Not Modified",
+                    "400": {
+                        "description": "Bad request",
+                        "schema": {
+                            "$ref": "#/definitions/handler.responseErr"
+                        }
+                    },
+                    "404": {
+                        "description": "Command not found",
                         "schema": {
                             "$ref": "#/definitions/handler.responseErr"
                         }
                     },
+                    "500": {
+                        "description": "Internal server error",
+                        "schema": {
+                            "$ref": "#/definitions/handler.responseErr"
+                        }
+                    }
+                }
+            }
+        },
+        "/cmd/{id}/stream": {
+            "get": {
+                "description": "Stream stored and live output of the command as Server-Sent Events.\nEvery output line is sent as \"output\" event, the stream ends with \"status\" event.",
+                "produces": [
+                    "text/event-stream"
+                ],
+                "tags": [
+                    "commands"
+                ],
+                "summary": "Stream command's output",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "Command id",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "integer",
+                        "description": "Line number of the last received output event",
+                        "name": "Last-Event-ID",
+                        "in": "header"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "Event stream",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
                     "400": {
                         "description": "Bad request",
                         "schema": {
                             "$ref": "#/definitions/handler.responseErr"
                         }
                     },
+                    "404": {
+                        "description": "Command not found",
+                        "schema": {
+                            "$ref": "#/definitions/handler.responseErr"
+                        }
+                    },
                     "500": {
                         "description": "Internal server error",
                         "schema": {
@@ -244,120 +507,1636 @@ const docTemplate = `{
                     }
                 }
             }
-        }
-    },
-    "definitions": {
-        "handler.commandRespBodyOK": {
-            "type": "object",
+        },
+        "/create": {
+            "post": {
+                "description": "Run new command and add it to DB",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "commands"
+                ],
+                "summary": "Create new command",
+                "parameters": [
+                    {
+                        "description": "Script for execution",
+                        "name": "command",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/handler.createRequest"
+                        }
+                    }
+                ],
+                "responses": {
+                    "201": {
+                        "description": "Sucess",
+                        "schema": {
+                            "$ref": "#/definitions/handler.idRespOK"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad request",
+                        "schema": {
+                            "$ref": "#/definitions/handler.responseErr"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal server error",
+                        "schema": {
+                            "$ref": "#/definitions/handler.responseErr"
+                        }
+                    }
+                }
+            }
+        },
+        "/create/upload": {
+            "post": {
+                "description": "Run new command from file and add it to DB",
+                "consumes": [
+                    "multipart/form-data"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "commands"
+                ],
+                "summary": "Create new command from file",
+                "parameters": [
+                    {
+                        "type": "file",
+                        "description": "Upload file",
+                        "name": "file",
+                        "in": "formData",
+                        "required": true
+                    },
+                    {
+                        "type": "boolean",
+                        "description": "Script reads stdin from attached client",
+                        "name": "interactive",
+                        "in": "formData"
+                    },
+                    {
+                        "type": "integer",
+                        "description": "Execution timeout in seconds",
+                        "name": "timeout",
+                        "in": "formData"
+                    },
+                    {
+                        "type": "integer",
+                        "description": "CPU time limit in seconds",
+                        "name": "cpu_time",
+                        "in": "formData"
+                    },
+                    {
+                        "type": "integer",
+                        "description": "Memory limit in megabytes",
+                        "name": "memory_mb",
+                        "in": "formData"
+                    },
+                    {
+                        "type": "integer",
+                        "description": "Open files limit",
+                        "name": "open_files",
+                        "in": "formData"
+                    },
+                    {
+                        "type": "integer",
+                        "description": "Processes limit",
+                        "name": "processes",
+                        "in": "formData"
+                    },
+                    {
+                        "type": "array",
+                        "items": {
+                            "type": "string"
+                        },
+                        "collectionFormat": "multi",
+                        "description": "Environment variable as NAME=value",
+                        "name": "env",
+                        "in": "formData"
+                    },
+                    {
+                        "type": "array",
+                        "items": {
+                            "type": "string"
+                        },
+                        "collectionFormat": "multi",
+                        "description": "Environment variable which isn't stored, as NAME=value",
+                        "name": "secret_env",
+                        "in": "formData"
+                    },
+                    {
+                        "type": "array",
+                        "items": {
+                            "type": "string"
+                        },
+                        "collectionFormat": "multi",
+                        "description": "Positional argument of the script",
+                        "name": "args",
+                        "in": "formData"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Working directory inside one of base directories, the workspace by default",
+                        "name": "working_dir",
+                        "in": "formData"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Interpreter of the script, it's detected by known shebang line by default",
+                        "name": "interpreter",
+                        "in": "formData"
+                    },
+                    {
+                        "type": "array",
+                        "items": {
+                            "type": "string"
+                        },
+                        "collectionFormat": "multi",
+                        "description": "Glob pattern of files collected from the workspace",
+                        "name": "artifacts",
+                        "in": "formData"
+                    }
+                ],
+                "responses": {
+                    "201": {
+                        "description": "Sucess",
+                        "schema": {
+                            "$ref": "#/definitions/handler.idRespOK"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad request",
+                        "schema": {
+                            "$ref": "#/definitions/handler.responseErr"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal server error",
+                        "schema": {
+                            "$ref": "#/definitions/handler.responseErr"
+                        }
+                    }
+                }
+            }
+        },
+        "/list": {
+            "get": {
+                "description": "Show the page of latest commands matching filters.\nPass next_cursor of the response as cursor to get the next page.",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "commands"
+                ],
+                "summary": "Show commands",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "Limit for commands",
+                        "name": "limit",
+                        "in": "query",
+                        "required": true
+                    },
+                    {
+                        "type": "integer",
+                        "description": "Cursor of the page",
+                        "name": "cursor",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Comma separated statuses",
+                        "name": "status",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Created at or after, RFC 3339",
+                        "name": "from",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Created before, RFC 3339",
+                        "name": "to",
+                        "in": "query"
+                    },
+                    {
+                        "type": "boolean",
+                        "description": "Only working or finished commands",
+                        "name": "is_working",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Full-text search over script and output",
+                        "name": "q",
+                        "in": "query"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "Sucess",
+                        "schema": {
+                            "$ref": "#/definitions/handler.commandsRespOK"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad request",
+                        "schema": {
+                            "$ref": "#/definitions/handler.responseErr"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal server error",
+                        "schema": {
+                            "$ref": "#/definitions/handler.responseErr"
+                        }
+                    }
+                }
+            }
+        },
+        "/schedules": {
+            "get": {
+                "description": "Show all schedules ordered by id",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "schedules"
+                ],
+                "summary": "Show schedules",
+                "responses": {
+                    "200": {
+                        "description": "Sucess",
+                        "schema": {
+                            "$ref": "#/definitions/handler.schedulesRespOK"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal server error",
+                        "schema": {
+                            "$ref": "#/definitions/handler.responseErr"
+                        }
+                    }
+                }
+            },
+            "post": {
+                "description": "Run the script or the template by cron expression.\nExpression has five fields or is a descriptor like @hourly or @every 10m.\nSchedule with no overlap skips the run while its previous command is still working.",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "schedules"
+                ],
+                "summary": "Create new schedule",
+                "parameters": [
+                    {
+                        "description": "Schedule",
+                        "name": "schedule",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/handler.scheduleRequest"
+                        }
+                    }
+                ],
+                "responses": {
+                    "201": {
+                        "description": "Sucess",
+                        "schema": {
+                            "$ref": "#/definitions/handler.idRespOK"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad request",
+                        "schema": {
+                            "$ref": "#/definitions/handler.responseErr"
+                        }
+                    },
+                    "404": {
+                        "description": "Template not found",
+                        "schema": {
+                            "$ref": "#/definitions/handler.responseErr"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal server error",
+                        "schema": {
+                            "$ref": "#/definitions/handler.responseErr"
+                        }
+                    }
+                }
+            }
+        },
+        "/schedules/{id}": {
+            "get": {
+                "description": "Show schedule by id",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "schedules"
+                ],
+                "summary": "Show one schedule",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "Schedule id",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "Sucess",
+                        "schema": {
+                            "$ref": "#/definitions/handler.scheduleRespOK"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad request",
+                        "schema": {
+                            "$ref": "#/definitions/handler.responseErr"
+                        }
+                    },
+                    "404": {
+                        "description": "Schedule not found",
+                        "schema": {
+                            "$ref": "#/definitions/handler.responseErr"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal server error",
+                        "schema": {
+                            "$ref": "#/definitions/handler.responseErr"
+                        }
+                    }
+                }
+            },
+            "put": {
+                "description": "Replace schedule by id, paused schedule stops firing",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "schedules"
+                ],
+                "summary": "Update schedule",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "Schedule id",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "description": "Schedule",
+                        "name": "schedule",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/handler.scheduleRequest"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "Sucess",
+                        "schema": {
+                            "$ref": "#/definitions/handler.idRespOK"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad request",
+                        "schema": {
+                            "$ref": "#/definitions/handler.responseErr"
+                        }
+                    },
+                    "404": {
+                        "description": "Schedule or template not found",
+                        "schema": {
+                            "$ref": "#/definitions/handler.responseErr"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal server error",
+                        "schema": {
+                            "$ref": "#/definitions/handler.responseErr"
+                        }
+                    }
+                }
+            },
+            "delete": {
+                "description": "Delete schedule by id, commands made by it are kept",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "schedules"
+                ],
+                "summary": "Delete schedule",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "Schedule id",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "Sucess",
+                        "schema": {
+                            "$ref": "#/definitions/handler.idRespOK"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad request",
+                        "schema": {
+                            "$ref": "#/definitions/handler.responseErr"
+                        }
+                    },
+                    "404": {
+                        "description": "Schedule not found",
+                        "schema": {
+                            "$ref": "#/definitions/handler.responseErr"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal server error",
+                        "schema": {
+                            "$ref": "#/definitions/handler.responseErr"
+                        }
+                    }
+                }
+            }
+        },
+        "/schedules/{id}/next": {
+            "get": {
+                "description": "Show the next times when the schedule fires",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "schedules"
+                ],
+                "summary": "Preview next runs",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "Schedule id",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "integer",
+                        "description": "Count of runs, 5 by default, 100 at most",
+                        "name": "count",
+                        "in": "query"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "Sucess",
+                        "schema": {
+                            "$ref": "#/definitions/handler.nextRunsRespOK"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad request",
+                        "schema": {
+                            "$ref": "#/definitions/handler.responseErr"
+                        }
+                    },
+                    "404": {
+                        "description": "Schedule not found",
+                        "schema": {
+                            "$ref": "#/definitions/handler.responseErr"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal server error",
+                        "schema": {
+                            "$ref": "#/definitions/handler.responseErr"
+                        }
+                    }
+                }
+            }
+        },
+        "/stop": {
+            "put": {
+                "description": "Stop command's execution by id",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "commands"
+                ],
+                "summary": "Stop one command",
+                "parameters": [
+                    {
+                        "description": "Command id",
+                        "name": "id",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/handler.stopCommandRequest"
+                        }
+                    }
+                ],
+                "responses": {
+                    "202": {
+                        "description": "Sucess",
+                        "schema": {
+                            "$ref": "#/definitions/handler.idRespOK"
+                        }
+                    },
+                    "304": {
+                        "description": "Not Modified",
+                        "schema": {
+                            "$ref": "#/definitions/handler.responseErr"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad request",
+                        "schema": {
+                            "$ref": "#/definitions/handler.responseErr"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal server error",
+                        "schema": {
+                            "$ref": "#/definitions/handler.responseErr"
+                        }
+                    }
+                }
+            }
+        },
+        "/templates": {
+            "get": {
+                "description": "Show all templates ordered by name",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "templates"
+                ],
+                "summary": "Show templates",
+                "responses": {
+                    "200": {
+                        "description": "Sucess",
+                        "schema": {
+                            "$ref": "#/definitions/handler.templatesRespOK"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal server error",
+                        "schema": {
+                            "$ref": "#/definitions/handler.responseErr"
+                        }
+                    }
+                }
+            },
+            "post": {
+                "description": "Save named script with typed parameters to the library.\nParameter types are string, int, enum and bool.",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "templates"
+                ],
+                "summary": "Create new template",
+                "parameters": [
+                    {
+                        "description": "Template",
+                        "name": "template",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/handler.templateRequest"
+                        }
+                    }
+                ],
+                "responses": {
+                    "201": {
+                        "description": "Sucess",
+                        "schema": {
+                            "$ref": "#/definitions/handler.idRespOK"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad request",
+                        "schema": {
+                            "$ref": "#/definitions/handler.responseErr"
+                        }
+                    },
+                    "409": {
+                        "description": "Template with this name already exists",
+                        "schema": {
+                            "$ref": "#/definitions/handler.responseErr"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal server error",
+                        "schema": {
+                            "$ref": "#/definitions/handler.responseErr"
+                        }
+                    }
+                }
+            }
+        },
+        "/templates/{id}": {
+            "get": {
+                "description": "Show template by id",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "templates"
+                ],
+                "summary": "Show one template",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "Template id",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "Sucess",
+                        "schema": {
+                            "$ref": "#/definitions/handler.templateRespOK"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad request",
+                        "schema": {
+                            "$ref": "#/definitions/handler.responseErr"
+                        }
+                    },
+                    "404": {
+                        "description": "Template not found",
+                        "schema": {
+                            "$ref": "#/definitions/handler.responseErr"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal server error",
+                        "schema": {
+                            "$ref": "#/definitions/handler.responseErr"
+                        }
+                    }
+                }
+            },
+            "put": {
+                "description": "Replace template by id",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "templates"
+                ],
+                "summary": "Update template",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "Template id",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "description": "Template",
+                        "name": "template",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/handler.templateRequest"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "Sucess",
+                        "schema": {
+                            "$ref": "#/definitions/handler.idRespOK"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad request",
+                        "schema": {
+                            "$ref": "#/definitions/handler.responseErr"
+                        }
+                    },
+                    "404": {
+                        "description": "Template not found",
+                        "schema": {
+                            "$ref": "#/definitions/handler.responseErr"
+                        }
+                    },
+                    "409": {
+                        "description": "Template with this name already exists",
+                        "schema": {
+                            "$ref": "#/definitions/handler.responseErr"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal server error",
+                        "schema": {
+                            "$ref": "#/definitions/handler.responseErr"
+                        }
+                    }
+                }
+            },
+            "delete": {
+                "description": "Delete template by id, commands made from it are kept",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "templates"
+                ],
+                "summary": "Delete template",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "Template id",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "Sucess",
+                        "schema": {
+                            "$ref": "#/definitions/handler.idRespOK"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad request",
+                        "schema": {
+                            "$ref": "#/definitions/handler.responseErr"
+                        }
+                    },
+                    "404": {
+                        "description": "Template not found",
+                        "schema": {
+                            "$ref": "#/definitions/handler.responseErr"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal server error",
+                        "schema": {
+                            "$ref": "#/definitions/handler.responseErr"
+                        }
+                    }
+                }
+            }
+        },
+        "/templates/{id}/run": {
+            "post": {
+                "description": "Run new command from template.\nValues of parameters are passed to the script as environment variables.",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "templates"
+                ],
+                "summary": "Run template",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "Template id",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "description": "Values of parameters",
+                        "name": "params",
+                        "in": "body",
+                        "schema": {
+                            "$ref": "#/definitions/handler.runTemplateRequest"
+                        }
+                    }
+                ],
+                "responses": {
+                    "201": {
+                        "description": "Sucess",
+                        "schema": {
+                            "$ref": "#/definitions/handler.idRespOK"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad request",
+                        "schema": {
+                            "$ref": "#/definitions/handler.responseErr"
+                        }
+                    },
+                    "404": {
+                        "description": "Template not found",
+                        "schema": {
+                            "$ref": "#/definitions/handler.responseErr"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal server error",
+                        "schema": {
+                            "$ref": "#/definitions/handler.responseErr"
+                        }
+                    }
+                }
+            }
+        }
+    },
+    "definitions": {
+        "handler.artifactsRespBodyOK": {
+            "type": "object",
+            "properties": {
+                "artifacts": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/models.Artifact"
+                    }
+                },
+                "command_id": {
+                    "type": "integer"
+                }
+            }
+        },
+        "handler.artifactsRespOK": {
+            "type": "object",
+            "properties": {
+                "body": {
+                    "$ref": "#/definitions/handler.artifactsRespBodyOK"
+                },
+                "status": {
+                    "type": "integer"
+                }
+            }
+        },
+        "handler.commandRespBodyOK": {
+            "type": "object",
+            "properties": {
+                "command": {
+                    "$ref": "#/definitions/models.Command"
+                }
+            }
+        },
+        "handler.commandRespOK": {
+            "type": "object",
+            "properties": {
+                "body": {
+                    "$ref": "#/definitions/handler.commandRespBodyOK"
+                },
+                "status": {
+                    "type": "integer"
+                }
+            }
+        },
+        "handler.commandsRespBodyOK": {
+            "type": "object",
+            "properties": {
+                "commands": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/models.Command"
+                    }
+                },
+                "next_cursor": {
+                    "type": "integer"
+                }
+            }
+        },
+        "handler.commandsRespOK": {
+            "type": "object",
+            "properties": {
+                "body": {
+                    "$ref": "#/definitions/handler.commandsRespBodyOK"
+                },
+                "status": {
+                    "type": "integer"
+                }
+            }
+        },
+        "handler.createRequest": {
+            "type": "object",
+            "properties": {
+                "args": {
+                    "type": "array",
+                    "items": {
+                        "type": "string"
+                    }
+                },
+                "artifacts": {
+                    "description": "Glob patterns of files collected from the workspace",
+                    "type": "array",
+                    "items": {
+                        "type": "string"
+                    }
+                },
+                "env": {
+                    "type": "object",
+                    "additionalProperties": {
+                        "type": "string"
+                    }
+                },
+                "interactive": {
+                    "type": "boolean"
+                },
+                "interpreter": {
+                    "description": "Empty means the default interpreter",
+                    "type": "string"
+                },
+                "limits": {
+                    "$ref": "#/definitions/handler.limitsRequest"
+                },
+                "script": {
+                    "type": "string"
+                },
+                "secret_env": {
+                    "description": "Isn't stored with the command",
+                    "type": "object",
+                    "additionalProperties": {
+                        "type": "string"
+                    }
+                },
+                "timeout": {
+                    "description": "Seconds, zero means the default timeout",
+                    "type": "integer"
+                },
+                "working_dir": {
+                    "type": "string"
+                }
+            }
+        },
+        "handler.idRespBodyOK": {
+            "type": "object",
+            "properties": {
+                "command_id": {
+                    "type": "integer"
+                },
+                "schedule_id": {
+                    "type": "integer"
+                },
+                "template_id": {
+                    "type": "integer"
+                }
+            }
+        },
+        "handler.idRespOK": {
+            "type": "object",
+            "properties": {
+                "body": {
+                    "$ref": "#/definitions/handler.idRespBodyOK"
+                },
+                "status": {
+                    "type": "integer"
+                }
+            }
+        },
+        "handler.limitsRequest": {
+            "type": "object",
+            "properties": {
+                "cpu_time": {
+                    "description": "Seconds",
+                    "type": "integer"
+                },
+                "memory_mb": {
+                    "type": "integer"
+                },
+                "open_files": {
+                    "type": "integer"
+                },
+                "processes": {
+                    "type": "integer"
+                }
+            }
+        },
+        "handler.nextRunsRespBodyOK": {
+            "type": "object",
+            "properties": {
+                "runs": {
+                    "type": "array",
+                    "items": {
+                        "type": "string"
+                    }
+                }
+            }
+        },
+        "handler.nextRunsRespOK": {
+            "type": "object",
+            "properties": {
+                "body": {
+                    "$ref": "#/definitions/handler.nextRunsRespBodyOK"
+                },
+                "status": {
+                    "type": "integer"
+                }
+            }
+        },
+        "handler.outputRespBodyOK": {
+            "type": "object",
+            "properties": {
+                "command_id": {
+                    "type": "integer"
+                },
+                "last_line": {
+                    "description": "Number of the last saved line",
+                    "type": "integer"
+                },
+                "lines": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/models.Output"
+                    }
+                }
+            }
+        },
+        "handler.outputRespOK": {
+            "type": "object",
+            "properties": {
+                "body": {
+                    "$ref": "#/definitions/handler.outputRespBodyOK"
+                },
+                "status": {
+                    "type": "integer"
+                }
+            }
+        },
+        "handler.rerunRequest": {
+            "type": "object",
+            "properties": {
+                "secret_env": {
+                    "description": "Secret variables of the original command aren't stored",
+                    "type": "object",
+                    "additionalProperties": {
+                        "type": "string"
+                    }
+                }
+            }
+        },
+        "handler.respBodyErr": {
+            "type": "object",
+            "properties": {
+                "error": {
+                    "type": "string"
+                }
+            }
+        },
+        "handler.responseErr": {
+            "type": "object",
+            "properties": {
+                "body": {
+                    "$ref": "#/definitions/handler.respBodyErr"
+                },
+                "status": {
+                    "type": "integer"
+                }
+            }
+        },
+        "handler.runTemplateRequest": {
+            "type": "object",
+            "properties": {
+                "params": {
+                    "type": "object",
+                    "additionalProperties": {}
+                },
+                "secret_env": {
+                    "description": "Isn't stored with the command",
+                    "type": "object",
+                    "additionalProperties": {
+                        "type": "string"
+                    }
+                }
+            }
+        },
+        "handler.scheduleRequest": {
+            "type": "object",
+            "properties": {
+                "args": {
+                    "type": "array",
+                    "items": {
+                        "type": "string"
+                    }
+                },
+                "artifacts": {
+                    "type": "array",
+                    "items": {
+                        "type": "string"
+                    }
+                },
+                "env": {
+                    "type": "object",
+                    "additionalProperties": {
+                        "type": "string"
+                    }
+                },
+                "interpreter": {
+                    "type": "string"
+                },
+                "limits": {
+                    "$ref": "#/definitions/handler.limitsRequest"
+                },
+                "name": {
+                    "type": "string"
+                },
+                "no_overlap": {
+                    "type": "boolean"
+                },
+                "params": {
+                    "description": "Values of the template's parameters",
+                    "type": "object",
+                    "additionalProperties": {}
+                },
+                "paused": {
+                    "type": "boolean"
+                },
+                "script": {
+                    "type": "string"
+                },
+                "spec": {
+                    "description": "Standard cron expression or descriptor like @hourly",
+                    "type": "string"
+                },
+                "template_id": {
+                    "type": "integer"
+                },
+                "timeout": {
+                    "description": "Seconds, zero means the default timeout",
+                    "type": "integer"
+                },
+                "working_dir": {
+                    "type": "string"
+                }
+            }
+        },
+        "handler.scheduleRespBodyOK": {
+            "type": "object",
             "properties": {
-                "command": {
-                    "$ref": "#/definitions/models.Command"
+                "schedule": {
+                    "$ref": "#/definitions/handler.scheduleResponse"
                 }
             }
         },
-        "handler.commandRespOK": {
+        "handler.scheduleRespOK": {
             "type": "object",
             "properties": {
                 "body": {
-                    "$ref": "#/definitions/handler.commandRespBodyOK"
+                    "$ref": "#/definitions/handler.scheduleRespBodyOK"
                 },
                 "status": {
                     "type": "integer"
                 }
             }
         },
-        "handler.commandsRespBodyOK": {
+        "handler.scheduleResponse": {
             "type": "object",
             "properties": {
-                "commands": {
+                "args": {
                     "type": "array",
                     "items": {
-                        "$ref": "#/definitions/models.Command"
+                        "type": "string"
+                    }
+                },
+                "artifacts": {
+                    "type": "array",
+                    "items": {
+                        "type": "string"
+                    }
+                },
+                "created_at": {
+                    "type": "string"
+                },
+                "env": {
+                    "type": "object",
+                    "additionalProperties": {
+                        "type": "string"
+                    }
+                },
+                "id": {
+                    "type": "integer"
+                },
+                "interpreter": {
+                    "type": "string"
+                },
+                "last_command_id": {
+                    "type": "integer"
+                },
+                "limits": {
+                    "$ref": "#/definitions/handler.limitsRequest"
+                },
+                "name": {
+                    "type": "string"
+                },
+                "no_overlap": {
+                    "type": "boolean"
+                },
+                "params": {
+                    "description": "Values of the template's parameters",
+                    "type": "object",
+                    "additionalProperties": {}
+                },
+                "paused": {
+                    "type": "boolean"
+                },
+                "script": {
+                    "type": "string"
+                },
+                "spec": {
+                    "description": "Standard cron expression or descriptor like @hourly",
+                    "type": "string"
+                },
+                "template_id": {
+                    "type": "integer"
+                },
+                "timeout": {
+                    "description": "Seconds, zero means the default timeout",
+                    "type": "integer"
+                },
+                "updated_at": {
+                    "type": "string"
+                },
+                "working_dir": {
+                    "type": "string"
+                }
+            }
+        },
+        "handler.schedulesRespBodyOK": {
+            "type": "object",
+            "properties": {
+                "schedules": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/handler.scheduleResponse"
                     }
                 }
             }
         },
-        "handler.commandsRespOK": {
+        "handler.schedulesRespOK": {
             "type": "object",
             "properties": {
                 "body": {
-                    "$ref": "#/definitions/handler.commandsRespBodyOK"
+                    "$ref": "#/definitions/handler.schedulesRespBodyOK"
                 },
                 "status": {
                     "type": "integer"
                 }
             }
         },
-        "handler.createRequest": {
+        "handler.stopCommandRequest": {
             "type": "object",
             "properties": {
-                "script": {
+                "id": {
+                    "type": "string"
+                },
+                "signal": {
+                    "description": "SIGTERM by default, SIGINT, SIGHUP, SIGQUIT and SIGKILL are allowed too",
                     "type": "string"
                 }
             }
         },
-        "handler.idRespBodyOK": {
+        "handler.templateRequest": {
             "type": "object",
             "properties": {
-                "command_id": {
+                "args": {
+                    "type": "array",
+                    "items": {
+                        "type": "string"
+                    }
+                },
+                "artifacts": {
+                    "type": "array",
+                    "items": {
+                        "type": "string"
+                    }
+                },
+                "description": {
+                    "type": "string"
+                },
+                "env": {
+                    "type": "object",
+                    "additionalProperties": {
+                        "type": "string"
+                    }
+                },
+                "interpreter": {
+                    "type": "string"
+                },
+                "limits": {
+                    "$ref": "#/definitions/handler.limitsRequest"
+                },
+                "name": {
+                    "type": "string"
+                },
+                "params": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/models.Param"
+                    }
+                },
+                "script": {
+                    "type": "string"
+                },
+                "timeout": {
+                    "description": "Seconds, zero means the default timeout",
                     "type": "integer"
+                },
+                "working_dir": {
+                    "type": "string"
                 }
             }
         },
-        "handler.idRespOK": {
+        "handler.templateRespBodyOK": {
+            "type": "object",
+            "properties": {
+                "template": {
+                    "$ref": "#/definitions/handler.templateResponse"
+                }
+            }
+        },
+        "handler.templateRespOK": {
             "type": "object",
             "properties": {
                 "body": {
-                    "$ref": "#/definitions/handler.idRespBodyOK"
+                    "$ref": "#/definitions/handler.templateRespBodyOK"
                 },
                 "status": {
                     "type": "integer"
                 }
             }
         },
-        "handler.respBodyErr": {
+        "handler.templateResponse": {
             "type": "object",
             "properties": {
-                "error": {
+                "args": {
+                    "type": "array",
+                    "items": {
+                        "type": "string"
+                    }
+                },
+                "artifacts": {
+                    "type": "array",
+                    "items": {
+                        "type": "string"
+                    }
+                },
+                "created_at": {
+                    "type": "string"
+                },
+                "description": {
+                    "type": "string"
+                },
+                "env": {
+                    "type": "object",
+                    "additionalProperties": {
+                        "type": "string"
+                    }
+                },
+                "id": {
+                    "type": "integer"
+                },
+                "interpreter": {
+                    "type": "string"
+                },
+                "limits": {
+                    "$ref": "#/definitions/handler.limitsRequest"
+                },
+                "name": {
+                    "type": "string"
+                },
+                "params": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/models.Param"
+                    }
+                },
+                "script": {
+                    "type": "string"
+                },
+                "timeout": {
+                    "description": "Seconds, zero means the default timeout",
+                    "type": "integer"
+                },
+                "updated_at": {
+                    "type": "string"
+                },
+                "working_dir": {
                     "type": "string"
                 }
             }
         },
-        "handler.responseErr": {
+        "handler.templatesRespBodyOK": {
+            "type": "object",
+            "properties": {
+                "templates": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/handler.templateResponse"
+                    }
+                }
+            }
+        },
+        "handler.templatesRespOK": {
             "type": "object",
             "properties": {
                 "body": {
-                    "$ref": "#/definitions/handler.respBodyErr"
+                    "$ref": "#/definitions/handler.templatesRespBodyOK"
                 },
                 "status": {
                     "type": "integer"
                 }
             }
         },
-        "handler.stopCommandRequest": {
+        "models.Artifact": {
             "type": "object",
             "properties": {
+                "command_id": {
+                    "type": "integer"
+                },
+                "created_at": {
+                    "type": "string"
+                },
                 "id": {
+                    "type": "integer"
+                },
+                "name": {
+                    "type": "string"
+                },
+                "sha256": {
                     "type": "string"
+                },
+                "size": {
+                    "type": "integer"
                 }
             }
         },
         "models.Command": {
             "type": "object",
             "properties": {
+                "args": {
+                    "type": "array",
+                    "items": {
+                        "type": "string"
+                    }
+                },
                 "command_name": {
                     "type": "string"
                 },
                 "created_at": {
                     "type": "string"
                 },
+                "env": {
+                    "type": "object",
+                    "additionalProperties": {
+                        "type": "string"
+                    }
+                },
+                "exceeded_limit": {
+                    "type": "string"
+                },
+                "exit_code": {
+                    "type": "integer"
+                },
+                "finished_at": {
+                    "type": "string"
+                },
                 "id": {
                     "type": "integer"
                 },
+                "interpreter": {
+                    "type": "string"
+                },
                 "is_working": {
                     "type": "boolean"
                 },
                 "output": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/models.Output"
+                    }
+                },
+                "parent_id": {
+                    "type": "integer"
+                },
+                "queue_position": {
+                    "type": "integer"
+                },
+                "reruns": {
+                    "type": "array",
+                    "items": {
+                        "type": "integer"
+                    }
+                },
+                "script": {
+                    "type": "string"
+                },
+                "script_hash": {
+                    "type": "string"
+                },
+                "signal": {
+                    "type": "string"
+                },
+                "status": {
+                    "type": "string"
+                },
+                "template_id": {
+                    "type": "integer"
+                },
+                "truncated": {
+                    "description": "Some lines of the output weren't saved",
+                    "type": "boolean"
+                },
+                "working_dir": {
+                    "type": "string"
+                }
+            }
+        },
+        "models.Output": {
+            "type": "object",
+            "properties": {
+                "at": {
+                    "description": "At is when the line was captured, Elapsed is seconds from the start of the run to the line.\nLines saved before timestamps were recorded have neither ...",
+                    "type": "string"
+                },
+                "elapsed": {
+                    "type": "number"
+                },
+                "encoding": {
+                    "description": "Encoding is base64 if the line isn't valid UTF-8 text,\nPartial means the line was longer than the limit and continues in the next one",
+                    "type": "string"
+                },
+                "line": {
+                    "description": "Number of the line in the command's output starting from 1",
+                    "type": "integer"
+                },
+                "partial": {
+                    "type": "boolean"
+                },
+                "stream": {
+                    "type": "string"
+                },
+                "text": {
+                    "type": "string"
+                }
+            }
+        },
+        "models.Param": {
+            "type": "object",
+            "properties": {
+                "default": {
+                    "type": "string"
+                },
+                "description": {
+                    "type": "string"
+                },
+                "name": {
+                    "type": "string"
+                },
+                "required": {
+                    "type": "boolean"
+                },
+                "type": {
+                    "type": "string"
+                },
+                "values": {
                     "type": "array",
                     "items": {
                         "type": "string"
@@ -38,6 +38,16 @@ func New() *App {
 
 	a.log = logs.NewLogger(a.cfg.Env)
 
+	if a.cfg.Executor.Cgroup == "" && (a.cfg.Commander.DefaultLimits.Processes > 0 || a.cfg.Commander.MaxLimits.Processes > 0) {
+		a.log.Error("Processes limit requires cgroup of executor")
+		os.Exit(1)
+	}
+
+	if a.cfg.Executor.Cgroup == "" && len(a.cfg.Commander.UnlimitedAddressSpace) > 0 {
+		a.log.Error("Interpreters with unlimited address space require cgroup of executor")
+		os.Exit(1)
+	}
+
 	a.db, err = connectionAttemptToDB(a.cfg.Storage)
 	if err != nil {
 		a.log.Error("Failed to connect to db", a.log.Attr("error", err))
@@ -46,9 +56,13 @@ func New() *App {
 
 	cS := storage.NewCommandStorage(a.db)
 
-	e := script.NewExecutor(a.log)
+	e := script.NewExecutor(a.log, &a.cfg.Executor)
+
+	if a.cfg.Executor.Sandbox.Enabled {
+		e = script.NewSandboxExecutor(a.log, &a.cfg.Executor)
+	}
 
-	a.cmd = commander.NewCommander(a.log, cS, e)
+	a.cmd = commander.NewCommander(a.log, cS, e, &a.cfg.Commander)
 
 	h := handler.New(a.cmd, a.cfg.Frontend.Domains, a.cfg.Server.Timeout, a.log)
 
@@ -62,6 +76,22 @@ func New() *App {
 func (a *App) MustRun() {
 	a.log.Info("Starting command executor service", "env", a.cfg.Env)
 
+	if err := a.cmd.PruneWorkspaces(); err != nil {
+		a.log.Error("Pruning workspaces", a.log.Attr("error", err))
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), a.cfg.CtxTimeout)
+
+	if err := a.cmd.RestoreQueue(ctx); err != nil {
+		a.log.Error("Restoring queued commands", a.log.Attr("error", err))
+	}
+
+	if err := a.cmd.StartScheduler(ctx); err != nil {
+		a.log.Error("Starting scheduler", a.log.Attr("error", err))
+	}
+
+	cancel()
+
 	go func() {
 		if err := a.srv.Start(); err != nil {
 			if !errors.Is(err, http.ErrServerClosed) {
@@ -78,7 +108,9 @@ func (a *App) MustRun() {
 	a.mustStop()
 }
 
-// mustStop stops the App's working elements ...
+// mustStop stops the App's working elements.
+// Running commands get their own timeout after the server and the scheduler are stopped,
+// the connection to storage is closed after their results are saved ...
 func (a *App) mustStop() {
 	ctx, cancel := context.WithTimeout(context.Background(), a.cfg.CtxTimeout)
 	defer cancel()
@@ -87,7 +119,14 @@ func (a *App) mustStop() {
 		a.log.Error("Closing connection to api server", a.log.Attr("error", err))
 	}
 
-	if err := a.cmd.StopAllRunningScripts(ctx); err != nil {
+	if err := a.cmd.StopScheduler(ctx); err != nil {
+		a.log.Error("Stopping scheduler", a.log.Attr("error", err))
+	}
+
+	cmdCtx, cmdCancel := context.WithTimeout(context.Background(), a.cfg.CtxTimeout)
+	defer cmdCancel()
+
+	if err := a.cmd.StopAllRunningScripts(cmdCtx); err != nil {
 		a.log.Error("Stopping running commands", a.log.Attr("error", err))
 	}
 
@@ -15,6 +15,8 @@ type Config struct {
 	Storage    Postgres       `yaml:"psql_storage"`
 	Server     ApiServer      `yaml:"api_server"`
 	Frontend   FrontendServer `yaml:"frontend"`
+	Commander  Commander      `yaml:"commander"`
+	Executor   Executor       `yaml:"executor"`
 }
 
 type Postgres struct {
@@ -37,6 +39,91 @@ type FrontendServer struct {
 	Domains []string `yaml:"domains" env-required:"true"`
 }
 
+type Commander struct {
+	MaxParallel    int           `yaml:"max_parallel" env-default:"8"`
+	DefaultTimeout time.Duration `yaml:"default_timeout" env-default:"10m"`
+	MaxTimeout     time.Duration `yaml:"max_timeout" env-default:"1h"`
+	DefaultLimits  Limits        `yaml:"default_limits"`
+	MaxLimits      Limits        `yaml:"max_limits"`
+	AllowedEnv     []string      `yaml:"allowed_env"`
+	DeniedEnv      []string      `yaml:"denied_env" env-default:"LD_*,BASH_*,ENV,IFS,PATH,SHELLOPTS,PS4"`
+	BaseDirs       []string      `yaml:"base_dirs"`
+	Workspace      Workspace     `yaml:"workspace"`
+	// Interpreters maps names to invocation templates, {script} is replaced with the script's body
+	// and positional arguments are appended. Only bash and sh are available if it's empty
+	Interpreters       map[string][]string `yaml:"interpreters"`
+	DefaultInterpreter string              `yaml:"default_interpreter" env-default:"bash"`
+	// UnlimitedAddressSpace are interpreters whose address space isn't limited, like node with V8
+	// which reserves much more than it uses. Their memory is limited only by the cgroup of the executor,
+	// so they require the cgroup
+	UnlimitedAddressSpace []string `yaml:"unlimited_address_space"`
+	// ScheduleTimezone is the location of cron expressions of schedules
+	ScheduleTimezone string       `yaml:"schedule_timezone" env-default:"UTC"`
+	OutputBatch      OutputBatch  `yaml:"output_batch"`
+	OutputLimits     OutputLimits `yaml:"output_limits"`
+	Artifacts        Artifacts    `yaml:"artifacts"`
+}
+
+// Artifacts are files collected from workspaces of finished commands and kept in the root directory.
+// Empty root means the directory in the system temporary one ...
+type Artifacts struct {
+	Root      string `yaml:"root"`
+	MaxFiles  int    `yaml:"max_files" env-default:"32"`
+	MaxFileMB int64  `yaml:"max_file_mb" env-default:"100"`
+}
+
+// OutputLimits caps the output saved for every command, zero value means no limit.
+// Policy is what happens when a limit is reached: "stop" stops the command,
+// "drop" drops new lines and "head_tail" keeps the head and the tail of the output ...
+type OutputLimits struct {
+	MaxBytes int64  `yaml:"max_bytes"`
+	MaxLines int64  `yaml:"max_lines"`
+	Policy   string `yaml:"policy" env-default:"drop"`
+}
+
+// OutputBatch is how the script's output is buffered before it's saved.
+// Lines are flushed when the batch is full or the interval elapses ...
+type OutputBatch struct {
+	Size     int           `yaml:"size" env-default:"500"`
+	Interval time.Duration `yaml:"interval" env-default:"200ms"`
+}
+
+// Workspace is the temporary directory created for every command.
+// It is removed after the command is finished or after retention period.
+// Empty root means the directory in the system temporary one ...
+type Workspace struct {
+	Root      string        `yaml:"root"`
+	Retention time.Duration `yaml:"retention"`
+}
+
+// Limits of the script's resources, zero value means no limit.
+// Processes limit requires the cgroup of the executor ...
+type Limits struct {
+	CPUTime   time.Duration `yaml:"cpu_time"`
+	MemoryMB  int64         `yaml:"memory_mb"`
+	OpenFiles int64         `yaml:"open_files"`
+	Processes int64         `yaml:"processes"`
+}
+
+type Executor struct {
+	GracePeriod time.Duration `yaml:"grace_period" env-default:"5s"`
+	Sandbox     Sandbox       `yaml:"sandbox"`
+	// MaxLineBytes is the longest line of the script's output, longer lines are split
+	MaxLineBytes int `yaml:"max_line_bytes" env-default:"65536"`
+	// Cgroup is the directory of cgroup v2 delegated to the server with enabled memory and pids controllers.
+	// Every script runs in its own child cgroup which applies memory and processes limits
+	Cgroup string `yaml:"cgroup"`
+}
+
+// Sandbox runs scripts in new namespaces, it requires CAP_SYS_ADMIN
+// or enabled user namespaces and setpriv to drop capabilities of the script ...
+type Sandbox struct {
+	Enabled       bool   `yaml:"enabled"`
+	NoNetwork     bool   `yaml:"no_network"`
+	UserNamespace bool   `yaml:"user_namespace"`
+	TmpfsSize     string `yaml:"tmpfs_size" env-default:"64m"`
+}
+
 // MustLoad load data from file and environment variables.
 // It exit if an error happened ...
 func MustLoad() *Config {
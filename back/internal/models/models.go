@@ -1,18 +1,221 @@
 package models
 
-import (
-	"os"
+import "time"
+
+const (
+	StreamStdout = "stdout"
+	StreamStderr = "stderr"
+)
+
+const (
+	EventOutput = "output"
+	EventStatus = "status"
 )
 
+const (
+	StatusQueued    = "queued"
+	StatusRunning   = "running"
+	StatusSucceeded = "succeeded"
+	StatusFailed    = "failed"
+	StatusStopped   = "stopped"
+	StatusTimedOut  = "timed_out"
+
+	StatusLimitExceeded = "limit_exceeded"
+)
+
+const (
+	LimitCPUTime   = "cpu_time"
+	LimitMemory    = "memory"
+	LimitOpenFiles = "open_files"
+	LimitProcesses = "processes"
+	LimitOutput    = "output"
+)
+
+// Script describes what and how should be executed.
+// Secret environment variables aren't kept with the command ...
 type Script struct {
-	Name string
-	Cmd  os.File
+	Name      string
+	Body      string
+	SecretEnv map[string]string
+	Workspace string
+	// TemplateID is the id of the template which the script was made from
+	TemplateID int64
+	// ParentID is the id of the command which the script is rerun of
+	ParentID int64
+	// Invocation is the command line of the interpreter with the script's body
+	Invocation []string
+	// UnlimitedAddressSpace is set if the interpreter reserves much more address space than it uses,
+	// memory of the script is limited only by the cgroup then
+	UnlimitedAddressSpace bool
+	// ShebangInterpreter is the interpreter named by the script's shebang line,
+	// it's used if no interpreter is set and it's known, the default one is used otherwise
+	ShebangInterpreter string
+	Options
+}
+
+// Options describes how the script should be executed.
+// They are kept with the command to run the queued script later ...
+type Options struct {
+	Interactive bool              `json:"interactive,omitempty"`
+	Timeout     time.Duration     `json:"timeout,omitempty"`
+	Limits      Limits            `json:"limits"`
+	Env         map[string]string `json:"env,omitempty"`
+	Args        []string          `json:"args,omitempty"`
+	WorkingDir  string            `json:"working_dir,omitempty"`
+	Interpreter string            `json:"interpreter,omitempty"`
+	// Artifacts are glob patterns of files in the workspace which are collected after the command ends
+	Artifacts []string `json:"artifacts,omitempty"`
+}
+
+// Artifact is the file collected from the workspace of the finished command.
+// Name is its path relative to the workspace ...
+type Artifact struct {
+	ID        int64  `json:"id"`
+	CommandID int64  `json:"command_id"`
+	Name      string `json:"name"`
+	Size      int64  `json:"size"`
+	SHA256    string `json:"sha256"`
+	CreatedAt string `json:"created_at"`
+}
+
+// Limits describes resources available for the script's processes.
+// Zero value means that the resource isn't limited ...
+type Limits struct {
+	CPUTime   time.Duration `json:"cpu_time,omitempty"`
+	MemoryMB  int64         `json:"memory_mb,omitempty"`
+	OpenFiles int64         `json:"open_files,omitempty"`
+	Processes int64         `json:"processes,omitempty"`
+}
+
+type Output struct {
+	ID     int64  `json:"-"`
+	Line   int64  `json:"line,omitempty"` // Number of the line in the command's output starting from 1
+	Stream string `json:"stream"`
+	Text   string `json:"text"`
+	// Encoding is base64 if the line isn't valid UTF-8 text,
+	// Partial means the line was longer than the limit and continues in the next one
+	Encoding string `json:"encoding,omitempty"`
+	Partial  bool   `json:"partial,omitempty"`
+	// At is when the line was captured, Elapsed is seconds from the start of the run to the line.
+	// Lines saved before timestamps were recorded have neither ...
+	At      *time.Time `json:"at,omitempty"`
+	Elapsed *float64   `json:"elapsed,omitempty"`
+}
+
+const EncodingBase64 = "base64"
+
+const (
+	TimeAbsolute = "absolute"
+	TimeRelative = "relative"
+)
+
+// OutputRange selects lines of the command's output starting from the line Since.
+// Tail selects the last lines of the range instead of Offset ...
+type OutputRange struct {
+	Since  int64
+	Offset int64
+	Limit  int64
+	Tail   int64
 }
 
 type Command struct {
-	ID        int64    `json:"id"`
-	Name      string   `json:"command_name"`
-	StartedAt string   `json:"created_at"`
-	Output    []string `json:"output,omitempty"`
-	IsWorking bool     `json:"is_working"`
+	ID            int64             `json:"id"`
+	Name          string            `json:"command_name"`
+	Script        string            `json:"script,omitempty"`
+	ScriptHash    string            `json:"script_hash,omitempty"`
+	Interpreter   string            `json:"interpreter,omitempty"`
+	TemplateID    int64             `json:"template_id,omitempty"`
+	ParentID      int64             `json:"parent_id,omitempty"`
+	Reruns        []int64           `json:"reruns,omitempty"`
+	Options       *Options          `json:"-"`
+	Env           map[string]string `json:"env,omitempty"`
+	Args          []string          `json:"args,omitempty"`
+	WorkingDir    string            `json:"working_dir,omitempty"`
+	StartedAt     string            `json:"created_at"`
+	FinishedAt    string            `json:"finished_at,omitempty"`
+	Status        string            `json:"status"`
+	QueuePosition int64             `json:"queue_position,omitempty"`
+	ExitCode      *int              `json:"exit_code,omitempty"`
+	Signal        string            `json:"signal,omitempty"`
+	Limit         string            `json:"exceeded_limit,omitempty"`
+	Truncated     bool              `json:"truncated,omitempty"` // Some lines of the output weren't saved
+	Output        []Output          `json:"output,omitempty"`
+	IsWorking     bool              `json:"is_working"`
+}
+
+// CommandFilter selects the page of commands ordered from the newest one.
+// Cursor is the id of the last command of the previous page, zero means the first page.
+// Query is the full-text search over the script and the output ...
+type CommandFilter struct {
+	Limit     int64
+	Cursor    int64
+	Statuses  []string
+	From      time.Time
+	To        time.Time
+	IsWorking *bool
+	Query     string
+}
+
+// Result describes how the command's execution has ended ...
+type Result struct {
+	Status   string `json:"status"`
+	ExitCode *int   `json:"exit_code,omitempty"`
+	Signal   string `json:"signal,omitempty"`
+	Limit    string `json:"exceeded_limit,omitempty"`
+}
+
+// Event is a notification about running command.
+// It carries either a new output line or the result of execution ...
+type Event struct {
+	ID     int64
+	Type   string
+	Output *Output
+	Result *Result
+}
+
+const (
+	ParamString = "string"
+	ParamInt    = "int"
+	ParamEnum   = "enum"
+	ParamBool   = "bool"
+)
+
+// Template is the saved script which can be run with parameters ...
+type Template struct {
+	ID          int64
+	Name        string
+	Description string
+	Script      string
+	Params      []Param
+	Options     Options
+	CreatedAt   string
+	UpdatedAt   string
+}
+
+// Param is the declared parameter of the template.
+// Its value is passed to the script as environment variable with the same name ...
+type Param struct {
+	Name        string   `json:"name"`
+	Type        string   `json:"type"`
+	Description string   `json:"description,omitempty"`
+	Required    bool     `json:"required,omitempty"`
+	Default     string   `json:"default,omitempty"`
+	Values      []string `json:"values,omitempty"`
+}
+
+// Schedule runs the script or the template with parameters by cron expression.
+// Schedule with no overlap skips the run while the previous command is still working ...
+type Schedule struct {
+	ID            int64
+	Name          string
+	Spec          string
+	Script        string
+	TemplateID    int64
+	Params        map[string]any
+	Options       Options
+	NoOverlap     bool
+	Paused        bool
+	LastCommandID int64
+	CreatedAt     string
+	UpdatedAt     string
 }
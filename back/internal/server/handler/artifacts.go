@@ -0,0 +1,166 @@
+package handler
+
+import (
+	"context"
+	"errors"
+	"mime"
+	"net/http"
+	"path"
+	"strconv"
+	"time"
+
+	"github.com/enchik0reo/commandApi/internal/services"
+
+	"github.com/go-chi/chi"
+)
+
+// artifacts godoc
+// @Summary Show artifacts of command
+// @Description Show files collected from the workspace of the command by its artifact patterns, ordered by name.
+// @Description Name is the path of the file relative to the workspace.
+// @Tags  commands
+// @Produce  json
+// @Param id path int true  "Command id"
+// @Success 200 {object} artifactsRespOK "Sucess"
+// @Failure 400 {object} responseErr "Bad request"
+// @Failure 404 {object} responseErr "Command not found"
+// @Failure 500 {object} responseErr "Internal server error"
+// @Router /cmd/{id}/artifacts [get]
+func (h *CustomRouter) artifacts() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+
+		defer r.Body.Close()
+
+		id, err := strconv.Atoi(chi.URLParam(r, "id"))
+		if err != nil {
+			h.log.Debug("Can't convert id to int", h.log.Attr("error", err))
+
+			err = responseJSONError(w, http.StatusBadRequest, http.StatusText(http.StatusBadRequest))
+			if err != nil {
+				h.log.Error("Can't make response", h.log.Attr("error", err))
+			}
+			return
+		}
+
+		ctx, cancel := context.WithTimeout(context.Background(), h.timeout)
+		defer cancel()
+
+		artifacts, err := h.cmdr.GetArtifactList(ctx, int64(id))
+		if err != nil {
+			switch {
+			case errors.Is(err, services.ErrCommandNotFound):
+				h.log.Debug("Can't get artifacts of command", h.log.Attr("command_id", id), h.log.Attr("error", err))
+
+				err = responseJSONError(w, http.StatusNotFound, services.ErrCommandNotFound.Error())
+				if err != nil {
+					h.log.Error("Can't make response", h.log.Attr("error", err))
+				}
+				return
+
+			default:
+				h.log.Error("Can't get artifacts of command", h.log.Attr("command_id", id), h.log.Attr("error", err))
+
+				err = responseJSONError(w, http.StatusInternalServerError, http.StatusText(http.StatusInternalServerError))
+				if err != nil {
+					h.log.Error("Can't make response", h.log.Attr("error", err))
+				}
+				return
+			}
+		}
+
+		respBody := artifactsRespBodyOK{
+			CommandID: int64(id),
+			Artifacts: artifacts,
+		}
+
+		if err = artifactsRespJSONOk(w, http.StatusOK, respBody); err != nil {
+			h.log.Error("Can't make response", h.log.Attr("error", err))
+		}
+	}
+}
+
+// artifact godoc
+// @Summary Download artifact of command
+// @Description Download the file collected from the workspace of the command, range requests are supported.
+// @Tags  commands
+// @Produce  octet-stream
+// @Param id path int true  "Command id"
+// @Param artifactID path int true  "Artifact id"
+// @Success 200 {file} file "Artifact file"
+// @Failure 400 {object} responseErr "Bad request"
+// @Failure 404 {object} responseErr "Artifact not found"
+// @Failure 500 {object} responseErr "Internal server error"
+// @Router /cmd/{id}/artifacts/{artifactID} [get]
+func (h *CustomRouter) artifact() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+
+		defer r.Body.Close()
+
+		id, err := strconv.Atoi(chi.URLParam(r, "id"))
+		if err != nil {
+			h.log.Debug("Can't convert id to int", h.log.Attr("error", err))
+
+			err = responseJSONError(w, http.StatusBadRequest, http.StatusText(http.StatusBadRequest))
+			if err != nil {
+				h.log.Error("Can't make response", h.log.Attr("error", err))
+			}
+			return
+		}
+
+		artifactID, err := strconv.Atoi(chi.URLParam(r, "artifactID"))
+		if err != nil {
+			h.log.Debug("Can't convert artifact id to int", h.log.Attr("error", err))
+
+			err = responseJSONError(w, http.StatusBadRequest, http.StatusText(http.StatusBadRequest))
+			if err != nil {
+				h.log.Error("Can't make response", h.log.Attr("error", err))
+			}
+			return
+		}
+
+		ctx, cancel := context.WithTimeout(context.Background(), h.timeout)
+		defer cancel()
+
+		artifact, file, err := h.cmdr.OpenArtifact(ctx, int64(id), int64(artifactID))
+		if err != nil {
+			switch {
+			case errors.Is(err, services.ErrArtifactNotFound):
+				h.log.Debug("Can't get artifact of command", h.log.Attr("command_id", id), h.log.Attr("error", err))
+
+				err = responseJSONError(w, http.StatusNotFound, services.ErrArtifactNotFound.Error())
+				if err != nil {
+					h.log.Error("Can't make response", h.log.Attr("error", err))
+				}
+				return
+
+			default:
+				h.log.Error("Can't get artifact of command", h.log.Attr("command_id", id), h.log.Attr("error", err))
+
+				err = responseJSONError(w, http.StatusInternalServerError, http.StatusText(http.StatusInternalServerError))
+				if err != nil {
+					h.log.Error("Can't make response", h.log.Attr("error", err))
+				}
+				return
+			}
+		}
+		defer file.Close()
+
+		rc := http.NewResponseController(w)
+
+		if err := rc.SetWriteDeadline(time.Time{}); err != nil {
+			h.log.Debug("Can't reset write deadline for download", h.log.Attr("error", err))
+		}
+
+		name := path.Base(artifact.Name)
+
+		w.Header().Set("Content-Type", "application/octet-stream")
+		w.Header().Set("Content-Disposition", mime.FormatMediaType("attachment", map[string]string{"filename": name}))
+
+		modTime := time.Time{}
+		if info, err := file.Stat(); err == nil {
+			modTime = info.ModTime()
+		}
+
+		http.ServeContent(w, r, name, modTime, file)
+	}
+}
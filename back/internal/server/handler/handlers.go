@@ -4,16 +4,52 @@ import (
 	"context"
 	"encoding/json"
 	"errors"
+	"fmt"
 	"io"
 	"net/http"
+	"path"
 	"strconv"
 	"strings"
+	"time"
 
+	"github.com/enchik0reo/commandApi/internal/models"
 	"github.com/enchik0reo/commandApi/internal/services"
+
+	"github.com/go-chi/chi"
+	"github.com/go-chi/chi/middleware"
+	"github.com/gorilla/websocket"
 )
 
+const streamHeartbeat = 15 * time.Second
+
 type createRequest struct {
-	Script string `json:"script"`
+	Script      string            `json:"script"`
+	Interactive bool              `json:"interactive"`
+	Timeout     int64             `json:"timeout"` // Seconds, zero means the default timeout
+	Limits      limitsRequest     `json:"limits"`
+	Env         map[string]string `json:"env"`
+	SecretEnv   map[string]string `json:"secret_env"` // Isn't stored with the command
+	Args        []string          `json:"args"`
+	WorkingDir  string            `json:"working_dir"`
+	Interpreter string            `json:"interpreter"` // Empty means the default interpreter
+	Artifacts   []string          `json:"artifacts"`   // Glob patterns of files collected from the workspace
+}
+
+// limitsRequest keeps resource limits of the script, zero means the default limit.
+type limitsRequest struct {
+	CPUTime   int64 `json:"cpu_time"` // Seconds
+	MemoryMB  int64 `json:"memory_mb"`
+	OpenFiles int64 `json:"open_files"`
+	Processes int64 `json:"processes"`
+}
+
+func (l limitsRequest) limits() models.Limits {
+	return models.Limits{
+		CPUTime:   time.Duration(l.CPUTime) * time.Second,
+		MemoryMB:  l.MemoryMB,
+		OpenFiles: l.OpenFiles,
+		Processes: l.Processes,
+	}
 }
 
 // create godoc
@@ -48,15 +84,40 @@ func (h *CustomRouter) create() http.HandlerFunc {
 		ctx, cancel := context.WithTimeout(context.Background(), h.timeout)
 		defer cancel()
 
-		id, err := h.cmdr.CreateNewCommand(ctx, req.Script)
+		id, err := h.cmdr.CreateNewCommand(ctx, models.Script{
+			Body:      req.Script,
+			SecretEnv: req.SecretEnv,
+			Options: models.Options{
+				Interactive: req.Interactive,
+				Timeout:     time.Duration(req.Timeout) * time.Second,
+				Limits:      req.Limits.limits(),
+				Env:         req.Env,
+				Args:        req.Args,
+				WorkingDir:  req.WorkingDir,
+				Interpreter: req.Interpreter,
+				Artifacts:   req.Artifacts,
+			},
+		})
 		if err != nil {
-			h.log.Error("Can't create new command", h.log.Attr("error", err))
+			switch {
+			case errors.Is(err, services.ErrInvalidOptions):
+				h.log.Debug("Can't create new command", h.log.Attr("error", err))
 
-			err = responseJSONError(w, http.StatusInternalServerError, http.StatusText(http.StatusInternalServerError))
-			if err != nil {
-				h.log.Error("Can't make response", h.log.Attr("error", err))
+				err = responseJSONError(w, http.StatusBadRequest, err.Error())
+				if err != nil {
+					h.log.Error("Can't make response", h.log.Attr("error", err))
+				}
+				return
+
+			default:
+				h.log.Error("Can't create new command", h.log.Attr("error", err))
+
+				err = responseJSONError(w, http.StatusInternalServerError, http.StatusText(http.StatusInternalServerError))
+				if err != nil {
+					h.log.Error("Can't make response", h.log.Attr("error", err))
+				}
+				return
 			}
-			return
 		}
 
 		respBody := idRespBodyOK{
@@ -76,6 +137,18 @@ func (h *CustomRouter) create() http.HandlerFunc {
 // @Accept multipart/form-data
 // @Produce  json
 // @Param file formData file true "Upload file"
+// @Param interactive formData bool false "Script reads stdin from attached client"
+// @Param timeout formData int false "Execution timeout in seconds"
+// @Param cpu_time formData int false "CPU time limit in seconds"
+// @Param memory_mb formData int false "Memory limit in megabytes"
+// @Param open_files formData int false "Open files limit"
+// @Param processes formData int false "Processes limit"
+// @Param env formData []string false "Environment variable as NAME=value" collectionFormat(multi)
+// @Param secret_env formData []string false "Environment variable which isn't stored, as NAME=value" collectionFormat(multi)
+// @Param args formData []string false "Positional argument of the script" collectionFormat(multi)
+// @Param working_dir formData string false "Working directory inside one of base directories, the workspace by default"
+// @Param interpreter formData string false "Interpreter of the script, it's detected by known shebang line by default"
+// @Param artifacts formData []string false "Glob pattern of files collected from the workspace" collectionFormat(multi)
 // @Success 201 {object} idRespOK "Sucess"
 // @Failure 400 {object} responseErr "Bad request"
 // @Failure 500 {object} responseErr "Internal server error"
@@ -111,17 +184,88 @@ func (h *CustomRouter) createUpload() http.HandlerFunc {
 		ctx, cancel := context.WithTimeout(context.Background(), h.timeout)
 		defer cancel()
 
-		id, err := h.cmdr.CreateNewCommand(ctx, string(data))
+		interactive, _ := strconv.ParseBool(r.FormValue("interactive"))
+
+		var timeout int64
+		limits := limitsRequest{}
+
+		for name, v := range map[string]*int64{
+			"timeout":    &timeout,
+			"cpu_time":   &limits.CPUTime,
+			"memory_mb":  &limits.MemoryMB,
+			"open_files": &limits.OpenFiles,
+			"processes":  &limits.Processes,
+		} {
+			if *v, err = formInt(r, name); err != nil {
+				h.log.Debug("Can't convert form value to int", h.log.Attr("name", name), h.log.Attr("error", err))
+
+				err = responseJSONError(w, http.StatusBadRequest, http.StatusText(http.StatusBadRequest))
+				if err != nil {
+					h.log.Error("Can't make response", h.log.Attr("error", err))
+				}
+				return
+			}
+		}
+
+		env, err := formEnv(r, "env")
 		if err != nil {
-			h.log.Error("Can't create new command", h.log.Attr("error", err))
+			h.log.Debug("Can't parse environment variables", h.log.Attr("error", err))
 
-			err = responseJSONError(w, http.StatusInternalServerError, http.StatusText(http.StatusInternalServerError))
+			err = responseJSONError(w, http.StatusBadRequest, http.StatusText(http.StatusBadRequest))
+			if err != nil {
+				h.log.Error("Can't make response", h.log.Attr("error", err))
+			}
+			return
+		}
+
+		secretEnv, err := formEnv(r, "secret_env")
+		if err != nil {
+			h.log.Debug("Can't parse environment variables", h.log.Attr("error", err))
+
+			err = responseJSONError(w, http.StatusBadRequest, http.StatusText(http.StatusBadRequest))
 			if err != nil {
 				h.log.Error("Can't make response", h.log.Attr("error", err))
 			}
 			return
 		}
 
+		id, err := h.cmdr.CreateNewCommand(ctx, models.Script{
+			Body:               string(data),
+			SecretEnv:          secretEnv,
+			ShebangInterpreter: shebangInterpreter(string(data)),
+			Options: models.Options{
+				Interactive: interactive,
+				Timeout:     time.Duration(timeout) * time.Second,
+				Limits:      limits.limits(),
+				Env:         env,
+				Args:        r.Form["args"],
+				WorkingDir:  r.FormValue("working_dir"),
+				Interpreter: r.FormValue("interpreter"),
+				Artifacts:   r.Form["artifacts"],
+			},
+		})
+		if err != nil {
+			switch {
+			case errors.Is(err, services.ErrInvalidOptions):
+				h.log.Debug("Can't create new command", h.log.Attr("error", err))
+
+				err = responseJSONError(w, http.StatusBadRequest, err.Error())
+				if err != nil {
+					h.log.Error("Can't make response", h.log.Attr("error", err))
+				}
+				return
+
+			default:
+				h.log.Error("Can't create new command", h.log.Attr("error", err))
+
+				err = responseJSONError(w, http.StatusInternalServerError, http.StatusText(http.StatusInternalServerError))
+				if err != nil {
+					h.log.Error("Can't make response", h.log.Attr("error", err))
+				}
+				return
+			}
+		}
+
 		respBody := idRespBodyOK{
 			CommandID: id,
 		}
@@ -134,11 +278,18 @@ func (h *CustomRouter) createUpload() http.HandlerFunc {
 
 // commands godoc
 // @Summary Show commands
-// @Description Show last n commands
+// @Description Show the page of latest commands matching filters.
+// @Description Pass next_cursor of the response as cursor to get the next page.
 // @Tags  commands
 // @Accept  json
 // @Produce  json
 // @Param limit query int true  "Limit for commands"
+// @Param cursor query int false  "Cursor of the page"
+// @Param status query string false  "Comma separated statuses"
+// @Param from query string false  "Created at or after, RFC 3339"
+// @Param to query string false  "Created before, RFC 3339"
+// @Param is_working query bool false  "Only working or finished commands"
+// @Param q query string false  "Full-text search over script and output"
 // @Success 200 {object} commandsRespOK "Sucess"
 // @Failure 400 {object} responseErr "Bad request"
 // @Failure 500 {object} responseErr "Internal server error"
@@ -148,11 +299,9 @@ func (h *CustomRouter) commands() http.HandlerFunc {
 
 		defer r.Body.Close()
 
-		l := r.URL.Query().Get("limit")
-
-		limit, err := strconv.Atoi(l)
+		filter, err := commandFilter(r)
 		if err != nil {
-			h.log.Debug("Can't convert limit to int", h.log.Attr("error", err))
+			h.log.Debug("Can't parse filter of commands", h.log.Attr("error", err))
 
 			err = responseJSONError(w, http.StatusBadRequest, http.StatusText(http.StatusBadRequest))
 			if err != nil {
@@ -164,20 +313,32 @@ func (h *CustomRouter) commands() http.HandlerFunc {
 		ctx, cancel := context.WithTimeout(context.Background(), h.timeout)
 		defer cancel()
 
-		cmds, err := h.cmdr.GetCommandList(ctx, int64(limit))
+		cmds, next, err := h.cmdr.GetCommandList(ctx, filter)
 		if err != nil {
-			h.log.Error("Can't get list of commands", h.log.Attr("error", err))
+			switch {
+			case errors.Is(err, services.ErrInvalidOptions):
+				h.log.Debug("Can't get list of commands", h.log.Attr("error", err))
 
-			err = responseJSONError(w, http.StatusInternalServerError, http.StatusText(http.StatusInternalServerError))
-			if err != nil {
-				h.log.Error("Can't make response", h.log.Attr("error", err))
-			}
-			return
+				err = responseJSONError(w, http.StatusBadRequest, err.Error())
+				if err != nil {
+					h.log.Error("Can't make response", h.log.Attr("error", err))
+				}
+				return
+
+			default:
+				h.log.Error("Can't get list of commands", h.log.Attr("error", err))
 
+				err = responseJSONError(w, http.StatusInternalServerError, http.StatusText(http.StatusInternalServerError))
+				if err != nil {
+					h.log.Error("Can't make response", h.log.Attr("error", err))
+				}
+				return
+			}
 		}
 
 		respBody := commandsRespBodyOK{
-			Commands: cmds,
+			Commands:   cmds,
+			NextCursor: next,
 		}
 
 		if err = commandsRespJSONOk(w, http.StatusOK, respBody); err != nil {
@@ -188,13 +349,16 @@ func (h *CustomRouter) commands() http.HandlerFunc {
 
 // command godoc
 // @Summary Show one command
-// @Description Show command description by id
+// @Description Show command description by id with the last 1000 lines of its output.
+// @Description The whole output is read by ranges from /cmd/{id}/output.
 // @Tags  commands
 // @Accept  json
 // @Produce  json
 // @Param id query int true  "Command id"
+// @Param time query string false  "View of output timestamps, capture time by default or seconds from the start of the run" Enums(absolute, relative)
 // @Success 200 {object} commandRespOK "Sucess"
 // @Failure 400 {object} responseErr "Bad request"
+// @Failure 404 {object} responseErr "Command not found"
 // @Failure 500 {object} responseErr "Internal server error"
 // @Router /cmd [get]
 func (h *CustomRouter) command() http.HandlerFunc {
@@ -215,21 +379,45 @@ func (h *CustomRouter) command() http.HandlerFunc {
 			return
 		}
 
-		ctx, cancel := context.WithTimeout(context.Background(), h.timeout)
-		defer cancel()
-
-		cmd, err := h.cmdr.GetOneCommandDescription(ctx, int64(id))
+		view, err := timeView(r)
 		if err != nil {
-			h.log.Error("Can't get command's description", h.log.Attr("error", err))
+			h.log.Debug("Can't parse time view", h.log.Attr("error", err))
 
-			err = responseJSONError(w, http.StatusInternalServerError, http.StatusText(http.StatusInternalServerError))
+			err = responseJSONError(w, http.StatusBadRequest, err.Error())
 			if err != nil {
 				h.log.Error("Can't make response", h.log.Attr("error", err))
 			}
 			return
+		}
+
+		ctx, cancel := context.WithTimeout(context.Background(), h.timeout)
+		defer cancel()
+
+		cmd, err := h.cmdr.GetOneCommandDescription(ctx, int64(id))
+		if err != nil {
+			switch {
+			case errors.Is(err, services.ErrCommandNotFound):
+				h.log.Debug("Can't get command's description", h.log.Attr("command_id", id), h.log.Attr("error", err))
 
+				err = responseJSONError(w, http.StatusNotFound, services.ErrCommandNotFound.Error())
+				if err != nil {
+					h.log.Error("Can't make response", h.log.Attr("error", err))
+				}
+				return
+
+			default:
+				h.log.Error("Can't get command's description", h.log.Attr("error", err))
+
+				err = responseJSONError(w, http.StatusInternalServerError, http.StatusText(http.StatusInternalServerError))
+				if err != nil {
+					h.log.Error("Can't make response", h.log.Attr("error", err))
+				}
+				return
+			}
 		}
 
+		applyTimeView(cmd.Output, view)
+
 		respBody := commandRespBodyOK{
 			CommandDescription: cmd,
 		}
@@ -240,31 +428,41 @@ func (h *CustomRouter) command() http.HandlerFunc {
 	}
 }
 
-type stopCommandRequest struct {
-	ID string `json:"id"`
-}
-
-// stopCommand godoc
-// @Summary Stop one command
-// @Description Stop command's execution by id
+// streamCommand godoc
+// @Summary Stream command's output
+// @Description Stream stored and live output of the command as Server-Sent Events.
+// @Description Every output line is sent as "output" event, the stream ends with "status" event.
 // @Tags  commands
-// @Accept  json
-// @Produce  json
-// @Param id body stopCommandRequest true "Command id"
-// @Success 202 {object} idRespOK "Sucess"
-// @Failure 304 {object} responseErr "Not Modified"
+// @Produce  text/event-stream
+// @Param id path int true  "Command id"
+// @Param Last-Event-ID header int false  "Line number of the last received output event"
+// @Success 200 {string} string "Event stream"
 // @Failure 400 {object} responseErr "Bad request"
+// @Failure 404 {object} responseErr "Command not found"
 // @Failure 500 {object} responseErr "Internal server error"
-// @Router /stop [put]
-func (h *CustomRouter) stopCommand() http.HandlerFunc {
+// @Router /cmd/{id}/stream [get]
+func (h *CustomRouter) streamCommand() http.HandlerFunc {
 	return func(w http.ResponseWriter, r *http.Request) {
 
 		defer r.Body.Close()
-		req := stopCommandRequest{}
 
-		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
-			if !strings.Contains(err.Error(), "EOF") {
-				h.log.Debug("Can't decode body from stop command request", h.log.Attr("error", err))
+		id, err := strconv.Atoi(chi.URLParam(r, "id"))
+		if err != nil {
+			h.log.Debug("Can't convert id to int", h.log.Attr("error", err))
+
+			err = responseJSONError(w, http.StatusBadRequest, http.StatusText(http.StatusBadRequest))
+			if err != nil {
+				h.log.Error("Can't make response", h.log.Attr("error", err))
+			}
+			return
+		}
+
+		var after int64
+
+		if lastID := r.Header.Get("Last-Event-ID"); lastID != "" {
+			after, err = strconv.ParseInt(lastID, 10, 64)
+			if err != nil {
+				h.log.Debug("Can't convert last event id to int", h.log.Attr("error", err))
 
 				err = responseJSONError(w, http.StatusBadRequest, http.StatusText(http.StatusBadRequest))
 				if err != nil {
@@ -274,10 +472,99 @@ func (h *CustomRouter) stopCommand() http.HandlerFunc {
 			}
 		}
 
-		ctx, cancel := context.WithTimeout(context.Background(), h.timeout)
-		defer cancel()
+		events, err := h.cmdr.StreamCommand(r.Context(), int64(id), after)
+		if err != nil {
+			switch {
+			case errors.Is(err, services.ErrCommandNotFound):
+				h.log.Debug("Can't stream command's output", h.log.Attr("command_id", id), h.log.Attr("error", err))
 
-		id, err := strconv.Atoi(req.ID)
+				err = responseJSONError(w, http.StatusNotFound, services.ErrCommandNotFound.Error())
+				if err != nil {
+					h.log.Error("Can't make response", h.log.Attr("error", err))
+				}
+				return
+
+			default:
+				h.log.Error("Can't stream command's output", h.log.Attr("error", err))
+
+				err = responseJSONError(w, http.StatusInternalServerError, http.StatusText(http.StatusInternalServerError))
+				if err != nil {
+					h.log.Error("Can't make response", h.log.Attr("error", err))
+				}
+				return
+			}
+		}
+
+		rc := http.NewResponseController(w)
+
+		if err := rc.SetWriteDeadline(time.Time{}); err != nil {
+			h.log.Debug("Can't reset write deadline for stream", h.log.Attr("error", err))
+		}
+
+		w.Header().Set("Content-Type", "text/event-stream")
+		w.Header().Set("Cache-Control", "no-cache")
+		w.Header().Set("Connection", "keep-alive")
+		w.Header().Set("X-Accel-Buffering", "no")
+		w.WriteHeader(http.StatusOK)
+
+		ticker := time.NewTicker(streamHeartbeat)
+		defer ticker.Stop()
+
+		for {
+			if err := rc.Flush(); err != nil {
+				h.log.Debug("Can't flush stream", h.log.Attr("error", err))
+				return
+			}
+
+			select {
+			case ev, open := <-events:
+				if !open {
+					return
+				}
+
+				if err := sseEvent(w, ev); err != nil {
+					h.log.Debug("Can't write event", h.log.Attr("error", err))
+					return
+				}
+			case <-ticker.C:
+				if err := sseHeartbeat(w); err != nil {
+					h.log.Debug("Can't write heartbeat", h.log.Attr("error", err))
+					return
+				}
+			}
+		}
+	}
+}
+
+// commandOutput godoc
+// @Summary Show output of command
+// @Description Show the range of the command's output lines with their numbers.
+// @Description Lines start from the line since, tail selects the last lines instead of offset.
+// @Tags  commands
+// @Produce  json
+// @Param id path int true  "Command id"
+// @Param since query int false  "Number of the first line"
+// @Param offset query int false  "Count of lines to skip"
+// @Param limit query int false  "Count of lines, 1000 by default, 10000 at most"
+// @Param tail query int false  "Count of the last lines"
+// @Param time query string false  "View of output timestamps, capture time by default or seconds from the start of the run" Enums(absolute, relative)
+// @Success 200 {object} outputRespOK "Sucess"
+// @Failure 400 {object} responseErr "Bad request"
+// @Failure 404 {object} responseErr "Command not found"
+// @Failure 500 {object} responseErr "Internal server error"
+// @Router /cmd/{id}/output [get]
+func (h *CustomRouter) commandOutput() http.HandlerFunc {
+	download := h.downloadOutput()
+
+	return func(w http.ResponseWriter, r *http.Request) {
+		if format, _ := r.Context().Value(middleware.URLFormatCtxKey).(string); format != "" {
+			download(w, r)
+			return
+		}
+
+		defer r.Body.Close()
+
+		id, err := strconv.Atoi(chi.URLParam(r, "id"))
 		if err != nil {
 			h.log.Debug("Can't convert id to int", h.log.Attr("error", err))
 
@@ -288,20 +575,54 @@ func (h *CustomRouter) stopCommand() http.HandlerFunc {
 			return
 		}
 
-		delId, err := h.cmdr.StopCommand(ctx, int64(id))
+		rng, err := outputRange(r)
+		if err != nil {
+			h.log.Debug("Can't parse output range", h.log.Attr("error", err))
+
+			err = responseJSONError(w, http.StatusBadRequest, http.StatusText(http.StatusBadRequest))
+			if err != nil {
+				h.log.Error("Can't make response", h.log.Attr("error", err))
+			}
+			return
+		}
+
+		view, err := timeView(r)
+		if err != nil {
+			h.log.Debug("Can't parse time view", h.log.Attr("error", err))
+
+			err = responseJSONError(w, http.StatusBadRequest, err.Error())
+			if err != nil {
+				h.log.Error("Can't make response", h.log.Attr("error", err))
+			}
+			return
+		}
+
+		ctx, cancel := context.WithTimeout(context.Background(), h.timeout)
+		defer cancel()
+
+		lines, last, err := h.cmdr.GetCommandOutput(ctx, int64(id), rng)
 		if err != nil {
 			switch {
-			case errors.Is(services.ErrNoExecutingCommand, err):
-				h.log.Debug("Can't stop service", h.log.Attr("command_id", req.ID), h.log.Attr("error", err))
+			case errors.Is(err, services.ErrInvalidOptions):
+				h.log.Debug("Can't get output of command", h.log.Attr("command_id", id), h.log.Attr("error", err))
 
-				err = responseJSONError(w, http.StatusNotModified, http.StatusText(http.StatusNotModified))
+				err = responseJSONError(w, http.StatusBadRequest, err.Error())
+				if err != nil {
+					h.log.Error("Can't make response", h.log.Attr("error", err))
+				}
+				return
+
+			case errors.Is(err, services.ErrCommandNotFound):
+				h.log.Debug("Can't get output of command", h.log.Attr("command_id", id), h.log.Attr("error", err))
+
+				err = responseJSONError(w, http.StatusNotFound, services.ErrCommandNotFound.Error())
 				if err != nil {
 					h.log.Error("Can't make response", h.log.Attr("error", err))
 				}
 				return
 
 			default:
-				h.log.Error("Can't stop command", h.log.Attr("error", err))
+				h.log.Error("Can't get output of command", h.log.Attr("command_id", id), h.log.Attr("error", err))
 
 				err = responseJSONError(w, http.StatusInternalServerError, http.StatusText(http.StatusInternalServerError))
 				if err != nil {
@@ -311,12 +632,597 @@ func (h *CustomRouter) stopCommand() http.HandlerFunc {
 			}
 		}
 
-		respBody := idRespBodyOK{
-			CommandID: delId,
+		applyTimeView(lines, view)
+
+		respBody := outputRespBodyOK{
+			CommandID: int64(id),
+			Lines:     lines,
+			LastLine:  last,
 		}
 
-		if err = idRespJSONOk(w, http.StatusAccepted, respBody); err != nil {
+		if err = outputRespJSONOk(w, http.StatusOK, respBody); err != nil {
 			h.log.Error("Can't make response", h.log.Attr("error", err))
 		}
 	}
 }
+
+const (
+	formatText   = "txt"
+	formatNDJSON = "ndjson"
+)
+
+// downloadOutput godoc
+// @Summary Download output of command
+// @Description Download all output of the command as a file, it's compressed with gzip if the client accepts it.
+// @Description Text file has lines as they were printed, every line of NDJSON file is the output line object.
+// @Tags  commands
+// @Produce  plain
+// @Produce  application/x-ndjson
+// @Param id path int true  "Command id"
+// @Success 200 {file} file "Output file"
+// @Failure 400 {object} responseErr "Bad request"
+// @Failure 404 {object} responseErr "Command not found"
+// @Failure 500 {object} responseErr "Internal server error"
+// @Router /cmd/{id}/output.txt [get]
+// @Router /cmd/{id}/output.ndjson [get]
+func (h *CustomRouter) downloadOutput() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+
+		defer r.Body.Close()
+
+		id, err := strconv.Atoi(chi.URLParam(r, "id"))
+		if err != nil {
+			h.log.Debug("Can't convert id to int", h.log.Attr("error", err))
+
+			err = responseJSONError(w, http.StatusBadRequest, http.StatusText(http.StatusBadRequest))
+			if err != nil {
+				h.log.Error("Can't make response", h.log.Attr("error", err))
+			}
+			return
+		}
+
+		format, _ := r.Context().Value(middleware.URLFormatCtxKey).(string)
+
+		if format != formatText && format != formatNDJSON {
+			h.log.Debug("Unknown format of output", h.log.Attr("format", format))
+
+			err = responseJSONError(w, http.StatusNotFound, http.StatusText(http.StatusNotFound))
+			if err != nil {
+				h.log.Error("Can't make response", h.log.Attr("error", err))
+			}
+			return
+		}
+
+		rc := http.NewResponseController(w)
+
+		if err := rc.SetWriteDeadline(time.Time{}); err != nil {
+			h.log.Debug("Can't reset write deadline for download", h.log.Attr("error", err))
+		}
+
+		var file *outputFile
+		var writeErr error
+
+		// Headers are written with the first line, so unknown command still gets the error response.
+		open := func() {
+			if file == nil {
+				file = newOutputFile(w, r, int64(id), format)
+			}
+		}
+
+		err = h.cmdr.EachCommandOutput(r.Context(), int64(id), func(output models.Output) error {
+			open()
+
+			writeErr = file.write(output)
+
+			return writeErr
+		})
+		if err != nil {
+			switch {
+			case writeErr != nil:
+				h.log.Debug("Can't write output file", h.log.Attr("command_id", id), h.log.Attr("error", writeErr))
+				return
+
+			case errors.Is(err, services.ErrCommandNotFound):
+				h.log.Debug("Can't download output of command", h.log.Attr("command_id", id), h.log.Attr("error", err))
+
+				err = responseJSONError(w, http.StatusNotFound, services.ErrCommandNotFound.Error())
+				if err != nil {
+					h.log.Error("Can't make response", h.log.Attr("error", err))
+				}
+				return
+
+			case file != nil:
+				h.log.Error("Can't download output of command", h.log.Attr("command_id", id), h.log.Attr("error", err))
+				return
+
+			default:
+				h.log.Error("Can't download output of command", h.log.Attr("command_id", id), h.log.Attr("error", err))
+
+				err = responseJSONError(w, http.StatusInternalServerError, http.StatusText(http.StatusInternalServerError))
+				if err != nil {
+					h.log.Error("Can't make response", h.log.Attr("error", err))
+				}
+				return
+			}
+		}
+
+		open()
+
+		if err := file.close(); err != nil {
+			h.log.Debug("Can't write output file", h.log.Attr("command_id", id), h.log.Attr("error", err))
+		}
+	}
+}
+
+const (
+	attachInput = "input"
+	attachEOF   = "eof"
+)
+
+type attachRequest struct {
+	Type string `json:"type"`
+	Data string `json:"data,omitempty"`
+}
+
+// attachCommand godoc
+// @Summary Attach to command
+// @Description Attach to the command over WebSocket.
+// @Description Output and status are sent as JSON messages, {"type":"input","data":"..."} writes to stdin
+// @Description and {"type":"eof"} closes stdin of the interactive command.
+// @Tags  commands
+// @Param id path int true  "Command id"
+// @Success 101 {string} string "Switching Protocols"
+// @Failure 400 {object} responseErr "Bad request"
+// @Failure 404 {object} responseErr "Command not found"
+// @Failure 500 {object} responseErr "Internal server error"
+// @Router /cmd/{id}/attach [get]
+func (h *CustomRouter) attachCommand() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+
+		defer r.Body.Close()
+
+		id, err := strconv.Atoi(chi.URLParam(r, "id"))
+		if err != nil {
+			h.log.Debug("Can't convert id to int", h.log.Attr("error", err))
+
+			err = responseJSONError(w, http.StatusBadRequest, http.StatusText(http.StatusBadRequest))
+			if err != nil {
+				h.log.Error("Can't make response", h.log.Attr("error", err))
+			}
+			return
+		}
+
+		ctx, cancel := context.WithCancel(r.Context())
+		defer cancel()
+
+		events, err := h.cmdr.StreamCommand(ctx, int64(id), 0)
+		if err != nil {
+			switch {
+			case errors.Is(err, services.ErrCommandNotFound):
+				h.log.Debug("Can't stream command's output", h.log.Attr("command_id", id), h.log.Attr("error", err))
+
+				err = responseJSONError(w, http.StatusNotFound, services.ErrCommandNotFound.Error())
+				if err != nil {
+					h.log.Error("Can't make response", h.log.Attr("error", err))
+				}
+				return
+
+			default:
+				h.log.Error("Can't stream command's output", h.log.Attr("error", err))
+
+				err = responseJSONError(w, http.StatusInternalServerError, http.StatusText(http.StatusInternalServerError))
+				if err != nil {
+					h.log.Error("Can't make response", h.log.Attr("error", err))
+				}
+				return
+			}
+		}
+
+		conn, err := h.upgrader.Upgrade(w, r, nil)
+		if err != nil {
+			h.log.Debug("Can't upgrade connection", h.log.Attr("error", err))
+			return
+		}
+		defer conn.Close()
+
+		inputErrs := make(chan error)
+
+		go func() {
+			defer cancel()
+
+			for {
+				req := attachRequest{}
+
+				if err := conn.ReadJSON(&req); err != nil {
+					h.log.Debug("Can't read attach message", h.log.Attr("error", err))
+					return
+				}
+
+				var err error
+
+				switch req.Type {
+				case attachInput:
+					err = h.cmdr.WriteInput(ctx, int64(id), []byte(req.Data))
+				case attachEOF:
+					err = h.cmdr.CloseInput(int64(id))
+				default:
+					err = fmt.Errorf("unknown message type: %q", req.Type)
+				}
+
+				if err != nil {
+					select {
+					case inputErrs <- err:
+					case <-ctx.Done():
+						return
+					}
+				}
+			}
+		}()
+
+		for {
+			select {
+			case ev, open := <-events:
+				if !open {
+					msg := websocket.FormatCloseMessage(websocket.CloseNormalClosure, "")
+
+					if err := conn.WriteControl(websocket.CloseMessage, msg, time.Now().Add(h.timeout)); err != nil {
+						h.log.Debug("Can't close connection", h.log.Attr("error", err))
+					}
+					return
+				}
+
+				if err := conn.WriteJSON(attachEventResponse(ev)); err != nil {
+					h.log.Debug("Can't write attach message", h.log.Attr("error", err))
+					return
+				}
+			case err := <-inputErrs:
+				if err := conn.WriteJSON(attachErrorResponse(err)); err != nil {
+					h.log.Debug("Can't write attach message", h.log.Attr("error", err))
+					return
+				}
+			case <-ctx.Done():
+				return
+			}
+		}
+	}
+}
+
+type rerunRequest struct {
+	SecretEnv map[string]string `json:"secret_env"` // Secret variables of the original command aren't stored
+}
+
+// rerunCommand godoc
+// @Summary Rerun command
+// @Description Run new command with the stored script and options of the command by id.
+// @Description The new command is linked to the original one as its parent.
+// @Tags  commands
+// @Accept  json
+// @Produce  json
+// @Param id path int true  "Command id"
+// @Param secrets body rerunRequest false "Secret environment variables"
+// @Success 201 {object} idRespOK "Sucess"
+// @Failure 400 {object} responseErr "Bad request"
+// @Failure 404 {object} responseErr "Command not found"
+// @Failure 500 {object} responseErr "Internal server error"
+// @Router /cmd/{id}/rerun [post]
+func (h *CustomRouter) rerunCommand() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+
+		defer r.Body.Close()
+
+		id, err := strconv.Atoi(chi.URLParam(r, "id"))
+		if err != nil {
+			h.log.Debug("Can't convert id to int", h.log.Attr("error", err))
+
+			err = responseJSONError(w, http.StatusBadRequest, http.StatusText(http.StatusBadRequest))
+			if err != nil {
+				h.log.Error("Can't make response", h.log.Attr("error", err))
+			}
+			return
+		}
+
+		req := rerunRequest{}
+
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			if !strings.Contains(err.Error(), "EOF") {
+				h.log.Debug("Can't decode body from rerun command request", h.log.Attr("error", err))
+
+				err = responseJSONError(w, http.StatusBadRequest, http.StatusText(http.StatusBadRequest))
+				if err != nil {
+					h.log.Error("Can't make response", h.log.Attr("error", err))
+				}
+				return
+			}
+		}
+
+		ctx, cancel := context.WithTimeout(context.Background(), h.timeout)
+		defer cancel()
+
+		newID, err := h.cmdr.RerunCommand(ctx, int64(id), req.SecretEnv)
+		if err != nil {
+			switch {
+			case errors.Is(err, services.ErrInvalidOptions):
+				h.log.Debug("Can't rerun command", h.log.Attr("command_id", id), h.log.Attr("error", err))
+
+				err = responseJSONError(w, http.StatusBadRequest, err.Error())
+				if err != nil {
+					h.log.Error("Can't make response", h.log.Attr("error", err))
+				}
+				return
+
+			case errors.Is(err, services.ErrCommandNotFound):
+				h.log.Debug("Can't rerun command", h.log.Attr("command_id", id), h.log.Attr("error", err))
+
+				err = responseJSONError(w, http.StatusNotFound, services.ErrCommandNotFound.Error())
+				if err != nil {
+					h.log.Error("Can't make response", h.log.Attr("error", err))
+				}
+				return
+
+			default:
+				h.log.Error("Can't rerun command", h.log.Attr("command_id", id), h.log.Attr("error", err))
+
+				err = responseJSONError(w, http.StatusInternalServerError, http.StatusText(http.StatusInternalServerError))
+				if err != nil {
+					h.log.Error("Can't make response", h.log.Attr("error", err))
+				}
+				return
+			}
+		}
+
+		respBody := idRespBodyOK{
+			CommandID: newID,
+		}
+
+		if err = idRespJSONOk(w, http.StatusCreated, respBody); err != nil {
+			h.log.Error("Can't make response", h.log.Attr("error", err))
+		}
+	}
+}
+
+type stopCommandRequest struct {
+	ID     string `json:"id"`
+	Signal string `json:"signal"` // SIGTERM by default, SIGINT, SIGHUP, SIGQUIT and SIGKILL are allowed too
+}
+
+// stopCommand godoc
+// @Summary Stop one command
+// @Description Stop command's execution by id
+// @Tags  commands
+// @Accept  json
+// @Produce  json
+// @Param id body stopCommandRequest true "Command id"
+// @Success 202 {object} idRespOK "Sucess"
+// @Failure 304 {object} responseErr "Not Modified"
+// @Failure 400 {object} responseErr "Bad request"
+// @Failure 500 {object} responseErr "Internal server error"
+// @Router /stop [put]
+func (h *CustomRouter) stopCommand() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+
+		defer r.Body.Close()
+		req := stopCommandRequest{}
+
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			if !strings.Contains(err.Error(), "EOF") {
+				h.log.Debug("Can't decode body from stop command request", h.log.Attr("error", err))
+
+				err = responseJSONError(w, http.StatusBadRequest, http.StatusText(http.StatusBadRequest))
+				if err != nil {
+					h.log.Error("Can't make response", h.log.Attr("error", err))
+				}
+				return
+			}
+		}
+
+		ctx, cancel := context.WithTimeout(context.Background(), h.timeout)
+		defer cancel()
+
+		id, err := strconv.Atoi(req.ID)
+		if err != nil {
+			h.log.Debug("Can't convert id to int", h.log.Attr("error", err))
+
+			err = responseJSONError(w, http.StatusBadRequest, http.StatusText(http.StatusBadRequest))
+			if err != nil {
+				h.log.Error("Can't make response", h.log.Attr("error", err))
+			}
+			return
+		}
+
+		delId, err := h.cmdr.StopCommand(ctx, int64(id), req.Signal)
+		if err != nil {
+			switch {
+			case errors.Is(err, services.ErrInvalidOptions):
+				h.log.Debug("Can't stop command", h.log.Attr("command_id", req.ID), h.log.Attr("error", err))
+
+				err = responseJSONError(w, http.StatusBadRequest, err.Error())
+				if err != nil {
+					h.log.Error("Can't make response", h.log.Attr("error", err))
+				}
+				return
+
+			case errors.Is(services.ErrNoExecutingCommand, err):
+				h.log.Debug("Can't stop service", h.log.Attr("command_id", req.ID), h.log.Attr("error", err))
+
+				err = responseJSONError(w, http.StatusNotModified, http.StatusText(http.StatusNotModified))
+				if err != nil {
+					h.log.Error("Can't make response", h.log.Attr("error", err))
+				}
+				return
+
+			default:
+				h.log.Error("Can't stop command", h.log.Attr("error", err))
+
+				err = responseJSONError(w, http.StatusInternalServerError, http.StatusText(http.StatusInternalServerError))
+				if err != nil {
+					h.log.Error("Can't make response", h.log.Attr("error", err))
+				}
+				return
+			}
+		}
+
+		respBody := idRespBodyOK{
+			CommandID: delId,
+		}
+
+		if err = idRespJSONOk(w, http.StatusAccepted, respBody); err != nil {
+			h.log.Error("Can't make response", h.log.Attr("error", err))
+		}
+	}
+}
+
+// commandFilter returns the filter of commands from the query, the limit is required ...
+func commandFilter(r *http.Request) (models.CommandFilter, error) {
+	var err error
+	q := r.URL.Query()
+	f := models.CommandFilter{Query: strings.TrimSpace(q.Get("q"))}
+
+	if f.Limit, err = strconv.ParseInt(q.Get("limit"), 10, 64); err != nil {
+		return f, fmt.Errorf("invalid limit: %w", err)
+	}
+
+	if c := q.Get("cursor"); c != "" {
+		if f.Cursor, err = strconv.ParseInt(c, 10, 64); err != nil {
+			return f, fmt.Errorf("invalid cursor: %w", err)
+		}
+	}
+
+	for _, s := range q["status"] {
+		for _, status := range strings.Split(s, ",") {
+			if status = strings.TrimSpace(status); status != "" {
+				f.Statuses = append(f.Statuses, status)
+			}
+		}
+	}
+
+	if from := q.Get("from"); from != "" {
+		if f.From, err = time.Parse(time.RFC3339, from); err != nil {
+			return f, fmt.Errorf("invalid from: %w", err)
+		}
+	}
+
+	if to := q.Get("to"); to != "" {
+		if f.To, err = time.Parse(time.RFC3339, to); err != nil {
+			return f, fmt.Errorf("invalid to: %w", err)
+		}
+	}
+
+	if w := q.Get("is_working"); w != "" {
+		working, err := strconv.ParseBool(w)
+		if err != nil {
+			return f, fmt.Errorf("invalid is_working: %w", err)
+		}
+
+		f.IsWorking = &working
+	}
+
+	return f, nil
+}
+
+// outputRange returns the range of output lines from the query ...
+func outputRange(r *http.Request) (models.OutputRange, error) {
+	var err error
+	rng := models.OutputRange{}
+
+	if rng.Since, err = formInt(r, "since"); err != nil {
+		return rng, fmt.Errorf("invalid since: %w", err)
+	}
+
+	if rng.Offset, err = formInt(r, "offset"); err != nil {
+		return rng, fmt.Errorf("invalid offset: %w", err)
+	}
+
+	if rng.Limit, err = formInt(r, "limit"); err != nil {
+		return rng, fmt.Errorf("invalid limit: %w", err)
+	}
+
+	if rng.Tail, err = formInt(r, "tail"); err != nil {
+		return rng, fmt.Errorf("invalid tail: %w", err)
+	}
+
+	return rng, nil
+}
+
+// timeView returns the view of output timestamps from the query, absolute one by default ...
+func timeView(r *http.Request) (string, error) {
+	switch view := r.FormValue("time"); view {
+	case "", models.TimeAbsolute:
+		return models.TimeAbsolute, nil
+	case models.TimeRelative:
+		return view, nil
+	default:
+		return "", fmt.Errorf("invalid time view: %s", view)
+	}
+}
+
+// applyTimeView keeps capture times of the lines in absolute view
+// and seconds from the start of the run in relative one ...
+func applyTimeView(lines []models.Output, view string) {
+	for i := range lines {
+		if view == models.TimeRelative {
+			lines[i].At = nil
+		} else {
+			lines[i].Elapsed = nil
+		}
+	}
+}
+
+// formInt returns integer value of the form field, missing field means zero ...
+func formInt(r *http.Request, name string) (int64, error) {
+	v := r.FormValue(name)
+	if v == "" {
+		return 0, nil
+	}
+
+	return strconv.ParseInt(v, 10, 64)
+}
+
+// formEnv returns environment variables from the form fields formatted as NAME=value ...
+func formEnv(r *http.Request, name string) (map[string]string, error) {
+	values := r.Form[name]
+	if len(values) == 0 {
+		return nil, nil
+	}
+
+	env := make(map[string]string, len(values))
+
+	for _, v := range values {
+		key, value, ok := strings.Cut(v, "=")
+		if !ok {
+			return nil, fmt.Errorf("invalid environment variable: %q", v)
+		}
+
+		env[key] = value
+	}
+
+	return env, nil
+}
+
+// shebangInterpreter returns the interpreter's name from the script's shebang line.
+// Both #!/usr/bin/python3 and #!/usr/bin/env python3 give python3.
+// It returns empty string if there is no shebang line ...
+func shebangInterpreter(script string) string {
+	line, _, _ := strings.Cut(script, "\n")
+
+	line, ok := strings.CutPrefix(line, "#!")
+	if !ok {
+		return ""
+	}
+
+	fields := strings.Fields(line)
+	if len(fields) == 0 {
+		return ""
+	}
+
+	if path.Base(fields[0]) != "env" {
+		return path.Base(fields[0])
+	}
+
+	for _, field := range fields[1:] {
+		if !strings.HasPrefix(field, "-") && !strings.Contains(field, "=") {
+			return path.Base(field)
+		}
+	}
+
+	return ""
+}
@@ -2,12 +2,17 @@ package handler
 
 import (
 	"bytes"
+	"compress/gzip"
+	"context"
 	"encoding/json"
 	"errors"
 	"fmt"
+	"io"
 	"mime/multipart"
 	"net/http"
 	"net/http/httptest"
+	"os"
+	"path/filepath"
 	"strings"
 	"testing"
 	"time"
@@ -16,6 +21,9 @@ import (
 	"github.com/enchik0reo/commandApi/internal/models"
 	"github.com/enchik0reo/commandApi/internal/server/handler/mocks"
 	"github.com/enchik0reo/commandApi/internal/services"
+	"github.com/go-chi/chi"
+	"github.com/go-chi/chi/middleware"
+	"github.com/gorilla/websocket"
 	"github.com/stretchr/testify/mock"
 	"github.com/stretchr/testify/require"
 )
@@ -51,7 +59,7 @@ func TestCustomRouter_create(t *testing.T) {
 				req.Header.Set("Content-Type", "application/json")
 				rr := httptest.NewRecorder()
 
-				fields.Commander.On("CreateNewCommand", mock.Anything, reqBody.Script).Return(int64(1), nil)
+				fields.Commander.On("CreateNewCommand", mock.Anything, models.Script{Body: reqBody.Script}).Return(int64(1), nil)
 
 				return rr, req
 			},
@@ -87,12 +95,33 @@ func TestCustomRouter_create(t *testing.T) {
 				req.Header.Set("Content-Type", "application/json")
 				rr := httptest.NewRecorder()
 
-				fields.Commander.On("CreateNewCommand", mock.Anything, reqBody.Script).
+				fields.Commander.On("CreateNewCommand", mock.Anything, models.Script{Body: reqBody.Script}).
 					Return(int64(0), errors.New("some error in commander"))
 
 				return rr, req
 			},
 		},
+		{
+			name: "test_4, BadRequest with invalid options",
+			want: want{
+				resBody: `{"status":400,"body":{"error":"invalid script options: timeout can't be negative"}}`,
+				status:  http.StatusOK,
+			},
+			calledCommander: true,
+			script:          "whoami",
+			prepare: func(fields fields, reqBody createRequest) (*httptest.ResponseRecorder, *http.Request) {
+				body, _ := json.Marshal(reqBody)
+
+				req := httptest.NewRequest("POST", "/create", strings.NewReader(string(body)))
+				req.Header.Set("Content-Type", "application/json")
+				rr := httptest.NewRecorder()
+
+				fields.Commander.On("CreateNewCommand", mock.Anything, models.Script{Body: reqBody.Script}).
+					Return(int64(0), fmt.Errorf("%w: timeout can't be negative", services.ErrInvalidOptions))
+
+				return rr, req
+			},
+		},
 	}
 
 	for _, tt := range tests {
@@ -122,7 +151,7 @@ func TestCustomRouter_create(t *testing.T) {
 			require.Equal(t, tt.want.resBody, rr.Body.String())
 
 			if tt.calledCommander {
-				if !Commander.AssertCalled(t, "CreateNewCommand", mock.Anything, reqBody.Script) {
+				if !Commander.AssertCalled(t, "CreateNewCommand", mock.Anything, models.Script{Body: reqBody.Script}) {
 					t.Errorf("Expected call Commander")
 				}
 			}
@@ -205,6 +234,30 @@ func TestCustomRouter_createUpload(t *testing.T) {
 				fields.Commander.On("CreateNewCommand", mock.Anything, mock.Anything).
 					Return(int64(0), errors.New("some error"))
 
+				return rr, req
+			},
+		}, {
+			name: "test_4, interpreter from shebang",
+			want: want{
+				resBody: `{"status":201,"body":{"command_id":2}}`,
+				status:  http.StatusOK,
+			},
+			calledCommander: true,
+			prepare: func(fields fields) (*httptest.ResponseRecorder, *http.Request) {
+				body := &bytes.Buffer{}
+				writer := multipart.NewWriter(body)
+				part, _ := writer.CreateFormFile("file", "test.py")
+				part.Write([]byte("#!/usr/bin/env python3\nprint(1)"))
+				writer.Close()
+
+				req := httptest.NewRequest("POST", "/create/upload", body)
+				req.Header.Set("Content-Type", writer.FormDataContentType())
+				rr := httptest.NewRecorder()
+
+				fields.Commander.On("CreateNewCommand", mock.Anything, mock.MatchedBy(func(s models.Script) bool {
+					return s.Interpreter == "" && s.ShebangInterpreter == "python3"
+				})).Return(int64(2), nil)
+
 				return rr, req
 			},
 		},
@@ -307,8 +360,9 @@ func TestCustomRouter_commands(t *testing.T) {
 			name: "test_1, OK",
 			want: want{
 				resBody: "{\"status\":200,\"body\":{\"commands\":" +
-					"[{\"id\":1,\"command_name\":\"whoami\",\"created_at\":\"8:40PM\",\"output\":[\"root\"],\"is_working\":false}," +
-					"{\"id\":2,\"command_name\":\"sleep 1000\",\"created_at\":\"8:40PM\",\"is_working\":true}]}}",
+					"[{\"id\":1,\"command_name\":\"whoami\",\"created_at\":\"8:40PM\",\"finished_at\":\"8:41PM\"," +
+					"\"status\":\"succeeded\",\"exit_code\":0,\"output\":[{\"stream\":\"stdout\",\"text\":\"root\"}],\"is_working\":false}," +
+					"{\"id\":2,\"command_name\":\"sleep 1000\",\"created_at\":\"8:40PM\",\"status\":\"running\",\"is_working\":true}]}}",
 				status: http.StatusOK,
 			},
 			calledCommander: true,
@@ -319,21 +373,25 @@ func TestCustomRouter_commands(t *testing.T) {
 				req.Header.Set("Content-Type", "application/json")
 				rr := httptest.NewRecorder()
 
-				fields.Commander.On("GetCommandList", mock.Anything, limit).Return([]models.Command{
+				fields.Commander.On("GetCommandList", mock.Anything, models.CommandFilter{Limit: limit}).Return([]models.Command{
 					{
-						ID:        1,
-						Name:      "whoami",
-						StartedAt: "8:40PM",
-						Output:    []string{"root"},
-						IsWorking: false,
+						ID:         1,
+						Name:       "whoami",
+						StartedAt:  "8:40PM",
+						FinishedAt: "8:41PM",
+						Status:     models.StatusSucceeded,
+						ExitCode:   new(int),
+						Output:     []models.Output{{Stream: models.StreamStdout, Text: "root"}},
+						IsWorking:  false,
 					},
 					{
 						ID:        2,
 						Name:      "sleep 1000",
 						StartedAt: "8:40PM",
+						Status:    models.StatusRunning,
 						IsWorking: true,
 					},
-				}, nil)
+				}, int64(0), nil)
 
 				return rr, req
 			},
@@ -367,7 +425,8 @@ func TestCustomRouter_commands(t *testing.T) {
 				req.Header.Set("Content-Type", "application/json")
 				rr := httptest.NewRecorder()
 
-				fields.Commander.On("GetCommandList", mock.Anything, limit).Return(nil, errors.New("some error"))
+				fields.Commander.On("GetCommandList", mock.Anything, models.CommandFilter{Limit: limit}).
+					Return(nil, int64(0), errors.New("some error"))
 
 				return rr, req
 			},
@@ -397,7 +456,7 @@ func TestCustomRouter_commands(t *testing.T) {
 			require.Equal(t, tt.want.resBody, rr.Body.String())
 
 			if tt.calledCommander {
-				if !Commander.AssertCalled(t, "GetCommandList", mock.Anything, tt.limit) {
+				if !Commander.AssertCalled(t, "GetCommandList", mock.Anything, models.CommandFilter{Limit: tt.limit}) {
 					t.Errorf("Expected call Commander")
 				}
 			}
@@ -425,7 +484,8 @@ func TestCustomRouter_command(t *testing.T) {
 			name: "test_1, OK",
 			want: want{
 				resBody: "{\"status\":200,\"body\":{\"command\":" +
-					"{\"id\":1,\"command_name\":\"whoami\",\"created_at\":\"8:40PM\",\"output\":[\"root\"],\"is_working\":false}}}",
+					"{\"id\":1,\"command_name\":\"whoami\",\"created_at\":\"8:40PM\",\"finished_at\":\"8:41PM\",\"status\":\"failed\"," +
+					"\"exit_code\":1,\"output\":[{\"stream\":\"stderr\",\"text\":\"whoami: not found\"}],\"is_working\":false}}}",
 				status: http.StatusOK,
 			},
 			calledCommander: true,
@@ -436,12 +496,17 @@ func TestCustomRouter_command(t *testing.T) {
 				req.Header.Set("Content-Type", "application/json")
 				rr := httptest.NewRecorder()
 
+				exitCode := 1
+
 				fields.Commander.On("GetOneCommandDescription", mock.Anything, id).Return(&models.Command{
-					ID:        1,
-					Name:      "whoami",
-					StartedAt: "8:40PM",
-					Output:    []string{"root"},
-					IsWorking: false,
+					ID:         1,
+					Name:       "whoami",
+					StartedAt:  "8:40PM",
+					FinishedAt: "8:41PM",
+					Status:     models.StatusFailed,
+					ExitCode:   &exitCode,
+					Output:     []models.Output{{Stream: models.StreamStderr, Text: "whoami: not found"}},
+					IsWorking:  false,
 				}, nil)
 
 				return rr, req
@@ -482,6 +547,26 @@ func TestCustomRouter_command(t *testing.T) {
 				return rr, req
 			},
 		},
+		{
+			name: "test_4, NotFound",
+			want: want{
+				resBody: `{"status":404,"body":{"error":"command not found"}}`,
+				status:  http.StatusOK,
+			},
+			id:              9,
+			calledCommander: true,
+			prepare: func(fields fields, id int64) (*httptest.ResponseRecorder, *http.Request) {
+
+				req := httptest.NewRequest("GET", fmt.Sprintf("/cmd?id=%d", id), nil)
+				req.Header.Set("Content-Type", "application/json")
+				rr := httptest.NewRecorder()
+
+				fields.Commander.On("GetOneCommandDescription", mock.Anything, id).
+					Return(nil, services.ErrCommandNotFound)
+
+				return rr, req
+			},
+		},
 	}
 
 	for _, tt := range tests {
@@ -546,7 +631,7 @@ func TestCustomRouter_stopCommand(t *testing.T) {
 				req.Header.Set("Content-Type", "application/json")
 				rr := httptest.NewRecorder()
 
-				fields.Commander.On("StopCommand", mock.Anything, mock.Anything).Return(int64(1), nil)
+				fields.Commander.On("StopCommand", mock.Anything, mock.Anything, mock.Anything).Return(int64(1), nil)
 
 				return rr, req
 			},
@@ -600,7 +685,7 @@ func TestCustomRouter_stopCommand(t *testing.T) {
 				req.Header.Set("Content-Type", "application/json")
 				rr := httptest.NewRecorder()
 
-				fields.Commander.On("StopCommand", mock.Anything, mock.Anything).
+				fields.Commander.On("StopCommand", mock.Anything, mock.Anything, mock.Anything).
 					Return(int64(0), services.ErrNoExecutingCommand)
 
 				return rr, req
@@ -621,12 +706,34 @@ func TestCustomRouter_stopCommand(t *testing.T) {
 				req.Header.Set("Content-Type", "application/json")
 				rr := httptest.NewRecorder()
 
-				fields.Commander.On("StopCommand", mock.Anything, mock.Anything).
+				fields.Commander.On("StopCommand", mock.Anything, mock.Anything, mock.Anything).
 					Return(int64(0), errors.New("some error"))
 
 				return rr, req
 			},
 		},
+		{
+			name: "test_6, BadRequest with unknown signal",
+			want: want{
+				resBody: `{"status":400,"body":{"error":"invalid script options: unknown signal SIGFOO"}}`,
+				status:  http.StatusOK,
+			},
+			calledCommander: true,
+			id:              "1",
+			prepare: func(fields fields, reqBody stopCommandRequest) (*httptest.ResponseRecorder, *http.Request) {
+				reqBody.Signal = "SIGFOO"
+				body, _ := json.Marshal(reqBody)
+
+				req := httptest.NewRequest("PUT", "/stop", strings.NewReader(string(body)))
+				req.Header.Set("Content-Type", "application/json")
+				rr := httptest.NewRecorder()
+
+				fields.Commander.On("StopCommand", mock.Anything, mock.Anything, "SIGFOO").
+					Return(int64(0), fmt.Errorf("%w: unknown signal SIGFOO", services.ErrInvalidOptions))
+
+				return rr, req
+			},
+		},
 	}
 
 	for _, tt := range tests {
@@ -656,10 +763,1042 @@ func TestCustomRouter_stopCommand(t *testing.T) {
 			require.Equal(t, tt.want.resBody, rr.Body.String())
 
 			if tt.calledCommander {
-				if !Commander.AssertCalled(t, "StopCommand", mock.Anything, mock.Anything) {
+				if !Commander.AssertCalled(t, "StopCommand", mock.Anything, mock.Anything, mock.Anything) {
+					t.Errorf("Expected call Commander")
+				}
+			}
+		})
+	}
+}
+
+func TestCustomRouter_streamCommand(t *testing.T) {
+	type want struct {
+		resBody string
+		status  int
+	}
+	type fields struct {
+		Commander *mocks.Commander
+	}
+	tests := []struct {
+		name            string
+		want            want
+		id              string
+		fields          fields
+		calledCommander bool
+		prepare         func(fields fields, id string) (*httptest.ResponseRecorder, *http.Request)
+	}{
+		{
+			name: "test_1, OK",
+			want: want{
+				resBody: "id: 7\nevent: output\ndata: {\"stream\":\"stdout\",\"text\":\"root\"}\n\n" +
+					"event: status\ndata: {\"status\":\"succeeded\",\"exit_code\":0}\n\n",
+				status: http.StatusOK,
+			},
+			calledCommander: true,
+			id:              "1",
+			prepare: func(fields fields, id string) (*httptest.ResponseRecorder, *http.Request) {
+				req := httptest.NewRequest("GET", fmt.Sprintf("/cmd/%s/stream", id), nil)
+				req = withURLParam(req, "id", id)
+				rr := httptest.NewRecorder()
+
+				events := make(chan models.Event, 2)
+				events <- models.Event{
+					ID:     7,
+					Type:   models.EventOutput,
+					Output: &models.Output{ID: 7, Stream: models.StreamStdout, Text: "root"},
+				}
+				events <- models.Event{
+					Type:   models.EventStatus,
+					Result: &models.Result{Status: models.StatusSucceeded, ExitCode: new(int)},
+				}
+				close(events)
+
+				fields.Commander.On("StreamCommand", mock.Anything, int64(1), int64(0)).
+					Return((<-chan models.Event)(events), nil)
+
+				return rr, req
+			},
+		},
+		{
+			name: "test_2, BadRequest",
+			want: want{
+				resBody: `{"status":400,"body":{"error":"Bad Request"}}`,
+				status:  http.StatusOK,
+			},
+			id: "invalid",
+			prepare: func(fields fields, id string) (*httptest.ResponseRecorder, *http.Request) {
+				req := httptest.NewRequest("GET", fmt.Sprintf("/cmd/%s/stream", id), nil)
+				req = withURLParam(req, "id", id)
+				rr := httptest.NewRecorder()
+
+				return rr, req
+			},
+		},
+		{
+			name: "test_3, InternalServerError",
+			want: want{
+				resBody: `{"status":500,"body":{"error":"Internal Server Error"}}`,
+				status:  http.StatusOK,
+			},
+			calledCommander: true,
+			id:              "1",
+			prepare: func(fields fields, id string) (*httptest.ResponseRecorder, *http.Request) {
+				req := httptest.NewRequest("GET", fmt.Sprintf("/cmd/%s/stream", id), nil)
+				req = withURLParam(req, "id", id)
+				rr := httptest.NewRecorder()
+
+				fields.Commander.On("StreamCommand", mock.Anything, int64(1), int64(0)).
+					Return(nil, errors.New("some error"))
+
+				return rr, req
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			Commander := new(mocks.Commander)
+			tt.fields.Commander = Commander
+
+			dlog := logs.NewDiscardLogger()
+
+			router := &CustomRouter{
+				cmdr:    Commander,
+				timeout: 10 * time.Second,
+				log:     dlog,
+			}
+
+			handler := router.streamCommand()
+
+			rr, req := tt.prepare(tt.fields, tt.id)
+
+			handler.ServeHTTP(rr, req)
+
+			require.Equal(t, tt.want.status, rr.Code)
+			require.Equal(t, tt.want.resBody, rr.Body.String())
+
+			if tt.calledCommander {
+				if !Commander.AssertCalled(t, "StreamCommand", mock.Anything, int64(1), int64(0)) {
 					t.Errorf("Expected call Commander")
 				}
 			}
 		})
 	}
 }
+
+// withURLParam adds chi's url parameter to the request ...
+func withURLParam(req *http.Request, key, value string) *http.Request {
+	rctx := chi.NewRouteContext()
+	rctx.URLParams.Add(key, value)
+
+	return req.WithContext(context.WithValue(req.Context(), chi.RouteCtxKey, rctx))
+}
+
+func TestCustomRouter_attachCommand(t *testing.T) {
+	Commander := new(mocks.Commander)
+
+	dlog := logs.NewDiscardLogger()
+
+	router := &CustomRouter{
+		Mux:     chi.NewRouter(),
+		cmdr:    Commander,
+		timeout: 10 * time.Second,
+		log:     dlog,
+	}
+
+	router.Get("/cmd/{id}/attach", router.attachCommand())
+
+	srv := httptest.NewServer(router)
+	defer srv.Close()
+
+	events := make(chan models.Event, 2)
+
+	Commander.On("StreamCommand", mock.Anything, int64(1), int64(0)).
+		Return((<-chan models.Event)(events), nil)
+	Commander.On("WriteInput", mock.Anything, int64(1), []byte("root\n")).Return(nil)
+	Commander.On("WriteInput", mock.Anything, int64(1), []byte("again\n")).Return(services.ErrInputClosed)
+	Commander.On("CloseInput", int64(1)).Return(nil).Run(func(args mock.Arguments) {
+		events <- models.Event{
+			ID:     1,
+			Type:   models.EventOutput,
+			Output: &models.Output{ID: 1, Stream: models.StreamStdout, Text: "hello root"},
+		}
+		events <- models.Event{
+			Type:   models.EventStatus,
+			Result: &models.Result{Status: models.StatusSucceeded, ExitCode: new(int)},
+		}
+		close(events)
+	})
+
+	conn, _, err := websocket.DefaultDialer.Dial("ws"+strings.TrimPrefix(srv.URL, "http")+"/cmd/1/attach", nil)
+	require.NoError(t, err)
+	defer conn.Close()
+
+	require.NoError(t, conn.WriteJSON(attachRequest{Type: attachInput, Data: "root\n"}))
+	require.NoError(t, conn.WriteJSON(attachRequest{Type: attachInput, Data: "again\n"}))
+
+	_, msg, err := conn.ReadMessage()
+	require.NoError(t, err)
+	require.Equal(t, `{"type":"error","error":"script's input is closed"}`, strings.TrimSpace(string(msg)))
+
+	require.NoError(t, conn.WriteJSON(attachRequest{Type: attachEOF}))
+
+	want := []string{
+		`{"type":"output","stream":"stdout","text":"hello root"}`,
+		`{"type":"status","result":{"status":"succeeded","exit_code":0}}`,
+	}
+
+	for _, w := range want {
+		_, msg, err := conn.ReadMessage()
+		require.NoError(t, err)
+		require.Equal(t, w, strings.TrimSpace(string(msg)))
+	}
+
+	_, _, err = conn.ReadMessage()
+	require.True(t, websocket.IsCloseError(err, websocket.CloseNormalClosure))
+
+	Commander.AssertExpectations(t)
+}
+
+func TestShebangInterpreter(t *testing.T) {
+	tests := []struct {
+		name   string
+		script string
+		want   string
+	}{
+		{name: "test_1, no shebang", script: "echo hi", want: ""},
+		{name: "test_2, absolute path", script: "#!/bin/sh\necho hi", want: "sh"},
+		{name: "test_3, env", script: "#!/usr/bin/env python3\nprint(1)", want: "python3"},
+		{name: "test_4, env with options", script: "#!/usr/bin/env -S perl -w\nprint 1", want: "perl"},
+		{name: "test_5, interpreter with options", script: "#! /usr/bin/node --no-warnings", want: "node"},
+		{name: "test_6, empty shebang", script: "#!\necho hi", want: ""},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			require.Equal(t, tt.want, shebangInterpreter(tt.script))
+		})
+	}
+}
+
+func TestCustomRouter_createTemplate(t *testing.T) {
+	tests := []struct {
+		name    string
+		body    string
+		resBody string
+		prepare func(c *mocks.Commander)
+	}{
+		{
+			name:    "test_1, OK",
+			body:    `{"name":"greet","script":"echo $NAME","timeout":5,"params":[{"name":"NAME","type":"string"}]}`,
+			resBody: `{"status":201,"body":{"template_id":1}}`,
+			prepare: func(c *mocks.Commander) {
+				c.On("CreateTemplate", mock.Anything, models.Template{
+					Name:    "greet",
+					Script:  "echo $NAME",
+					Params:  []models.Param{{Name: "NAME", Type: models.ParamString}},
+					Options: models.Options{Timeout: 5 * time.Second},
+				}).Return(int64(1), nil)
+			},
+		},
+		{
+			name:    "test_2, BadRequest",
+			body:    `{"name":`,
+			resBody: `{"status":400,"body":{"error":"Bad Request"}}`,
+			prepare: func(c *mocks.Commander) {},
+		},
+		{
+			name:    "test_3, Conflict",
+			body:    `{"name":"greet","script":"echo hi"}`,
+			resBody: `{"status":409,"body":{"error":"template with this name already exists"}}`,
+			prepare: func(c *mocks.Commander) {
+				c.On("CreateTemplate", mock.Anything, mock.Anything).Return(int64(-1), services.ErrTemplateExists)
+			},
+		},
+		{
+			name:    "test_4, invalid params",
+			body:    `{"name":"greet","script":"echo hi","params":[{"name":"N","type":"float"}]}`,
+			resBody: `{"status":400,"body":{"error":"invalid template parameters: parameter N has unknown type \"float\""}}`,
+			prepare: func(c *mocks.Commander) {
+				c.On("CreateTemplate", mock.Anything, mock.Anything).
+					Return(int64(-1), fmt.Errorf("%w: parameter N has unknown type \"float\"", services.ErrInvalidParams))
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			Commander := mocks.NewCommander(t)
+			tt.prepare(Commander)
+
+			router := &CustomRouter{
+				cmdr:    Commander,
+				timeout: 10 * time.Second,
+				log:     logs.NewDiscardLogger(),
+			}
+
+			req := httptest.NewRequest("POST", "/templates", strings.NewReader(tt.body))
+			rr := httptest.NewRecorder()
+
+			router.createTemplate().ServeHTTP(rr, req)
+
+			require.Equal(t, http.StatusOK, rr.Code)
+			require.Equal(t, tt.resBody, rr.Body.String())
+		})
+	}
+}
+
+func TestCustomRouter_runTemplate(t *testing.T) {
+	tests := []struct {
+		name    string
+		id      string
+		body    string
+		resBody string
+		prepare func(c *mocks.Commander)
+	}{
+		{
+			name:    "test_1, OK",
+			id:      "1",
+			body:    `{"params":{"COUNT":3,"VERBOSE":true}}`,
+			resBody: `{"status":201,"body":{"command_id":7}}`,
+			prepare: func(c *mocks.Commander) {
+				c.On("RunTemplate", mock.Anything, int64(1),
+					map[string]any{"COUNT": json.Number("3"), "VERBOSE": true}, map[string]string(nil)).
+					Return(int64(7), nil)
+			},
+		},
+		{
+			name:    "test_2, without body",
+			id:      "1",
+			resBody: `{"status":201,"body":{"command_id":8}}`,
+			prepare: func(c *mocks.Commander) {
+				c.On("RunTemplate", mock.Anything, int64(1), map[string]any(nil), map[string]string(nil)).
+					Return(int64(8), nil)
+			},
+		},
+		{
+			name:    "test_3, BadRequest",
+			id:      "invalid",
+			resBody: `{"status":400,"body":{"error":"Bad Request"}}`,
+			prepare: func(c *mocks.Commander) {},
+		},
+		{
+			name:    "test_4, NotFound",
+			id:      "2",
+			resBody: `{"status":404,"body":{"error":"template not found"}}`,
+			prepare: func(c *mocks.Commander) {
+				c.On("RunTemplate", mock.Anything, int64(2), mock.Anything, mock.Anything).
+					Return(int64(-1), services.ErrTemplateNotFound)
+			},
+		},
+		{
+			name:    "test_5, invalid params",
+			id:      "1",
+			body:    `{"params":{"COUNT":"many"}}`,
+			resBody: `{"status":400,"body":{"error":"invalid template parameters: parameter COUNT must be int"}}`,
+			prepare: func(c *mocks.Commander) {
+				c.On("RunTemplate", mock.Anything, int64(1), mock.Anything, mock.Anything).
+					Return(int64(-1), fmt.Errorf("%w: parameter COUNT must be int", services.ErrInvalidParams))
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			Commander := mocks.NewCommander(t)
+			tt.prepare(Commander)
+
+			router := &CustomRouter{
+				cmdr:    Commander,
+				timeout: 10 * time.Second,
+				log:     logs.NewDiscardLogger(),
+			}
+
+			req := httptest.NewRequest("POST", fmt.Sprintf("/templates/%s/run", tt.id), strings.NewReader(tt.body))
+			req = withURLParam(req, "id", tt.id)
+			rr := httptest.NewRecorder()
+
+			router.runTemplate().ServeHTTP(rr, req)
+
+			require.Equal(t, http.StatusOK, rr.Code)
+			require.Equal(t, tt.resBody, rr.Body.String())
+		})
+	}
+}
+
+func TestCustomRouter_createSchedule(t *testing.T) {
+	tests := []struct {
+		name    string
+		body    string
+		resBody string
+		prepare func(c *mocks.Commander)
+	}{
+		{
+			name:    "test_1, OK",
+			body:    `{"name":"greet","spec":"@hourly","template_id":1,"params":{"COUNT":3},"no_overlap":true}`,
+			resBody: `{"status":201,"body":{"schedule_id":2}}`,
+			prepare: func(c *mocks.Commander) {
+				c.On("CreateSchedule", mock.Anything, models.Schedule{
+					Name:       "greet",
+					Spec:       "@hourly",
+					TemplateID: 1,
+					Params:     map[string]any{"COUNT": json.Number("3")},
+					NoOverlap:  true,
+				}).Return(int64(2), nil)
+			},
+		},
+		{
+			name:    "test_2, BadRequest",
+			body:    `{"name":`,
+			resBody: `{"status":400,"body":{"error":"Bad Request"}}`,
+			prepare: func(c *mocks.Commander) {},
+		},
+		{
+			name:    "test_3, invalid spec",
+			body:    `{"name":"ls","spec":"* *","script":"ls"}`,
+			resBody: `{"status":400,"body":{"error":"invalid schedule: expected exactly 5 fields"}}`,
+			prepare: func(c *mocks.Commander) {
+				c.On("CreateSchedule", mock.Anything, mock.Anything).
+					Return(int64(-1), fmt.Errorf("%w: expected exactly 5 fields", services.ErrInvalidSchedule))
+			},
+		},
+		{
+			name:    "test_4, template not found",
+			body:    `{"name":"ls","spec":"@daily","template_id":3}`,
+			resBody: `{"status":404,"body":{"error":"template not found"}}`,
+			prepare: func(c *mocks.Commander) {
+				c.On("CreateSchedule", mock.Anything, mock.Anything).Return(int64(-1), services.ErrTemplateNotFound)
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			Commander := mocks.NewCommander(t)
+			tt.prepare(Commander)
+
+			router := &CustomRouter{
+				cmdr:    Commander,
+				timeout: 10 * time.Second,
+				log:     logs.NewDiscardLogger(),
+			}
+
+			req := httptest.NewRequest("POST", "/schedules", strings.NewReader(tt.body))
+			rr := httptest.NewRecorder()
+
+			router.createSchedule().ServeHTTP(rr, req)
+
+			require.Equal(t, http.StatusOK, rr.Code)
+			require.Equal(t, tt.resBody, rr.Body.String())
+		})
+	}
+}
+
+func TestCustomRouter_nextRuns(t *testing.T) {
+	run := time.Date(2024, 3, 1, 10, 0, 0, 0, time.UTC)
+
+	tests := []struct {
+		name    string
+		id      string
+		query   string
+		resBody string
+		prepare func(c *mocks.Commander)
+	}{
+		{
+			name:    "test_1, OK",
+			id:      "1",
+			query:   "?count=2",
+			resBody: `{"status":200,"body":{"runs":["2024-03-01T10:00:00Z","2024-03-01T11:00:00Z"]}}`,
+			prepare: func(c *mocks.Commander) {
+				c.On("NextRuns", mock.Anything, int64(1), 2).Return([]time.Time{run, run.Add(time.Hour)}, nil)
+			},
+		},
+		{
+			name:    "test_2, default count",
+			id:      "1",
+			resBody: `{"status":200,"body":{"runs":["2024-03-01T10:00:00Z"]}}`,
+			prepare: func(c *mocks.Commander) {
+				c.On("NextRuns", mock.Anything, int64(1), defaultNextRuns).Return([]time.Time{run}, nil)
+			},
+		},
+		{
+			name:    "test_3, invalid count",
+			id:      "1",
+			query:   "?count=x",
+			resBody: `{"status":400,"body":{"error":"Bad Request"}}`,
+			prepare: func(c *mocks.Commander) {},
+		},
+		{
+			name:    "test_4, not found",
+			id:      "9",
+			resBody: `{"status":404,"body":{"error":"schedule not found"}}`,
+			prepare: func(c *mocks.Commander) {
+				c.On("NextRuns", mock.Anything, int64(9), defaultNextRuns).Return(nil, services.ErrScheduleNotFound)
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			Commander := mocks.NewCommander(t)
+			tt.prepare(Commander)
+
+			router := &CustomRouter{
+				cmdr:    Commander,
+				timeout: 10 * time.Second,
+				log:     logs.NewDiscardLogger(),
+			}
+
+			req := httptest.NewRequest("GET", "/schedules/"+tt.id+"/next"+tt.query, nil)
+			req = withURLParam(req, "id", tt.id)
+			rr := httptest.NewRecorder()
+
+			router.nextRuns().ServeHTTP(rr, req)
+
+			require.Equal(t, http.StatusOK, rr.Code)
+			require.Equal(t, tt.resBody, rr.Body.String())
+		})
+	}
+}
+
+func TestCustomRouter_rerunCommand(t *testing.T) {
+	tests := []struct {
+		name    string
+		id      string
+		body    string
+		resBody string
+		prepare func(c *mocks.Commander)
+	}{
+		{
+			name:    "test_1, OK",
+			id:      "1",
+			resBody: `{"status":201,"body":{"command_id":2}}`,
+			prepare: func(c *mocks.Commander) {
+				c.On("RerunCommand", mock.Anything, int64(1), map[string]string(nil)).Return(int64(2), nil)
+			},
+		},
+		{
+			name:    "test_2, with secrets",
+			id:      "1",
+			body:    `{"secret_env":{"TOKEN":"x"}}`,
+			resBody: `{"status":201,"body":{"command_id":3}}`,
+			prepare: func(c *mocks.Commander) {
+				c.On("RerunCommand", mock.Anything, int64(1), map[string]string{"TOKEN": "x"}).Return(int64(3), nil)
+			},
+		},
+		{
+			name:    "test_3, BadRequest",
+			id:      "one",
+			resBody: `{"status":400,"body":{"error":"Bad Request"}}`,
+			prepare: func(c *mocks.Commander) {},
+		},
+		{
+			name:    "test_4, no script",
+			id:      "5",
+			resBody: `{"status":400,"body":{"error":"invalid script options: command 5 has no stored script"}}`,
+			prepare: func(c *mocks.Commander) {
+				c.On("RerunCommand", mock.Anything, int64(5), map[string]string(nil)).
+					Return(int64(-1), fmt.Errorf("%w: command 5 has no stored script", services.ErrInvalidOptions))
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			Commander := mocks.NewCommander(t)
+			tt.prepare(Commander)
+
+			router := &CustomRouter{
+				cmdr:    Commander,
+				timeout: 10 * time.Second,
+				log:     logs.NewDiscardLogger(),
+			}
+
+			req := httptest.NewRequest("POST", "/cmd/"+tt.id+"/rerun", strings.NewReader(tt.body))
+			req = withURLParam(req, "id", tt.id)
+			rr := httptest.NewRecorder()
+
+			router.rerunCommand().ServeHTTP(rr, req)
+
+			require.Equal(t, http.StatusOK, rr.Code)
+			require.Equal(t, tt.resBody, rr.Body.String())
+		})
+	}
+}
+
+func TestCommandFilter(t *testing.T) {
+	working := false
+
+	tests := []struct {
+		name    string
+		query   string
+		want    models.CommandFilter
+		wantErr bool
+	}{
+		{
+			name:  "test_1, limit only",
+			query: "limit=10",
+			want:  models.CommandFilter{Limit: 10},
+		},
+		{
+			name:  "test_2, all filters",
+			query: "limit=10&cursor=55&status=failed,timed_out&status=stopped&from=2024-03-01T00:00:00Z&to=2024-03-02T00:00:00Z&is_working=false&q=%20disk%20full%20",
+			want: models.CommandFilter{
+				Limit:     10,
+				Cursor:    55,
+				Statuses:  []string{models.StatusFailed, models.StatusTimedOut, models.StatusStopped},
+				From:      time.Date(2024, 3, 1, 0, 0, 0, 0, time.UTC),
+				To:        time.Date(2024, 3, 2, 0, 0, 0, 0, time.UTC),
+				IsWorking: &working,
+				Query:     "disk full",
+			},
+		},
+		{
+			name:    "test_3, missing limit",
+			query:   "cursor=5",
+			wantErr: true,
+		},
+		{
+			name:    "test_4, invalid date",
+			query:   "limit=5&from=yesterday",
+			wantErr: true,
+		},
+		{
+			name:    "test_5, invalid is_working",
+			query:   "limit=5&is_working=maybe",
+			wantErr: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest("GET", "/list?"+tt.query, nil)
+
+			got, err := commandFilter(req)
+
+			if tt.wantErr {
+				require.Error(t, err)
+				return
+			}
+
+			require.NoError(t, err)
+			require.Equal(t, tt.want, got)
+		})
+	}
+}
+
+func TestCustomRouter_commandOutput(t *testing.T) {
+	tests := []struct {
+		name    string
+		id      string
+		query   string
+		resBody string
+		prepare func(c *mocks.Commander)
+	}{
+		{
+			name:    "test_1, tail",
+			id:      "1",
+			query:   "?tail=1",
+			resBody: `{"status":200,"body":{"command_id":1,"lines":[{"line":3,"stream":"stderr","text":"done"}],"last_line":3}}`,
+			prepare: func(c *mocks.Commander) {
+				c.On("GetCommandOutput", mock.Anything, int64(1), models.OutputRange{Tail: 1}).
+					Return([]models.Output{{ID: 12, Line: 3, Stream: models.StreamStderr, Text: "done"}}, int64(3), nil)
+			},
+		},
+		{
+			name:    "test_2, empty range",
+			id:      "1",
+			query:   "?since=4&offset=1&limit=2",
+			resBody: `{"status":200,"body":{"command_id":1,"lines":[],"last_line":3}}`,
+			prepare: func(c *mocks.Commander) {
+				c.On("GetCommandOutput", mock.Anything, int64(1), models.OutputRange{Since: 4, Offset: 1, Limit: 2}).
+					Return([]models.Output{}, int64(3), nil)
+			},
+		},
+		{
+			name:    "test_3, BadRequest",
+			id:      "1",
+			query:   "?tail=last",
+			resBody: `{"status":400,"body":{"error":"Bad Request"}}`,
+			prepare: func(c *mocks.Commander) {},
+		},
+		{
+			name:    "test_4, invalid range",
+			id:      "1",
+			query:   "?tail=1&offset=1",
+			resBody: `{"status":400,"body":{"error":"invalid script options: tail can't be used with offset"}}`,
+			prepare: func(c *mocks.Commander) {
+				c.On("GetCommandOutput", mock.Anything, int64(1), models.OutputRange{Tail: 1, Offset: 1}).
+					Return(nil, int64(0), fmt.Errorf("%w: tail can't be used with offset", services.ErrInvalidOptions))
+			},
+		},
+		{
+			name:    "test_5, absolute time",
+			id:      "1",
+			query:   "?since=2",
+			resBody: `{"status":200,"body":{"command_id":1,"lines":[{"line":2,"stream":"stdout","text":"b","at":"2024-01-02T03:04:05.5Z"}],"last_line":2}}`,
+			prepare: func(c *mocks.Commander) {
+				c.On("GetCommandOutput", mock.Anything, int64(1), models.OutputRange{Since: 2}).
+					Return([]models.Output{timedOutput()}, int64(2), nil)
+			},
+		},
+		{
+			name:    "test_6, relative time",
+			id:      "1",
+			query:   "?since=2&time=relative",
+			resBody: `{"status":200,"body":{"command_id":1,"lines":[{"line":2,"stream":"stdout","text":"b","elapsed":1.25}],"last_line":2}}`,
+			prepare: func(c *mocks.Commander) {
+				c.On("GetCommandOutput", mock.Anything, int64(1), models.OutputRange{Since: 2}).
+					Return([]models.Output{timedOutput()}, int64(2), nil)
+			},
+		},
+		{
+			name:    "test_7, invalid time view",
+			id:      "1",
+			query:   "?time=local",
+			resBody: `{"status":400,"body":{"error":"invalid time view: local"}}`,
+			prepare: func(c *mocks.Commander) {},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			Commander := mocks.NewCommander(t)
+			tt.prepare(Commander)
+
+			router := &CustomRouter{
+				cmdr:    Commander,
+				timeout: 10 * time.Second,
+				log:     logs.NewDiscardLogger(),
+			}
+
+			req := httptest.NewRequest("GET", "/cmd/"+tt.id+"/output"+tt.query, nil)
+			req = withURLParam(req, "id", tt.id)
+			rr := httptest.NewRecorder()
+
+			router.commandOutput().ServeHTTP(rr, req)
+
+			require.Equal(t, http.StatusOK, rr.Code)
+			require.Equal(t, tt.resBody, rr.Body.String())
+		})
+	}
+}
+
+func TestCustomRouter_downloadOutput(t *testing.T) {
+	lines := []models.Output{
+		{Line: 1, Stream: models.StreamStdout, Text: "long ", Partial: true},
+		{Line: 2, Stream: models.StreamStdout, Text: "line"},
+		{Line: 3, Stream: models.StreamStdout, Text: "AP8=", Encoding: models.EncodingBase64},
+		{Line: 4, Stream: models.StreamStderr, Text: "done"},
+	}
+
+	tests := []struct {
+		name        string
+		id          string
+		format      string
+		gzip        bool
+		wantType    string
+		wantBody    string
+		prepare     func(c *mocks.Commander)
+		wantHeaders bool
+	}{
+		{
+			name:     "test_1, text",
+			id:       "1",
+			format:   "txt",
+			wantType: "text/plain; charset=utf-8",
+			wantBody: "long line\n\x00\xff\ndone\n",
+			prepare: func(c *mocks.Commander) {
+				c.On("EachCommandOutput", mock.Anything, int64(1), mock.Anything).Run(func(args mock.Arguments) {
+					each := args.Get(2).(func(models.Output) error)
+					for _, line := range lines {
+						require.NoError(t, each(line))
+					}
+				}).Return(nil)
+			},
+			wantHeaders: true,
+		},
+		{
+			name:     "test_2, gzipped ndjson",
+			id:       "1",
+			format:   "ndjson",
+			gzip:     true,
+			wantType: "application/x-ndjson",
+			wantBody: `{"line":4,"stream":"stderr","text":"done"}` + "\n",
+			prepare: func(c *mocks.Commander) {
+				c.On("EachCommandOutput", mock.Anything, int64(1), mock.Anything).Run(func(args mock.Arguments) {
+					each := args.Get(2).(func(models.Output) error)
+					require.NoError(t, each(lines[3]))
+				}).Return(nil)
+			},
+			wantHeaders: true,
+		},
+		{
+			name:     "test_3, empty output",
+			id:       "1",
+			format:   "txt",
+			wantType: "text/plain; charset=utf-8",
+			prepare: func(c *mocks.Commander) {
+				c.On("EachCommandOutput", mock.Anything, int64(1), mock.Anything).Return(nil)
+			},
+			wantHeaders: true,
+		},
+		{
+			name:     "test_4, NotFound",
+			id:       "42",
+			format:   "txt",
+			wantType: "application/json",
+			wantBody: `{"status":404,"body":{"error":"command not found"}}`,
+			prepare: func(c *mocks.Commander) {
+				c.On("EachCommandOutput", mock.Anything, int64(42), mock.Anything).Return(services.ErrCommandNotFound)
+			},
+		},
+		{
+			name:     "test_5, unknown format",
+			id:       "1",
+			format:   "csv",
+			wantType: "application/json",
+			wantBody: `{"status":404,"body":{"error":"Not Found"}}`,
+			prepare:  func(c *mocks.Commander) {},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			Commander := mocks.NewCommander(t)
+			tt.prepare(Commander)
+
+			router := &CustomRouter{
+				cmdr:    Commander,
+				timeout: 10 * time.Second,
+				log:     logs.NewDiscardLogger(),
+			}
+
+			req := httptest.NewRequest("GET", "/cmd/"+tt.id+"/output."+tt.format, nil)
+			req = withURLParam(req, "id", tt.id)
+			req = req.WithContext(context.WithValue(req.Context(), middleware.URLFormatCtxKey, tt.format))
+
+			if tt.gzip {
+				req.Header.Set("Accept-Encoding", "br;q=1.0, gzip;q=0.8")
+			}
+
+			rr := httptest.NewRecorder()
+
+			router.commandOutput().ServeHTTP(rr, req)
+
+			body := rr.Body.String()
+
+			if tt.gzip {
+				require.Equal(t, "gzip", rr.Header().Get("Content-Encoding"))
+
+				zr, err := gzip.NewReader(rr.Body)
+				require.NoError(t, err)
+
+				data, err := io.ReadAll(zr)
+				require.NoError(t, err)
+
+				body = string(data)
+			}
+
+			require.Equal(t, http.StatusOK, rr.Code)
+			require.Contains(t, rr.Header().Get("Content-Type"), tt.wantType)
+			require.Equal(t, tt.wantBody, body)
+
+			if tt.wantHeaders {
+				require.Equal(t, `attachment; filename="command-`+tt.id+`-output.`+tt.format+`"`,
+					rr.Header().Get("Content-Disposition"))
+			}
+		})
+	}
+}
+
+func TestAcceptsGzip(t *testing.T) {
+	tests := []struct {
+		header string
+		want   bool
+	}{
+		{header: "", want: false},
+		{header: "gzip", want: true},
+		{header: "deflate, gzip;q=0.5", want: true},
+		{header: "gzip;q=0", want: false},
+		{header: "br", want: false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.header, func(t *testing.T) {
+			req := httptest.NewRequest("GET", "/cmd/1/output.txt", nil)
+			req.Header.Set("Accept-Encoding", tt.header)
+
+			require.Equal(t, tt.want, acceptsGzip(req))
+		})
+	}
+}
+
+func timedOutput() models.Output {
+	at := time.Date(2024, 1, 2, 3, 4, 5, 5e8, time.UTC)
+	elapsed := 1.25
+
+	return models.Output{Line: 2, Stream: models.StreamStdout, Text: "b", At: &at, Elapsed: &elapsed}
+}
+
+func TestCustomRouter_artifacts(t *testing.T) {
+	tests := []struct {
+		name     string
+		id       string
+		wantBody string
+		prepare  func(c *mocks.Commander)
+	}{
+		{
+			name:     "test_1, OK",
+			id:       "1",
+			wantBody: `{"status":200,"body":{"command_id":1,"artifacts":[{"id":7,"command_id":1,"name":"out/report.txt","size":6,"sha256":"845e9183","created_at":"Jan  2 15:04:05.000"}]}}`,
+			prepare: func(c *mocks.Commander) {
+				c.On("GetArtifactList", mock.Anything, int64(1)).Return([]models.Artifact{
+					{ID: 7, CommandID: 1, Name: "out/report.txt", Size: 6, SHA256: "845e9183", CreatedAt: "Jan  2 15:04:05.000"},
+				}, nil)
+			},
+		},
+		{
+			name:     "test_2, no artifacts",
+			id:       "2",
+			wantBody: `{"status":200,"body":{"command_id":2,"artifacts":[]}}`,
+			prepare: func(c *mocks.Commander) {
+				c.On("GetArtifactList", mock.Anything, int64(2)).Return([]models.Artifact{}, nil)
+			},
+		},
+		{
+			name:     "test_3, NotFound",
+			id:       "42",
+			wantBody: `{"status":404,"body":{"error":"command not found"}}`,
+			prepare: func(c *mocks.Commander) {
+				c.On("GetArtifactList", mock.Anything, int64(42)).Return(nil, services.ErrCommandNotFound)
+			},
+		},
+		{
+			name:     "test_4, bad id",
+			id:       "abc",
+			wantBody: `{"status":400,"body":{"error":"Bad Request"}}`,
+			prepare:  func(c *mocks.Commander) {},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			Commander := mocks.NewCommander(t)
+			tt.prepare(Commander)
+
+			router := &CustomRouter{
+				cmdr:    Commander,
+				timeout: 10 * time.Second,
+				log:     logs.NewDiscardLogger(),
+			}
+
+			req := httptest.NewRequest("GET", "/cmd/"+tt.id+"/artifacts", nil)
+			req = withURLParam(req, "id", tt.id)
+
+			rr := httptest.NewRecorder()
+
+			router.artifacts().ServeHTTP(rr, req)
+
+			require.Equal(t, http.StatusOK, rr.Code)
+			require.Equal(t, tt.wantBody, rr.Body.String())
+		})
+	}
+}
+
+func TestCustomRouter_artifact(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "report.txt")
+	require.NoError(t, os.WriteFile(path, []byte("report"), 0o600))
+
+	open := func() *os.File {
+		f, err := os.Open(path)
+		require.NoError(t, err)
+		return f
+	}
+
+	tests := []struct {
+		name        string
+		artifactID  string
+		rangeHeader string
+		wantCode    int
+		wantBody    string
+		prepare     func(c *mocks.Commander)
+		wantFile    bool
+	}{
+		{
+			name:       "test_1, OK",
+			artifactID: "7",
+			wantCode:   http.StatusOK,
+			wantBody:   "report",
+			prepare: func(c *mocks.Commander) {
+				c.On("OpenArtifact", mock.Anything, int64(1), int64(7)).
+					Return(&models.Artifact{ID: 7, CommandID: 1, Name: "out/report.txt"}, open(), nil)
+			},
+			wantFile: true,
+		},
+		{
+			name:        "test_2, range",
+			artifactID:  "7",
+			rangeHeader: "bytes=2-",
+			wantCode:    http.StatusPartialContent,
+			wantBody:    "port",
+			prepare: func(c *mocks.Commander) {
+				c.On("OpenArtifact", mock.Anything, int64(1), int64(7)).
+					Return(&models.Artifact{ID: 7, CommandID: 1, Name: "out/report.txt"}, open(), nil)
+			},
+			wantFile: true,
+		},
+		{
+			name:       "test_3, NotFound",
+			artifactID: "42",
+			wantCode:   http.StatusOK,
+			wantBody:   `{"status":404,"body":{"error":"artifact not found"}}`,
+			prepare: func(c *mocks.Commander) {
+				c.On("OpenArtifact", mock.Anything, int64(1), int64(42)).Return(nil, nil, services.ErrArtifactNotFound)
+			},
+		},
+		{
+			name:       "test_4, bad artifact id",
+			artifactID: "abc",
+			wantCode:   http.StatusOK,
+			wantBody:   `{"status":400,"body":{"error":"Bad Request"}}`,
+			prepare:    func(c *mocks.Commander) {},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			Commander := mocks.NewCommander(t)
+			tt.prepare(Commander)
+
+			router := &CustomRouter{
+				cmdr:    Commander,
+				timeout: 10 * time.Second,
+				log:     logs.NewDiscardLogger(),
+			}
+
+			rctx := chi.NewRouteContext()
+			rctx.URLParams.Add("id", "1")
+			rctx.URLParams.Add("artifactID", tt.artifactID)
+
+			req := httptest.NewRequest("GET", "/cmd/1/artifacts/"+tt.artifactID, nil)
+			req = req.WithContext(context.WithValue(req.Context(), chi.RouteCtxKey, rctx))
+
+			if tt.rangeHeader != "" {
+				req.Header.Set("Range", tt.rangeHeader)
+			}
+
+			rr := httptest.NewRecorder()
+
+			router.artifact().ServeHTTP(rr, req)
+
+			require.Equal(t, tt.wantCode, rr.Code)
+			require.Equal(t, tt.wantBody, rr.Body.String())
+
+			if tt.wantFile {
+				require.Equal(t, "application/octet-stream", rr.Header().Get("Content-Type"))
+				require.Equal(t, "attachment; filename=report.txt", rr.Header().Get("Content-Disposition"))
+			}
+		})
+	}
+}
@@ -3,11 +3,13 @@ package handler
 import (
 	"log/slog"
 	"net/http"
+	"strings"
 	"time"
 
 	"github.com/enchik0reo/commandApi/internal/logs"
 	"github.com/go-chi/chi/middleware"
 	"github.com/go-chi/cors"
+	"github.com/gorilla/websocket"
 )
 
 // corsSettings sets allowed domains, http methods, header for communication with frontend server ...
@@ -23,6 +25,26 @@ func corsSettings(domains []string) func(next http.Handler) http.Handler {
 	return h
 }
 
+// newUpgrader returns WebSocket upgrader which accepts connections only from allowed domains ...
+func newUpgrader(domains []string) websocket.Upgrader {
+	return websocket.Upgrader{
+		CheckOrigin: func(r *http.Request) bool {
+			origin := r.Header.Get("Origin")
+			if origin == "" {
+				return true
+			}
+
+			for _, d := range domains {
+				if d == "*" || strings.EqualFold(d, origin) {
+					return true
+				}
+			}
+
+			return false
+		},
+	}
+}
+
 // loggerMw add information form ResponseWriter and Request ...
 func loggerMw(log *logs.CustomLog) func(next http.Handler) http.Handler {
 	return func(next http.Handler) http.Handler {
@@ -8,6 +8,10 @@ import (
 	mock "github.com/stretchr/testify/mock"
 
 	models "github.com/enchik0reo/commandApi/internal/models"
+
+	os "os"
+
+	time "time"
 )
 
 // Commander is an autogenerated mock type for the Commander type
@@ -15,8 +19,26 @@ type Commander struct {
 	mock.Mock
 }
 
+// CloseInput provides a mock function with given fields: _a0
+func (_m *Commander) CloseInput(_a0 int64) error {
+	ret := _m.Called(_a0)
+
+	if len(ret) == 0 {
+		panic("no return value specified for CloseInput")
+	}
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func(int64) error); ok {
+		r0 = rf(_a0)
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
+
 // CreateNewCommand provides a mock function with given fields: _a0, _a1
-func (_m *Commander) CreateNewCommand(_a0 context.Context, _a1 string) (int64, error) {
+func (_m *Commander) CreateNewCommand(_a0 context.Context, _a1 models.Script) (int64, error) {
 	ret := _m.Called(_a0, _a1)
 
 	if len(ret) == 0 {
@@ -25,16 +47,16 @@ func (_m *Commander) CreateNewCommand(_a0 context.Context, _a1 string) (int64, e
 
 	var r0 int64
 	var r1 error
-	if rf, ok := ret.Get(0).(func(context.Context, string) (int64, error)); ok {
+	if rf, ok := ret.Get(0).(func(context.Context, models.Script) (int64, error)); ok {
 		return rf(_a0, _a1)
 	}
-	if rf, ok := ret.Get(0).(func(context.Context, string) int64); ok {
+	if rf, ok := ret.Get(0).(func(context.Context, models.Script) int64); ok {
 		r0 = rf(_a0, _a1)
 	} else {
 		r0 = ret.Get(0).(int64)
 	}
 
-	if rf, ok := ret.Get(1).(func(context.Context, string) error); ok {
+	if rf, ok := ret.Get(1).(func(context.Context, models.Script) error); ok {
 		r1 = rf(_a0, _a1)
 	} else {
 		r1 = ret.Error(1)
@@ -43,24 +65,134 @@ func (_m *Commander) CreateNewCommand(_a0 context.Context, _a1 string) (int64, e
 	return r0, r1
 }
 
-// GetCommandList provides a mock function with given fields: _a0, _a1
-func (_m *Commander) GetCommandList(_a0 context.Context, _a1 int64) ([]models.Command, error) {
+// CreateSchedule provides a mock function with given fields: _a0, _a1
+func (_m *Commander) CreateSchedule(_a0 context.Context, _a1 models.Schedule) (int64, error) {
 	ret := _m.Called(_a0, _a1)
 
 	if len(ret) == 0 {
-		panic("no return value specified for GetCommandList")
+		panic("no return value specified for CreateSchedule")
 	}
 
-	var r0 []models.Command
+	var r0 int64
+	var r1 error
+	if rf, ok := ret.Get(0).(func(context.Context, models.Schedule) (int64, error)); ok {
+		return rf(_a0, _a1)
+	}
+	if rf, ok := ret.Get(0).(func(context.Context, models.Schedule) int64); ok {
+		r0 = rf(_a0, _a1)
+	} else {
+		r0 = ret.Get(0).(int64)
+	}
+
+	if rf, ok := ret.Get(1).(func(context.Context, models.Schedule) error); ok {
+		r1 = rf(_a0, _a1)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// CreateTemplate provides a mock function with given fields: _a0, _a1
+func (_m *Commander) CreateTemplate(_a0 context.Context, _a1 models.Template) (int64, error) {
+	ret := _m.Called(_a0, _a1)
+
+	if len(ret) == 0 {
+		panic("no return value specified for CreateTemplate")
+	}
+
+	var r0 int64
+	var r1 error
+	if rf, ok := ret.Get(0).(func(context.Context, models.Template) (int64, error)); ok {
+		return rf(_a0, _a1)
+	}
+	if rf, ok := ret.Get(0).(func(context.Context, models.Template) int64); ok {
+		r0 = rf(_a0, _a1)
+	} else {
+		r0 = ret.Get(0).(int64)
+	}
+
+	if rf, ok := ret.Get(1).(func(context.Context, models.Template) error); ok {
+		r1 = rf(_a0, _a1)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// DeleteSchedule provides a mock function with given fields: _a0, _a1
+func (_m *Commander) DeleteSchedule(_a0 context.Context, _a1 int64) error {
+	ret := _m.Called(_a0, _a1)
+
+	if len(ret) == 0 {
+		panic("no return value specified for DeleteSchedule")
+	}
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func(context.Context, int64) error); ok {
+		r0 = rf(_a0, _a1)
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
+
+// DeleteTemplate provides a mock function with given fields: _a0, _a1
+func (_m *Commander) DeleteTemplate(_a0 context.Context, _a1 int64) error {
+	ret := _m.Called(_a0, _a1)
+
+	if len(ret) == 0 {
+		panic("no return value specified for DeleteTemplate")
+	}
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func(context.Context, int64) error); ok {
+		r0 = rf(_a0, _a1)
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
+
+// EachCommandOutput provides a mock function with given fields: _a0, _a1, _a2
+func (_m *Commander) EachCommandOutput(_a0 context.Context, _a1 int64, _a2 func(models.Output) error) error {
+	ret := _m.Called(_a0, _a1, _a2)
+
+	if len(ret) == 0 {
+		panic("no return value specified for EachCommandOutput")
+	}
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func(context.Context, int64, func(models.Output) error) error); ok {
+		r0 = rf(_a0, _a1, _a2)
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
+
+// GetArtifactList provides a mock function with given fields: _a0, _a1
+func (_m *Commander) GetArtifactList(_a0 context.Context, _a1 int64) ([]models.Artifact, error) {
+	ret := _m.Called(_a0, _a1)
+
+	if len(ret) == 0 {
+		panic("no return value specified for GetArtifactList")
+	}
+
+	var r0 []models.Artifact
 	var r1 error
-	if rf, ok := ret.Get(0).(func(context.Context, int64) ([]models.Command, error)); ok {
+	if rf, ok := ret.Get(0).(func(context.Context, int64) ([]models.Artifact, error)); ok {
 		return rf(_a0, _a1)
 	}
-	if rf, ok := ret.Get(0).(func(context.Context, int64) []models.Command); ok {
+	if rf, ok := ret.Get(0).(func(context.Context, int64) []models.Artifact); ok {
 		r0 = rf(_a0, _a1)
 	} else {
 		if ret.Get(0) != nil {
-			r0 = ret.Get(0).([]models.Command)
+			r0 = ret.Get(0).([]models.Artifact)
 		}
 	}
 
@@ -73,6 +205,80 @@ func (_m *Commander) GetCommandList(_a0 context.Context, _a1 int64) ([]models.Co
 	return r0, r1
 }
 
+// GetCommandList provides a mock function with given fields: _a0, _a1
+func (_m *Commander) GetCommandList(_a0 context.Context, _a1 models.CommandFilter) ([]models.Command, int64, error) {
+	ret := _m.Called(_a0, _a1)
+
+	if len(ret) == 0 {
+		panic("no return value specified for GetCommandList")
+	}
+
+	var r0 []models.Command
+	var r1 int64
+	var r2 error
+	if rf, ok := ret.Get(0).(func(context.Context, models.CommandFilter) ([]models.Command, int64, error)); ok {
+		return rf(_a0, _a1)
+	}
+	if rf, ok := ret.Get(0).(func(context.Context, models.CommandFilter) []models.Command); ok {
+		r0 = rf(_a0, _a1)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).([]models.Command)
+		}
+	}
+
+	if rf, ok := ret.Get(1).(func(context.Context, models.CommandFilter) int64); ok {
+		r1 = rf(_a0, _a1)
+	} else {
+		r1 = ret.Get(1).(int64)
+	}
+
+	if rf, ok := ret.Get(2).(func(context.Context, models.CommandFilter) error); ok {
+		r2 = rf(_a0, _a1)
+	} else {
+		r2 = ret.Error(2)
+	}
+
+	return r0, r1, r2
+}
+
+// GetCommandOutput provides a mock function with given fields: _a0, _a1, _a2
+func (_m *Commander) GetCommandOutput(_a0 context.Context, _a1 int64, _a2 models.OutputRange) ([]models.Output, int64, error) {
+	ret := _m.Called(_a0, _a1, _a2)
+
+	if len(ret) == 0 {
+		panic("no return value specified for GetCommandOutput")
+	}
+
+	var r0 []models.Output
+	var r1 int64
+	var r2 error
+	if rf, ok := ret.Get(0).(func(context.Context, int64, models.OutputRange) ([]models.Output, int64, error)); ok {
+		return rf(_a0, _a1, _a2)
+	}
+	if rf, ok := ret.Get(0).(func(context.Context, int64, models.OutputRange) []models.Output); ok {
+		r0 = rf(_a0, _a1, _a2)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).([]models.Output)
+		}
+	}
+
+	if rf, ok := ret.Get(1).(func(context.Context, int64, models.OutputRange) int64); ok {
+		r1 = rf(_a0, _a1, _a2)
+	} else {
+		r1 = ret.Get(1).(int64)
+	}
+
+	if rf, ok := ret.Get(2).(func(context.Context, int64, models.OutputRange) error); ok {
+		r2 = rf(_a0, _a1, _a2)
+	} else {
+		r2 = ret.Error(2)
+	}
+
+	return r0, r1, r2
+}
+
 // GetOneCommandDescription provides a mock function with given fields: _a0, _a1
 func (_m *Commander) GetOneCommandDescription(_a0 context.Context, _a1 int64) (*models.Command, error) {
 	ret := _m.Called(_a0, _a1)
@@ -103,23 +309,25 @@ func (_m *Commander) GetOneCommandDescription(_a0 context.Context, _a1 int64) (*
 	return r0, r1
 }
 
-// StopCommand provides a mock function with given fields: _a0, _a1
-func (_m *Commander) StopCommand(_a0 context.Context, _a1 int64) (int64, error) {
+// GetSchedule provides a mock function with given fields: _a0, _a1
+func (_m *Commander) GetSchedule(_a0 context.Context, _a1 int64) (*models.Schedule, error) {
 	ret := _m.Called(_a0, _a1)
 
 	if len(ret) == 0 {
-		panic("no return value specified for StopCommand")
+		panic("no return value specified for GetSchedule")
 	}
 
-	var r0 int64
+	var r0 *models.Schedule
 	var r1 error
-	if rf, ok := ret.Get(0).(func(context.Context, int64) (int64, error)); ok {
+	if rf, ok := ret.Get(0).(func(context.Context, int64) (*models.Schedule, error)); ok {
 		return rf(_a0, _a1)
 	}
-	if rf, ok := ret.Get(0).(func(context.Context, int64) int64); ok {
+	if rf, ok := ret.Get(0).(func(context.Context, int64) *models.Schedule); ok {
 		r0 = rf(_a0, _a1)
 	} else {
-		r0 = ret.Get(0).(int64)
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).(*models.Schedule)
+		}
 	}
 
 	if rf, ok := ret.Get(1).(func(context.Context, int64) error); ok {
@@ -131,6 +339,333 @@ func (_m *Commander) StopCommand(_a0 context.Context, _a1 int64) (int64, error)
 	return r0, r1
 }
 
+// GetScheduleList provides a mock function with given fields: _a0
+func (_m *Commander) GetScheduleList(_a0 context.Context) ([]models.Schedule, error) {
+	ret := _m.Called(_a0)
+
+	if len(ret) == 0 {
+		panic("no return value specified for GetScheduleList")
+	}
+
+	var r0 []models.Schedule
+	var r1 error
+	if rf, ok := ret.Get(0).(func(context.Context) ([]models.Schedule, error)); ok {
+		return rf(_a0)
+	}
+	if rf, ok := ret.Get(0).(func(context.Context) []models.Schedule); ok {
+		r0 = rf(_a0)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).([]models.Schedule)
+		}
+	}
+
+	if rf, ok := ret.Get(1).(func(context.Context) error); ok {
+		r1 = rf(_a0)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// GetTemplate provides a mock function with given fields: _a0, _a1
+func (_m *Commander) GetTemplate(_a0 context.Context, _a1 int64) (*models.Template, error) {
+	ret := _m.Called(_a0, _a1)
+
+	if len(ret) == 0 {
+		panic("no return value specified for GetTemplate")
+	}
+
+	var r0 *models.Template
+	var r1 error
+	if rf, ok := ret.Get(0).(func(context.Context, int64) (*models.Template, error)); ok {
+		return rf(_a0, _a1)
+	}
+	if rf, ok := ret.Get(0).(func(context.Context, int64) *models.Template); ok {
+		r0 = rf(_a0, _a1)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).(*models.Template)
+		}
+	}
+
+	if rf, ok := ret.Get(1).(func(context.Context, int64) error); ok {
+		r1 = rf(_a0, _a1)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// GetTemplateList provides a mock function with given fields: _a0
+func (_m *Commander) GetTemplateList(_a0 context.Context) ([]models.Template, error) {
+	ret := _m.Called(_a0)
+
+	if len(ret) == 0 {
+		panic("no return value specified for GetTemplateList")
+	}
+
+	var r0 []models.Template
+	var r1 error
+	if rf, ok := ret.Get(0).(func(context.Context) ([]models.Template, error)); ok {
+		return rf(_a0)
+	}
+	if rf, ok := ret.Get(0).(func(context.Context) []models.Template); ok {
+		r0 = rf(_a0)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).([]models.Template)
+		}
+	}
+
+	if rf, ok := ret.Get(1).(func(context.Context) error); ok {
+		r1 = rf(_a0)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// NextRuns provides a mock function with given fields: _a0, _a1, _a2
+func (_m *Commander) NextRuns(_a0 context.Context, _a1 int64, _a2 int) ([]time.Time, error) {
+	ret := _m.Called(_a0, _a1, _a2)
+
+	if len(ret) == 0 {
+		panic("no return value specified for NextRuns")
+	}
+
+	var r0 []time.Time
+	var r1 error
+	if rf, ok := ret.Get(0).(func(context.Context, int64, int) ([]time.Time, error)); ok {
+		return rf(_a0, _a1, _a2)
+	}
+	if rf, ok := ret.Get(0).(func(context.Context, int64, int) []time.Time); ok {
+		r0 = rf(_a0, _a1, _a2)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).([]time.Time)
+		}
+	}
+
+	if rf, ok := ret.Get(1).(func(context.Context, int64, int) error); ok {
+		r1 = rf(_a0, _a1, _a2)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// OpenArtifact provides a mock function with given fields: _a0, _a1, _a2
+func (_m *Commander) OpenArtifact(_a0 context.Context, _a1 int64, _a2 int64) (*models.Artifact, *os.File, error) {
+	ret := _m.Called(_a0, _a1, _a2)
+
+	if len(ret) == 0 {
+		panic("no return value specified for OpenArtifact")
+	}
+
+	var r0 *models.Artifact
+	var r1 *os.File
+	var r2 error
+	if rf, ok := ret.Get(0).(func(context.Context, int64, int64) (*models.Artifact, *os.File, error)); ok {
+		return rf(_a0, _a1, _a2)
+	}
+	if rf, ok := ret.Get(0).(func(context.Context, int64, int64) *models.Artifact); ok {
+		r0 = rf(_a0, _a1, _a2)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).(*models.Artifact)
+		}
+	}
+
+	if rf, ok := ret.Get(1).(func(context.Context, int64, int64) *os.File); ok {
+		r1 = rf(_a0, _a1, _a2)
+	} else {
+		if ret.Get(1) != nil {
+			r1 = ret.Get(1).(*os.File)
+		}
+	}
+
+	if rf, ok := ret.Get(2).(func(context.Context, int64, int64) error); ok {
+		r2 = rf(_a0, _a1, _a2)
+	} else {
+		r2 = ret.Error(2)
+	}
+
+	return r0, r1, r2
+}
+
+// RerunCommand provides a mock function with given fields: _a0, _a1, _a2
+func (_m *Commander) RerunCommand(_a0 context.Context, _a1 int64, _a2 map[string]string) (int64, error) {
+	ret := _m.Called(_a0, _a1, _a2)
+
+	if len(ret) == 0 {
+		panic("no return value specified for RerunCommand")
+	}
+
+	var r0 int64
+	var r1 error
+	if rf, ok := ret.Get(0).(func(context.Context, int64, map[string]string) (int64, error)); ok {
+		return rf(_a0, _a1, _a2)
+	}
+	if rf, ok := ret.Get(0).(func(context.Context, int64, map[string]string) int64); ok {
+		r0 = rf(_a0, _a1, _a2)
+	} else {
+		r0 = ret.Get(0).(int64)
+	}
+
+	if rf, ok := ret.Get(1).(func(context.Context, int64, map[string]string) error); ok {
+		r1 = rf(_a0, _a1, _a2)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// RunTemplate provides a mock function with given fields: _a0, _a1, _a2, _a3
+func (_m *Commander) RunTemplate(_a0 context.Context, _a1 int64, _a2 map[string]interface{}, _a3 map[string]string) (int64, error) {
+	ret := _m.Called(_a0, _a1, _a2, _a3)
+
+	if len(ret) == 0 {
+		panic("no return value specified for RunTemplate")
+	}
+
+	var r0 int64
+	var r1 error
+	if rf, ok := ret.Get(0).(func(context.Context, int64, map[string]interface{}, map[string]string) (int64, error)); ok {
+		return rf(_a0, _a1, _a2, _a3)
+	}
+	if rf, ok := ret.Get(0).(func(context.Context, int64, map[string]interface{}, map[string]string) int64); ok {
+		r0 = rf(_a0, _a1, _a2, _a3)
+	} else {
+		r0 = ret.Get(0).(int64)
+	}
+
+	if rf, ok := ret.Get(1).(func(context.Context, int64, map[string]interface{}, map[string]string) error); ok {
+		r1 = rf(_a0, _a1, _a2, _a3)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// StopCommand provides a mock function with given fields: _a0, _a1, _a2
+func (_m *Commander) StopCommand(_a0 context.Context, _a1 int64, _a2 string) (int64, error) {
+	ret := _m.Called(_a0, _a1, _a2)
+
+	if len(ret) == 0 {
+		panic("no return value specified for StopCommand")
+	}
+
+	var r0 int64
+	var r1 error
+	if rf, ok := ret.Get(0).(func(context.Context, int64, string) (int64, error)); ok {
+		return rf(_a0, _a1, _a2)
+	}
+	if rf, ok := ret.Get(0).(func(context.Context, int64, string) int64); ok {
+		r0 = rf(_a0, _a1, _a2)
+	} else {
+		r0 = ret.Get(0).(int64)
+	}
+
+	if rf, ok := ret.Get(1).(func(context.Context, int64, string) error); ok {
+		r1 = rf(_a0, _a1, _a2)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// StreamCommand provides a mock function with given fields: _a0, _a1, _a2
+func (_m *Commander) StreamCommand(_a0 context.Context, _a1 int64, _a2 int64) (<-chan models.Event, error) {
+	ret := _m.Called(_a0, _a1, _a2)
+
+	if len(ret) == 0 {
+		panic("no return value specified for StreamCommand")
+	}
+
+	var r0 <-chan models.Event
+	var r1 error
+	if rf, ok := ret.Get(0).(func(context.Context, int64, int64) (<-chan models.Event, error)); ok {
+		return rf(_a0, _a1, _a2)
+	}
+	if rf, ok := ret.Get(0).(func(context.Context, int64, int64) <-chan models.Event); ok {
+		r0 = rf(_a0, _a1, _a2)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).(<-chan models.Event)
+		}
+	}
+
+	if rf, ok := ret.Get(1).(func(context.Context, int64, int64) error); ok {
+		r1 = rf(_a0, _a1, _a2)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// UpdateSchedule provides a mock function with given fields: _a0, _a1
+func (_m *Commander) UpdateSchedule(_a0 context.Context, _a1 models.Schedule) error {
+	ret := _m.Called(_a0, _a1)
+
+	if len(ret) == 0 {
+		panic("no return value specified for UpdateSchedule")
+	}
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func(context.Context, models.Schedule) error); ok {
+		r0 = rf(_a0, _a1)
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
+
+// UpdateTemplate provides a mock function with given fields: _a0, _a1
+func (_m *Commander) UpdateTemplate(_a0 context.Context, _a1 models.Template) error {
+	ret := _m.Called(_a0, _a1)
+
+	if len(ret) == 0 {
+		panic("no return value specified for UpdateTemplate")
+	}
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func(context.Context, models.Template) error); ok {
+		r0 = rf(_a0, _a1)
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
+
+// WriteInput provides a mock function with given fields: _a0, _a1, _a2
+func (_m *Commander) WriteInput(_a0 context.Context, _a1 int64, _a2 []byte) error {
+	ret := _m.Called(_a0, _a1, _a2)
+
+	if len(ret) == 0 {
+		panic("no return value specified for WriteInput")
+	}
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func(context.Context, int64, []byte) error); ok {
+		r0 = rf(_a0, _a1, _a2)
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
+
 // NewCommander creates a new instance of Commander. It also registers a testing interface on the mock and a cleanup function to assert the mocks expectations.
 // The first argument is typically a *testing.T value.
 func NewCommander(t interface {
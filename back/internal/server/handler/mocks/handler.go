@@ -6,7 +6,9 @@ package mocks
 
 import (
 	context "context"
+	os "os"
 	reflect "reflect"
+	time "time"
 
 	models "github.com/enchik0reo/commandApi/internal/models"
 	gomock "github.com/golang/mock/gomock"
@@ -35,8 +37,22 @@ func (m *MockCommander) EXPECT() *MockCommanderMockRecorder {
 	return m.recorder
 }
 
+// CloseInput mocks base method.
+func (m *MockCommander) CloseInput(arg0 int64) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "CloseInput", arg0)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// CloseInput indicates an expected call of CloseInput.
+func (mr *MockCommanderMockRecorder) CloseInput(arg0 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "CloseInput", reflect.TypeOf((*MockCommander)(nil).CloseInput), arg0)
+}
+
 // CreateNewCommand mocks base method.
-func (m *MockCommander) CreateNewCommand(arg0 context.Context, arg1 string) (int64, error) {
+func (m *MockCommander) CreateNewCommand(arg0 context.Context, arg1 models.Script) (int64, error) {
 	m.ctrl.T.Helper()
 	ret := m.ctrl.Call(m, "CreateNewCommand", arg0, arg1)
 	ret0, _ := ret[0].(int64)
@@ -50,13 +66,101 @@ func (mr *MockCommanderMockRecorder) CreateNewCommand(arg0, arg1 interface{}) *g
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "CreateNewCommand", reflect.TypeOf((*MockCommander)(nil).CreateNewCommand), arg0, arg1)
 }
 
+// CreateSchedule mocks base method.
+func (m *MockCommander) CreateSchedule(arg0 context.Context, arg1 models.Schedule) (int64, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "CreateSchedule", arg0, arg1)
+	ret0, _ := ret[0].(int64)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// CreateSchedule indicates an expected call of CreateSchedule.
+func (mr *MockCommanderMockRecorder) CreateSchedule(arg0, arg1 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "CreateSchedule", reflect.TypeOf((*MockCommander)(nil).CreateSchedule), arg0, arg1)
+}
+
+// CreateTemplate mocks base method.
+func (m *MockCommander) CreateTemplate(arg0 context.Context, arg1 models.Template) (int64, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "CreateTemplate", arg0, arg1)
+	ret0, _ := ret[0].(int64)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// CreateTemplate indicates an expected call of CreateTemplate.
+func (mr *MockCommanderMockRecorder) CreateTemplate(arg0, arg1 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "CreateTemplate", reflect.TypeOf((*MockCommander)(nil).CreateTemplate), arg0, arg1)
+}
+
+// DeleteSchedule mocks base method.
+func (m *MockCommander) DeleteSchedule(arg0 context.Context, arg1 int64) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "DeleteSchedule", arg0, arg1)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// DeleteSchedule indicates an expected call of DeleteSchedule.
+func (mr *MockCommanderMockRecorder) DeleteSchedule(arg0, arg1 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "DeleteSchedule", reflect.TypeOf((*MockCommander)(nil).DeleteSchedule), arg0, arg1)
+}
+
+// DeleteTemplate mocks base method.
+func (m *MockCommander) DeleteTemplate(arg0 context.Context, arg1 int64) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "DeleteTemplate", arg0, arg1)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// DeleteTemplate indicates an expected call of DeleteTemplate.
+func (mr *MockCommanderMockRecorder) DeleteTemplate(arg0, arg1 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "DeleteTemplate", reflect.TypeOf((*MockCommander)(nil).DeleteTemplate), arg0, arg1)
+}
+
+// EachCommandOutput mocks base method.
+func (m *MockCommander) EachCommandOutput(arg0 context.Context, arg1 int64, arg2 func(models.Output) error) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "EachCommandOutput", arg0, arg1, arg2)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// EachCommandOutput indicates an expected call of EachCommandOutput.
+func (mr *MockCommanderMockRecorder) EachCommandOutput(arg0, arg1, arg2 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "EachCommandOutput", reflect.TypeOf((*MockCommander)(nil).EachCommandOutput), arg0, arg1, arg2)
+}
+
+// GetArtifactList mocks base method.
+func (m *MockCommander) GetArtifactList(arg0 context.Context, arg1 int64) ([]models.Artifact, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetArtifactList", arg0, arg1)
+	ret0, _ := ret[0].([]models.Artifact)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetArtifactList indicates an expected call of GetArtifactList.
+func (mr *MockCommanderMockRecorder) GetArtifactList(arg0, arg1 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetArtifactList", reflect.TypeOf((*MockCommander)(nil).GetArtifactList), arg0, arg1)
+}
+
 // GetCommandList mocks base method.
-func (m *MockCommander) GetCommandList(arg0 context.Context, arg1 int64) ([]models.Command, error) {
+func (m *MockCommander) GetCommandList(arg0 context.Context, arg1 models.CommandFilter) ([]models.Command, int64, error) {
 	m.ctrl.T.Helper()
 	ret := m.ctrl.Call(m, "GetCommandList", arg0, arg1)
 	ret0, _ := ret[0].([]models.Command)
-	ret1, _ := ret[1].(error)
-	return ret0, ret1
+	ret1, _ := ret[1].(int64)
+	ret2, _ := ret[2].(error)
+	return ret0, ret1, ret2
 }
 
 // GetCommandList indicates an expected call of GetCommandList.
@@ -65,6 +169,22 @@ func (mr *MockCommanderMockRecorder) GetCommandList(arg0, arg1 interface{}) *gom
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetCommandList", reflect.TypeOf((*MockCommander)(nil).GetCommandList), arg0, arg1)
 }
 
+// GetCommandOutput mocks base method.
+func (m *MockCommander) GetCommandOutput(arg0 context.Context, arg1 int64, arg2 models.OutputRange) ([]models.Output, int64, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetCommandOutput", arg0, arg1, arg2)
+	ret0, _ := ret[0].([]models.Output)
+	ret1, _ := ret[1].(int64)
+	ret2, _ := ret[2].(error)
+	return ret0, ret1, ret2
+}
+
+// GetCommandOutput indicates an expected call of GetCommandOutput.
+func (mr *MockCommanderMockRecorder) GetCommandOutput(arg0, arg1, arg2 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetCommandOutput", reflect.TypeOf((*MockCommander)(nil).GetCommandOutput), arg0, arg1, arg2)
+}
+
 // GetOneCommandDescription mocks base method.
 func (m *MockCommander) GetOneCommandDescription(arg0 context.Context, arg1 int64) (*models.Command, error) {
 	m.ctrl.T.Helper()
@@ -80,17 +200,195 @@ func (mr *MockCommanderMockRecorder) GetOneCommandDescription(arg0, arg1 interfa
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetOneCommandDescription", reflect.TypeOf((*MockCommander)(nil).GetOneCommandDescription), arg0, arg1)
 }
 
+// GetSchedule mocks base method.
+func (m *MockCommander) GetSchedule(arg0 context.Context, arg1 int64) (*models.Schedule, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetSchedule", arg0, arg1)
+	ret0, _ := ret[0].(*models.Schedule)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetSchedule indicates an expected call of GetSchedule.
+func (mr *MockCommanderMockRecorder) GetSchedule(arg0, arg1 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetSchedule", reflect.TypeOf((*MockCommander)(nil).GetSchedule), arg0, arg1)
+}
+
+// GetScheduleList mocks base method.
+func (m *MockCommander) GetScheduleList(arg0 context.Context) ([]models.Schedule, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetScheduleList", arg0)
+	ret0, _ := ret[0].([]models.Schedule)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetScheduleList indicates an expected call of GetScheduleList.
+func (mr *MockCommanderMockRecorder) GetScheduleList(arg0 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetScheduleList", reflect.TypeOf((*MockCommander)(nil).GetScheduleList), arg0)
+}
+
+// GetTemplate mocks base method.
+func (m *MockCommander) GetTemplate(arg0 context.Context, arg1 int64) (*models.Template, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetTemplate", arg0, arg1)
+	ret0, _ := ret[0].(*models.Template)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetTemplate indicates an expected call of GetTemplate.
+func (mr *MockCommanderMockRecorder) GetTemplate(arg0, arg1 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetTemplate", reflect.TypeOf((*MockCommander)(nil).GetTemplate), arg0, arg1)
+}
+
+// GetTemplateList mocks base method.
+func (m *MockCommander) GetTemplateList(arg0 context.Context) ([]models.Template, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetTemplateList", arg0)
+	ret0, _ := ret[0].([]models.Template)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetTemplateList indicates an expected call of GetTemplateList.
+func (mr *MockCommanderMockRecorder) GetTemplateList(arg0 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetTemplateList", reflect.TypeOf((*MockCommander)(nil).GetTemplateList), arg0)
+}
+
+// NextRuns mocks base method.
+func (m *MockCommander) NextRuns(arg0 context.Context, arg1 int64, arg2 int) ([]time.Time, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "NextRuns", arg0, arg1, arg2)
+	ret0, _ := ret[0].([]time.Time)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// NextRuns indicates an expected call of NextRuns.
+func (mr *MockCommanderMockRecorder) NextRuns(arg0, arg1, arg2 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "NextRuns", reflect.TypeOf((*MockCommander)(nil).NextRuns), arg0, arg1, arg2)
+}
+
+// OpenArtifact mocks base method.
+func (m *MockCommander) OpenArtifact(arg0 context.Context, arg1, arg2 int64) (*models.Artifact, *os.File, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "OpenArtifact", arg0, arg1, arg2)
+	ret0, _ := ret[0].(*models.Artifact)
+	ret1, _ := ret[1].(*os.File)
+	ret2, _ := ret[2].(error)
+	return ret0, ret1, ret2
+}
+
+// OpenArtifact indicates an expected call of OpenArtifact.
+func (mr *MockCommanderMockRecorder) OpenArtifact(arg0, arg1, arg2 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "OpenArtifact", reflect.TypeOf((*MockCommander)(nil).OpenArtifact), arg0, arg1, arg2)
+}
+
+// RerunCommand mocks base method.
+func (m *MockCommander) RerunCommand(arg0 context.Context, arg1 int64, arg2 map[string]string) (int64, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "RerunCommand", arg0, arg1, arg2)
+	ret0, _ := ret[0].(int64)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// RerunCommand indicates an expected call of RerunCommand.
+func (mr *MockCommanderMockRecorder) RerunCommand(arg0, arg1, arg2 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "RerunCommand", reflect.TypeOf((*MockCommander)(nil).RerunCommand), arg0, arg1, arg2)
+}
+
+// RunTemplate mocks base method.
+func (m *MockCommander) RunTemplate(arg0 context.Context, arg1 int64, arg2 map[string]interface{}, arg3 map[string]string) (int64, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "RunTemplate", arg0, arg1, arg2, arg3)
+	ret0, _ := ret[0].(int64)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// RunTemplate indicates an expected call of RunTemplate.
+func (mr *MockCommanderMockRecorder) RunTemplate(arg0, arg1, arg2, arg3 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "RunTemplate", reflect.TypeOf((*MockCommander)(nil).RunTemplate), arg0, arg1, arg2, arg3)
+}
+
 // StopCommand mocks base method.
-func (m *MockCommander) StopCommand(arg0 context.Context, arg1 int64) (int64, error) {
+func (m *MockCommander) StopCommand(arg0 context.Context, arg1 int64, arg2 string) (int64, error) {
 	m.ctrl.T.Helper()
-	ret := m.ctrl.Call(m, "StopCommand", arg0, arg1)
+	ret := m.ctrl.Call(m, "StopCommand", arg0, arg1, arg2)
 	ret0, _ := ret[0].(int64)
 	ret1, _ := ret[1].(error)
 	return ret0, ret1
 }
 
 // StopCommand indicates an expected call of StopCommand.
-func (mr *MockCommanderMockRecorder) StopCommand(arg0, arg1 interface{}) *gomock.Call {
+func (mr *MockCommanderMockRecorder) StopCommand(arg0, arg1, arg2 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "StopCommand", reflect.TypeOf((*MockCommander)(nil).StopCommand), arg0, arg1, arg2)
+}
+
+// StreamCommand mocks base method.
+func (m *MockCommander) StreamCommand(arg0 context.Context, arg1, arg2 int64) (<-chan models.Event, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "StreamCommand", arg0, arg1, arg2)
+	ret0, _ := ret[0].(<-chan models.Event)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// StreamCommand indicates an expected call of StreamCommand.
+func (mr *MockCommanderMockRecorder) StreamCommand(arg0, arg1, arg2 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "StreamCommand", reflect.TypeOf((*MockCommander)(nil).StreamCommand), arg0, arg1, arg2)
+}
+
+// UpdateSchedule mocks base method.
+func (m *MockCommander) UpdateSchedule(arg0 context.Context, arg1 models.Schedule) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "UpdateSchedule", arg0, arg1)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// UpdateSchedule indicates an expected call of UpdateSchedule.
+func (mr *MockCommanderMockRecorder) UpdateSchedule(arg0, arg1 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "UpdateSchedule", reflect.TypeOf((*MockCommander)(nil).UpdateSchedule), arg0, arg1)
+}
+
+// UpdateTemplate mocks base method.
+func (m *MockCommander) UpdateTemplate(arg0 context.Context, arg1 models.Template) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "UpdateTemplate", arg0, arg1)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// UpdateTemplate indicates an expected call of UpdateTemplate.
+func (mr *MockCommanderMockRecorder) UpdateTemplate(arg0, arg1 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "UpdateTemplate", reflect.TypeOf((*MockCommander)(nil).UpdateTemplate), arg0, arg1)
+}
+
+// WriteInput mocks base method.
+func (m *MockCommander) WriteInput(arg0 context.Context, arg1 int64, arg2 []byte) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "WriteInput", arg0, arg1, arg2)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// WriteInput indicates an expected call of WriteInput.
+func (mr *MockCommanderMockRecorder) WriteInput(arg0, arg1, arg2 interface{}) *gomock.Call {
 	mr.mock.ctrl.T.Helper()
-	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "StopCommand", reflect.TypeOf((*MockCommander)(nil).StopCommand), arg0, arg1)
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "WriteInput", reflect.TypeOf((*MockCommander)(nil).WriteInput), arg0, arg1, arg2)
 }
@@ -1,8 +1,16 @@
 package handler
 
 import (
+	"bufio"
+	"compress/gzip"
+	"encoding/base64"
 	"encoding/json"
+	"fmt"
+	"io"
 	"net/http"
+	"strconv"
+	"strings"
+	"time"
 
 	"github.com/enchik0reo/commandApi/internal/models"
 )
@@ -13,7 +21,9 @@ type idRespOK struct {
 }
 
 type idRespBodyOK struct {
-	CommandID int64 `json:"command_id,omitempty"`
+	CommandID  int64 `json:"command_id,omitempty"`
+	TemplateID int64 `json:"template_id,omitempty"`
+	ScheduleID int64 `json:"schedule_id,omitempty"`
 }
 
 func idRespJSONOk(w http.ResponseWriter, status int, body idRespBodyOK) error {
@@ -43,7 +53,8 @@ type commandsRespOK struct {
 }
 
 type commandsRespBodyOK struct {
-	Commands []models.Command `json:"commands,omitempty"`
+	Commands   []models.Command `json:"commands,omitempty"`
+	NextCursor int64            `json:"next_cursor,omitempty"`
 }
 
 func commandsRespJSONOk(w http.ResponseWriter, status int, body commandsRespBodyOK) error {
@@ -97,6 +108,453 @@ func commandRespJSONOk(w http.ResponseWriter, status int, body commandRespBodyOK
 	return nil
 }
 
+type outputRespOK struct {
+	Status int              `json:"status"`
+	Body   outputRespBodyOK `json:"body"`
+}
+
+type outputRespBodyOK struct {
+	CommandID int64           `json:"command_id"`
+	Lines     []models.Output `json:"lines"`
+	LastLine  int64           `json:"last_line"` // Number of the last saved line
+}
+
+func outputRespJSONOk(w http.ResponseWriter, status int, body outputRespBodyOK) error {
+	resp := outputRespOK{
+		Status: status,
+		Body:   body,
+	}
+
+	w.Header().Add("Content-Type", "application/json")
+
+	respJSON, err := json.Marshal(resp)
+	if err != nil {
+		return err
+	}
+
+	_, err = w.Write(respJSON)
+	if err != nil {
+		return err
+	}
+
+	return nil
+}
+
+type templateResponse struct {
+	ID        int64  `json:"id"`
+	CreatedAt string `json:"created_at"`
+	UpdatedAt string `json:"updated_at"`
+	templateRequest
+}
+
+// newTemplateResponse converts the template to its description in response ...
+func newTemplateResponse(tmpl models.Template) templateResponse {
+	return templateResponse{
+		ID:        tmpl.ID,
+		CreatedAt: tmpl.CreatedAt,
+		UpdatedAt: tmpl.UpdatedAt,
+		templateRequest: templateRequest{
+			Name:        tmpl.Name,
+			Description: tmpl.Description,
+			Script:      tmpl.Script,
+			Params:      tmpl.Params,
+			Timeout:     int64(tmpl.Options.Timeout / time.Second),
+			Limits: limitsRequest{
+				CPUTime:   int64(tmpl.Options.Limits.CPUTime / time.Second),
+				MemoryMB:  tmpl.Options.Limits.MemoryMB,
+				OpenFiles: tmpl.Options.Limits.OpenFiles,
+				Processes: tmpl.Options.Limits.Processes,
+			},
+			Env:         tmpl.Options.Env,
+			Args:        tmpl.Options.Args,
+			WorkingDir:  tmpl.Options.WorkingDir,
+			Interpreter: tmpl.Options.Interpreter,
+			Artifacts:   tmpl.Options.Artifacts,
+		},
+	}
+}
+
+type templatesRespOK struct {
+	Status int                 `json:"status"`
+	Body   templatesRespBodyOK `json:"body"`
+}
+
+type templatesRespBodyOK struct {
+	Templates []templateResponse `json:"templates"`
+}
+
+func templatesRespJSONOk(w http.ResponseWriter, status int, body templatesRespBodyOK) error {
+	resp := templatesRespOK{
+		Status: status,
+		Body:   body,
+	}
+
+	w.Header().Add("Content-Type", "application/json")
+
+	respJSON, err := json.Marshal(resp)
+	if err != nil {
+		return err
+	}
+
+	_, err = w.Write(respJSON)
+	if err != nil {
+		return err
+	}
+
+	return nil
+}
+
+type templateRespOK struct {
+	Status int                `json:"status"`
+	Body   templateRespBodyOK `json:"body"`
+}
+
+type templateRespBodyOK struct {
+	Template templateResponse `json:"template"`
+}
+
+func templateRespJSONOk(w http.ResponseWriter, status int, body templateRespBodyOK) error {
+	resp := templateRespOK{
+		Status: status,
+		Body:   body,
+	}
+
+	w.Header().Add("Content-Type", "application/json")
+
+	respJSON, err := json.Marshal(resp)
+	if err != nil {
+		return err
+	}
+
+	_, err = w.Write(respJSON)
+	if err != nil {
+		return err
+	}
+
+	return nil
+}
+
+type scheduleResponse struct {
+	ID            int64  `json:"id"`
+	LastCommandID int64  `json:"last_command_id,omitempty"`
+	CreatedAt     string `json:"created_at"`
+	UpdatedAt     string `json:"updated_at"`
+	scheduleRequest
+}
+
+// newScheduleResponse converts the schedule to its description in response ...
+func newScheduleResponse(sch models.Schedule) scheduleResponse {
+	return scheduleResponse{
+		ID:            sch.ID,
+		LastCommandID: sch.LastCommandID,
+		CreatedAt:     sch.CreatedAt,
+		UpdatedAt:     sch.UpdatedAt,
+		scheduleRequest: scheduleRequest{
+			Name:       sch.Name,
+			Spec:       sch.Spec,
+			Script:     sch.Script,
+			TemplateID: sch.TemplateID,
+			Params:     sch.Params,
+			NoOverlap:  sch.NoOverlap,
+			Paused:     sch.Paused,
+			Timeout:    int64(sch.Options.Timeout / time.Second),
+			Limits: limitsRequest{
+				CPUTime:   int64(sch.Options.Limits.CPUTime / time.Second),
+				MemoryMB:  sch.Options.Limits.MemoryMB,
+				OpenFiles: sch.Options.Limits.OpenFiles,
+				Processes: sch.Options.Limits.Processes,
+			},
+			Env:         sch.Options.Env,
+			Args:        sch.Options.Args,
+			WorkingDir:  sch.Options.WorkingDir,
+			Interpreter: sch.Options.Interpreter,
+			Artifacts:   sch.Options.Artifacts,
+		},
+	}
+}
+
+type schedulesRespOK struct {
+	Status int                 `json:"status"`
+	Body   schedulesRespBodyOK `json:"body"`
+}
+
+type schedulesRespBodyOK struct {
+	Schedules []scheduleResponse `json:"schedules"`
+}
+
+func schedulesRespJSONOk(w http.ResponseWriter, status int, body schedulesRespBodyOK) error {
+	resp := schedulesRespOK{
+		Status: status,
+		Body:   body,
+	}
+
+	w.Header().Add("Content-Type", "application/json")
+
+	respJSON, err := json.Marshal(resp)
+	if err != nil {
+		return err
+	}
+
+	_, err = w.Write(respJSON)
+	if err != nil {
+		return err
+	}
+
+	return nil
+}
+
+type scheduleRespOK struct {
+	Status int                `json:"status"`
+	Body   scheduleRespBodyOK `json:"body"`
+}
+
+type scheduleRespBodyOK struct {
+	Schedule scheduleResponse `json:"schedule"`
+}
+
+func scheduleRespJSONOk(w http.ResponseWriter, status int, body scheduleRespBodyOK) error {
+	resp := scheduleRespOK{
+		Status: status,
+		Body:   body,
+	}
+
+	w.Header().Add("Content-Type", "application/json")
+
+	respJSON, err := json.Marshal(resp)
+	if err != nil {
+		return err
+	}
+
+	_, err = w.Write(respJSON)
+	if err != nil {
+		return err
+	}
+
+	return nil
+}
+
+type nextRunsRespOK struct {
+	Status int                `json:"status"`
+	Body   nextRunsRespBodyOK `json:"body"`
+}
+
+type nextRunsRespBodyOK struct {
+	Runs []time.Time `json:"runs"`
+}
+
+func nextRunsRespJSONOk(w http.ResponseWriter, status int, body nextRunsRespBodyOK) error {
+	resp := nextRunsRespOK{
+		Status: status,
+		Body:   body,
+	}
+
+	w.Header().Add("Content-Type", "application/json")
+
+	respJSON, err := json.Marshal(resp)
+	if err != nil {
+		return err
+	}
+
+	_, err = w.Write(respJSON)
+	if err != nil {
+		return err
+	}
+
+	return nil
+}
+
+// sseEvent writes the command's event in Server-Sent Events format ...
+func sseEvent(w io.Writer, ev models.Event) error {
+	var data any = ev.Output
+
+	if ev.Type == models.EventStatus {
+		data = ev.Result
+	}
+
+	dataJSON, err := json.Marshal(data)
+	if err != nil {
+		return err
+	}
+
+	if ev.ID > 0 {
+		if _, err := fmt.Fprintf(w, "id: %d\n", ev.ID); err != nil {
+			return err
+		}
+	}
+
+	_, err = fmt.Fprintf(w, "event: %s\ndata: %s\n\n", ev.Type, dataJSON)
+
+	return err
+}
+
+// sseHeartbeat writes comment line to keep the stream alive ...
+func sseHeartbeat(w io.Writer) error {
+	_, err := io.WriteString(w, ": ping\n\n")
+
+	return err
+}
+
+// outputFile writes the command's output as the downloaded file ...
+type outputFile struct {
+	format string
+	gz     *gzip.Writer
+	buf    *bufio.Writer
+	enc    *json.Encoder
+}
+
+// newOutputFile writes headers of the file and returns its writer.
+// The file is compressed with gzip if the client accepts it ...
+func newOutputFile(w http.ResponseWriter, r *http.Request, id int64, format string) *outputFile {
+	f := &outputFile{format: format}
+	var dst io.Writer = w
+
+	contentType := "text/plain; charset=utf-8"
+	if format == formatNDJSON {
+		contentType = "application/x-ndjson"
+	}
+
+	w.Header().Set("Content-Type", contentType)
+	w.Header().Set("Content-Disposition", fmt.Sprintf(`attachment; filename="command-%d-output.%s"`, id, format))
+	w.Header().Set("Vary", "Accept-Encoding")
+
+	if acceptsGzip(r) {
+		w.Header().Set("Content-Encoding", "gzip")
+
+		f.gz = gzip.NewWriter(w)
+		dst = f.gz
+	}
+
+	w.WriteHeader(http.StatusOK)
+
+	f.buf = bufio.NewWriter(dst)
+	f.enc = json.NewEncoder(f.buf)
+
+	return f
+}
+
+// write writes the output line to the file.
+// Text file gets decoded binary lines and parts of long lines without line endings between them ...
+func (f *outputFile) write(output models.Output) error {
+	if f.format == formatNDJSON {
+		return f.enc.Encode(output)
+	}
+
+	text := []byte(output.Text)
+
+	if output.Encoding == models.EncodingBase64 {
+		if data, err := base64.StdEncoding.DecodeString(output.Text); err == nil {
+			text = data
+		}
+	}
+
+	if _, err := f.buf.Write(text); err != nil {
+		return err
+	}
+
+	if output.Partial {
+		return nil
+	}
+
+	return f.buf.WriteByte('\n')
+}
+
+// close flushes the rest of the file ...
+func (f *outputFile) close() error {
+	if err := f.buf.Flush(); err != nil {
+		return err
+	}
+
+	if f.gz != nil {
+		return f.gz.Close()
+	}
+
+	return nil
+}
+
+// acceptsGzip checks that the client accepts gzip encoding of the response ...
+func acceptsGzip(r *http.Request) bool {
+	for _, enc := range strings.Split(r.Header.Get("Accept-Encoding"), ",") {
+		name, params, _ := strings.Cut(strings.TrimSpace(enc), ";")
+
+		if strings.TrimSpace(name) != "gzip" {
+			continue
+		}
+
+		q, ok := strings.CutPrefix(strings.TrimSpace(params), "q=")
+		if !ok {
+			return true
+		}
+
+		weight, err := strconv.ParseFloat(q, 64)
+
+		return err == nil && weight > 0
+	}
+
+	return false
+}
+
+type attachResponse struct {
+	Type     string         `json:"type"`
+	Stream   string         `json:"stream,omitempty"`
+	Text     string         `json:"text,omitempty"`
+	Encoding string         `json:"encoding,omitempty"`
+	Partial  bool           `json:"partial,omitempty"`
+	Result   *models.Result `json:"result,omitempty"`
+	Error    string         `json:"error,omitempty"`
+}
+
+// attachEventResponse converts the command's event to WebSocket message ...
+func attachEventResponse(ev models.Event) attachResponse {
+	resp := attachResponse{Type: ev.Type}
+
+	if ev.Output != nil {
+		resp.Stream = ev.Output.Stream
+		resp.Text = ev.Output.Text
+		resp.Encoding = ev.Output.Encoding
+		resp.Partial = ev.Output.Partial
+	}
+
+	resp.Result = ev.Result
+
+	return resp
+}
+
+// attachErrorResponse converts the input error to WebSocket message ...
+func attachErrorResponse(err error) attachResponse {
+	return attachResponse{Type: "error", Error: err.Error()}
+}
+
+type artifactsRespOK struct {
+	Status int                 `json:"status"`
+	Body   artifactsRespBodyOK `json:"body"`
+}
+
+type artifactsRespBodyOK struct {
+	CommandID int64             `json:"command_id"`
+	Artifacts []models.Artifact `json:"artifacts"`
+}
+
+func artifactsRespJSONOk(w http.ResponseWriter, status int, body artifactsRespBodyOK) error {
+	resp := artifactsRespOK{
+		Status: status,
+		Body:   body,
+	}
+
+	w.Header().Add("Content-Type", "application/json")
+
+	respJSON, err := json.Marshal(resp)
+	if err != nil {
+		return err
+	}
+
+	_, err = w.Write(respJSON)
+	if err != nil {
+		return err
+	}
+
+	return nil
+}
+
 type responseErr struct {
 	Status int         `json:"status"`
 	Body   respBodyErr `json:"body"`
@@ -3,6 +3,7 @@ package handler
 import (
 	"context"
 	"net/http"
+	"os"
 	"time"
 
 	_ "github.com/enchik0reo/commandApi/docs"
@@ -11,6 +12,7 @@ import (
 
 	"github.com/go-chi/chi"
 	"github.com/go-chi/chi/middleware"
+	"github.com/gorilla/websocket"
 	httpSwagger "github.com/swaggo/http-swagger" // swagger embed files
 )
 
@@ -18,22 +20,43 @@ import (
 
 //go:generate go run github.com/vektra/mockery/v2@v2.42.2 --name=Commander
 type Commander interface {
-	CreateNewCommand(context.Context, string) (int64, error)
-	GetCommandList(context.Context, int64) ([]models.Command, error)
+	CreateNewCommand(context.Context, models.Script) (int64, error)
+	GetCommandList(context.Context, models.CommandFilter) ([]models.Command, int64, error)
 	GetOneCommandDescription(context.Context, int64) (*models.Command, error)
-	StopCommand(context.Context, int64) (int64, error)
+	GetCommandOutput(context.Context, int64, models.OutputRange) ([]models.Output, int64, error)
+	EachCommandOutput(context.Context, int64, func(models.Output) error) error
+	GetArtifactList(context.Context, int64) ([]models.Artifact, error)
+	OpenArtifact(context.Context, int64, int64) (*models.Artifact, *os.File, error)
+	StreamCommand(context.Context, int64, int64) (<-chan models.Event, error)
+	WriteInput(context.Context, int64, []byte) error
+	CloseInput(int64) error
+	StopCommand(context.Context, int64, string) (int64, error)
+	RerunCommand(context.Context, int64, map[string]string) (int64, error)
+	CreateTemplate(context.Context, models.Template) (int64, error)
+	GetTemplateList(context.Context) ([]models.Template, error)
+	GetTemplate(context.Context, int64) (*models.Template, error)
+	UpdateTemplate(context.Context, models.Template) error
+	DeleteTemplate(context.Context, int64) error
+	RunTemplate(context.Context, int64, map[string]interface{}, map[string]string) (int64, error)
+	CreateSchedule(context.Context, models.Schedule) (int64, error)
+	GetScheduleList(context.Context) ([]models.Schedule, error)
+	GetSchedule(context.Context, int64) (*models.Schedule, error)
+	UpdateSchedule(context.Context, models.Schedule) error
+	DeleteSchedule(context.Context, int64) error
+	NextRuns(context.Context, int64, int) ([]time.Time, error)
 }
 
 type CustomRouter struct {
 	*chi.Mux
-	cmdr    Commander
-	timeout time.Duration
-	log     *logs.CustomLog
+	cmdr     Commander
+	timeout  time.Duration
+	log      *logs.CustomLog
+	upgrader websocket.Upgrader
 }
 
 // New returns new handler ...
 func New(cmdr Commander, domains []string, timeout time.Duration, log *logs.CustomLog) http.Handler {
-	r := CustomRouter{chi.NewRouter(), cmdr, timeout, log}
+	r := CustomRouter{chi.NewRouter(), cmdr, timeout, log, newUpgrader(domains)}
 
 	r.Use(middleware.RequestID)
 	r.Use(middleware.Recoverer)
@@ -45,8 +68,28 @@ func New(cmdr Commander, domains []string, timeout time.Duration, log *logs.Cust
 	r.Post("/create/upload", r.createUpload())
 	r.Get("/list", r.commands())
 	r.Get("/cmd", r.command())
+	r.Get("/cmd/{id}/output", r.commandOutput())
+	r.Get("/cmd/{id}/stream", r.streamCommand())
+	r.Get("/cmd/{id}/attach", r.attachCommand())
+	r.Get("/cmd/{id}/artifacts", r.artifacts())
+	r.Get("/cmd/{id}/artifacts/{artifactID}", r.artifact())
+	r.Post("/cmd/{id}/rerun", r.rerunCommand())
 	r.Put("/stop", r.stopCommand())
 
+	r.Post("/templates", r.createTemplate())
+	r.Get("/templates", r.templates())
+	r.Get("/templates/{id}", r.template())
+	r.Put("/templates/{id}", r.updateTemplate())
+	r.Delete("/templates/{id}", r.deleteTemplate())
+	r.Post("/templates/{id}/run", r.runTemplate())
+
+	r.Post("/schedules", r.createSchedule())
+	r.Get("/schedules", r.schedules())
+	r.Get("/schedules/{id}", r.schedule())
+	r.Put("/schedules/{id}", r.updateSchedule())
+	r.Delete("/schedules/{id}", r.deleteSchedule())
+	r.Get("/schedules/{id}/next", r.nextRuns())
+
 	r.Get("/swagger/*", httpSwagger.Handler(
 		httpSwagger.URL("http://localhost:8008/swagger/doc.json"),
 	))
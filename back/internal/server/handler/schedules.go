@@ -0,0 +1,369 @@
+package handler
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/enchik0reo/commandApi/internal/models"
+	"github.com/enchik0reo/commandApi/internal/services"
+
+	"github.com/go-chi/chi"
+)
+
+const defaultNextRuns = 5
+
+type scheduleRequest struct {
+	Name        string            `json:"name"`
+	Spec        string            `json:"spec"` // Standard cron expression or descriptor like @hourly
+	Script      string            `json:"script,omitempty"`
+	TemplateID  int64             `json:"template_id,omitempty"`
+	Params      map[string]any    `json:"params,omitempty"` // Values of the template's parameters
+	NoOverlap   bool              `json:"no_overlap"`
+	Paused      bool              `json:"paused"`
+	Timeout     int64             `json:"timeout,omitempty"` // Seconds, zero means the default timeout
+	Limits      limitsRequest     `json:"limits"`
+	Env         map[string]string `json:"env,omitempty"`
+	Args        []string          `json:"args,omitempty"`
+	WorkingDir  string            `json:"working_dir,omitempty"`
+	Interpreter string            `json:"interpreter,omitempty"`
+	Artifacts   []string          `json:"artifacts,omitempty"`
+}
+
+func (req scheduleRequest) schedule(id int64) models.Schedule {
+	return models.Schedule{
+		ID:         id,
+		Name:       req.Name,
+		Spec:       req.Spec,
+		Script:     req.Script,
+		TemplateID: req.TemplateID,
+		Params:     req.Params,
+		NoOverlap:  req.NoOverlap,
+		Paused:     req.Paused,
+		Options: models.Options{
+			Timeout:     time.Duration(req.Timeout) * time.Second,
+			Limits:      req.Limits.limits(),
+			Env:         req.Env,
+			Args:        req.Args,
+			WorkingDir:  req.WorkingDir,
+			Interpreter: req.Interpreter,
+			Artifacts:   req.Artifacts,
+		},
+	}
+}
+
+// decodeScheduleRequest decodes the schedule keeping numbers of parameters as they are ...
+func decodeScheduleRequest(r *http.Request) (scheduleRequest, error) {
+	req := scheduleRequest{}
+
+	dec := json.NewDecoder(r.Body)
+	dec.UseNumber()
+
+	err := dec.Decode(&req)
+
+	return req, err
+}
+
+// createSchedule godoc
+// @Summary Create new schedule
+// @Description Run the script or the template by cron expression.
+// @Description Expression has five fields or is a descriptor like @hourly or @every 10m.
+// @Description Schedule with no overlap skips the run while its previous command is still working.
+// @Tags  schedules
+// @Accept  json
+// @Produce  json
+// @Param schedule body scheduleRequest true "Schedule"
+// @Success 201 {object} idRespOK "Sucess"
+// @Failure 400 {object} responseErr "Bad request"
+// @Failure 404 {object} responseErr "Template not found"
+// @Failure 500 {object} responseErr "Internal server error"
+// @Router /schedules [post]
+func (h *CustomRouter) createSchedule() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+
+		defer r.Body.Close()
+
+		req, err := decodeScheduleRequest(r)
+		if err != nil {
+			h.log.Debug("Can't decode body from create schedule request", h.log.Attr("error", err))
+
+			err = responseJSONError(w, http.StatusBadRequest, http.StatusText(http.StatusBadRequest))
+			if err != nil {
+				h.log.Error("Can't make response", h.log.Attr("error", err))
+			}
+			return
+		}
+
+		ctx, cancel := context.WithTimeout(context.Background(), h.timeout)
+		defer cancel()
+
+		id, err := h.cmdr.CreateSchedule(ctx, req.schedule(0))
+		if err != nil {
+			h.scheduleError(w, "Can't create schedule", err)
+			return
+		}
+
+		if err = idRespJSONOk(w, http.StatusCreated, idRespBodyOK{ScheduleID: id}); err != nil {
+			h.log.Error("Can't make response", h.log.Attr("error", err))
+		}
+	}
+}
+
+// schedules godoc
+// @Summary Show schedules
+// @Description Show all schedules ordered by id
+// @Tags  schedules
+// @Produce  json
+// @Success 200 {object} schedulesRespOK "Sucess"
+// @Failure 500 {object} responseErr "Internal server error"
+// @Router /schedules [get]
+func (h *CustomRouter) schedules() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+
+		defer r.Body.Close()
+
+		ctx, cancel := context.WithTimeout(context.Background(), h.timeout)
+		defer cancel()
+
+		schs, err := h.cmdr.GetScheduleList(ctx)
+		if err != nil {
+			h.scheduleError(w, "Can't get list of schedules", err)
+			return
+		}
+
+		respBody := schedulesRespBodyOK{Schedules: make([]scheduleResponse, 0, len(schs))}
+
+		for _, sch := range schs {
+			respBody.Schedules = append(respBody.Schedules, newScheduleResponse(sch))
+		}
+
+		if err = schedulesRespJSONOk(w, http.StatusOK, respBody); err != nil {
+			h.log.Error("Can't make response", h.log.Attr("error", err))
+		}
+	}
+}
+
+// schedule godoc
+// @Summary Show one schedule
+// @Description Show schedule by id
+// @Tags  schedules
+// @Produce  json
+// @Param id path int true  "Schedule id"
+// @Success 200 {object} scheduleRespOK "Sucess"
+// @Failure 400 {object} responseErr "Bad request"
+// @Failure 404 {object} responseErr "Schedule not found"
+// @Failure 500 {object} responseErr "Internal server error"
+// @Router /schedules/{id} [get]
+func (h *CustomRouter) schedule() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+
+		defer r.Body.Close()
+
+		id, err := strconv.Atoi(chi.URLParam(r, "id"))
+		if err != nil {
+			h.log.Debug("Can't convert id to int", h.log.Attr("error", err))
+
+			err = responseJSONError(w, http.StatusBadRequest, http.StatusText(http.StatusBadRequest))
+			if err != nil {
+				h.log.Error("Can't make response", h.log.Attr("error", err))
+			}
+			return
+		}
+
+		ctx, cancel := context.WithTimeout(context.Background(), h.timeout)
+		defer cancel()
+
+		sch, err := h.cmdr.GetSchedule(ctx, int64(id))
+		if err != nil {
+			h.scheduleError(w, "Can't get schedule", err)
+			return
+		}
+
+		respBody := scheduleRespBodyOK{Schedule: newScheduleResponse(*sch)}
+
+		if err = scheduleRespJSONOk(w, http.StatusOK, respBody); err != nil {
+			h.log.Error("Can't make response", h.log.Attr("error", err))
+		}
+	}
+}
+
+// updateSchedule godoc
+// @Summary Update schedule
+// @Description Replace schedule by id, paused schedule stops firing
+// @Tags  schedules
+// @Accept  json
+// @Produce  json
+// @Param id path int true  "Schedule id"
+// @Param schedule body scheduleRequest true "Schedule"
+// @Success 200 {object} idRespOK "Sucess"
+// @Failure 400 {object} responseErr "Bad request"
+// @Failure 404 {object} responseErr "Schedule or template not found"
+// @Failure 500 {object} responseErr "Internal server error"
+// @Router /schedules/{id} [put]
+func (h *CustomRouter) updateSchedule() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+
+		defer r.Body.Close()
+
+		id, err := strconv.Atoi(chi.URLParam(r, "id"))
+		if err != nil {
+			h.log.Debug("Can't convert id to int", h.log.Attr("error", err))
+
+			err = responseJSONError(w, http.StatusBadRequest, http.StatusText(http.StatusBadRequest))
+			if err != nil {
+				h.log.Error("Can't make response", h.log.Attr("error", err))
+			}
+			return
+		}
+
+		req, err := decodeScheduleRequest(r)
+		if err != nil {
+			h.log.Debug("Can't decode body from update schedule request", h.log.Attr("error", err))
+
+			err = responseJSONError(w, http.StatusBadRequest, http.StatusText(http.StatusBadRequest))
+			if err != nil {
+				h.log.Error("Can't make response", h.log.Attr("error", err))
+			}
+			return
+		}
+
+		ctx, cancel := context.WithTimeout(context.Background(), h.timeout)
+		defer cancel()
+
+		if err = h.cmdr.UpdateSchedule(ctx, req.schedule(int64(id))); err != nil {
+			h.scheduleError(w, "Can't update schedule", err)
+			return
+		}
+
+		if err = idRespJSONOk(w, http.StatusOK, idRespBodyOK{ScheduleID: int64(id)}); err != nil {
+			h.log.Error("Can't make response", h.log.Attr("error", err))
+		}
+	}
+}
+
+// deleteSchedule godoc
+// @Summary Delete schedule
+// @Description Delete schedule by id, commands made by it are kept
+// @Tags  schedules
+// @Produce  json
+// @Param id path int true  "Schedule id"
+// @Success 200 {object} idRespOK "Sucess"
+// @Failure 400 {object} responseErr "Bad request"
+// @Failure 404 {object} responseErr "Schedule not found"
+// @Failure 500 {object} responseErr "Internal server error"
+// @Router /schedules/{id} [delete]
+func (h *CustomRouter) deleteSchedule() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+
+		defer r.Body.Close()
+
+		id, err := strconv.Atoi(chi.URLParam(r, "id"))
+		if err != nil {
+			h.log.Debug("Can't convert id to int", h.log.Attr("error", err))
+
+			err = responseJSONError(w, http.StatusBadRequest, http.StatusText(http.StatusBadRequest))
+			if err != nil {
+				h.log.Error("Can't make response", h.log.Attr("error", err))
+			}
+			return
+		}
+
+		ctx, cancel := context.WithTimeout(context.Background(), h.timeout)
+		defer cancel()
+
+		if err = h.cmdr.DeleteSchedule(ctx, int64(id)); err != nil {
+			h.scheduleError(w, "Can't delete schedule", err)
+			return
+		}
+
+		if err = idRespJSONOk(w, http.StatusOK, idRespBodyOK{ScheduleID: int64(id)}); err != nil {
+			h.log.Error("Can't make response", h.log.Attr("error", err))
+		}
+	}
+}
+
+// nextRuns godoc
+// @Summary Preview next runs
+// @Description Show the next times when the schedule fires
+// @Tags  schedules
+// @Produce  json
+// @Param id path int true  "Schedule id"
+// @Param count query int false  "Count of runs, 5 by default, 100 at most"
+// @Success 200 {object} nextRunsRespOK "Sucess"
+// @Failure 400 {object} responseErr "Bad request"
+// @Failure 404 {object} responseErr "Schedule not found"
+// @Failure 500 {object} responseErr "Internal server error"
+// @Router /schedules/{id}/next [get]
+func (h *CustomRouter) nextRuns() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+
+		defer r.Body.Close()
+
+		id, err := strconv.Atoi(chi.URLParam(r, "id"))
+		if err != nil {
+			h.log.Debug("Can't convert id to int", h.log.Attr("error", err))
+
+			err = responseJSONError(w, http.StatusBadRequest, http.StatusText(http.StatusBadRequest))
+			if err != nil {
+				h.log.Error("Can't make response", h.log.Attr("error", err))
+			}
+			return
+		}
+
+		count := defaultNextRuns
+
+		if c := r.URL.Query().Get("count"); c != "" {
+			count, err = strconv.Atoi(c)
+			if err != nil {
+				h.log.Debug("Can't convert count to int", h.log.Attr("error", err))
+
+				err = responseJSONError(w, http.StatusBadRequest, http.StatusText(http.StatusBadRequest))
+				if err != nil {
+					h.log.Error("Can't make response", h.log.Attr("error", err))
+				}
+				return
+			}
+		}
+
+		ctx, cancel := context.WithTimeout(context.Background(), h.timeout)
+		defer cancel()
+
+		runs, err := h.cmdr.NextRuns(ctx, int64(id), count)
+		if err != nil {
+			h.scheduleError(w, "Can't get next runs of schedule", err)
+			return
+		}
+
+		if err = nextRunsRespJSONOk(w, http.StatusOK, nextRunsRespBodyOK{Runs: runs}); err != nil {
+			h.log.Error("Can't make response", h.log.Attr("error", err))
+		}
+	}
+}
+
+// scheduleError writes the response with the status matching the schedule's error ...
+func (h *CustomRouter) scheduleError(w http.ResponseWriter, msg string, err error) {
+	status, text := http.StatusInternalServerError, http.StatusText(http.StatusInternalServerError)
+
+	switch {
+	case errors.Is(err, services.ErrInvalidSchedule), errors.Is(err, services.ErrInvalidOptions),
+		errors.Is(err, services.ErrInvalidParams):
+		status, text = http.StatusBadRequest, err.Error()
+	case errors.Is(err, services.ErrScheduleNotFound):
+		status, text = http.StatusNotFound, services.ErrScheduleNotFound.Error()
+	case errors.Is(err, services.ErrTemplateNotFound):
+		status, text = http.StatusNotFound, services.ErrTemplateNotFound.Error()
+	}
+
+	if status == http.StatusInternalServerError {
+		h.log.Error(msg, h.log.Attr("error", err))
+	} else {
+		h.log.Debug(msg, h.log.Attr("error", err))
+	}
+
+	if err = responseJSONError(w, status, text); err != nil {
+		h.log.Error("Can't make response", h.log.Attr("error", err))
+	}
+}
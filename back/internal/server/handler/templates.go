@@ -0,0 +1,357 @@
+package handler
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"io"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/enchik0reo/commandApi/internal/models"
+	"github.com/enchik0reo/commandApi/internal/services"
+
+	"github.com/go-chi/chi"
+)
+
+type templateRequest struct {
+	Name        string            `json:"name"`
+	Description string            `json:"description"`
+	Script      string            `json:"script"`
+	Params      []models.Param    `json:"params"`
+	Timeout     int64             `json:"timeout"` // Seconds, zero means the default timeout
+	Limits      limitsRequest     `json:"limits"`
+	Env         map[string]string `json:"env"`
+	Args        []string          `json:"args"`
+	WorkingDir  string            `json:"working_dir"`
+	Interpreter string            `json:"interpreter"`
+	Artifacts   []string          `json:"artifacts"`
+}
+
+func (req templateRequest) template(id int64) models.Template {
+	return models.Template{
+		ID:          id,
+		Name:        req.Name,
+		Description: req.Description,
+		Script:      req.Script,
+		Params:      req.Params,
+		Options: models.Options{
+			Timeout:     time.Duration(req.Timeout) * time.Second,
+			Limits:      req.Limits.limits(),
+			Env:         req.Env,
+			Args:        req.Args,
+			WorkingDir:  req.WorkingDir,
+			Interpreter: req.Interpreter,
+			Artifacts:   req.Artifacts,
+		},
+	}
+}
+
+type runTemplateRequest struct {
+	Params    map[string]any    `json:"params"`
+	SecretEnv map[string]string `json:"secret_env"` // Isn't stored with the command
+}
+
+// createTemplate godoc
+// @Summary Create new template
+// @Description Save named script with typed parameters to the library.
+// @Description Parameter types are string, int, enum and bool.
+// @Tags  templates
+// @Accept  json
+// @Produce  json
+// @Param template body templateRequest true "Template"
+// @Success 201 {object} idRespOK "Sucess"
+// @Failure 400 {object} responseErr "Bad request"
+// @Failure 409 {object} responseErr "Template with this name already exists"
+// @Failure 500 {object} responseErr "Internal server error"
+// @Router /templates [post]
+func (h *CustomRouter) createTemplate() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+
+		defer r.Body.Close()
+		req := templateRequest{}
+
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			h.log.Debug("Can't decode body from create template request", h.log.Attr("error", err))
+
+			err = responseJSONError(w, http.StatusBadRequest, http.StatusText(http.StatusBadRequest))
+			if err != nil {
+				h.log.Error("Can't make response", h.log.Attr("error", err))
+			}
+			return
+		}
+
+		ctx, cancel := context.WithTimeout(context.Background(), h.timeout)
+		defer cancel()
+
+		id, err := h.cmdr.CreateTemplate(ctx, req.template(0))
+		if err != nil {
+			h.templateError(w, "Can't create template", err)
+			return
+		}
+
+		if err = idRespJSONOk(w, http.StatusCreated, idRespBodyOK{TemplateID: id}); err != nil {
+			h.log.Error("Can't make response", h.log.Attr("error", err))
+		}
+	}
+}
+
+// templates godoc
+// @Summary Show templates
+// @Description Show all templates ordered by name
+// @Tags  templates
+// @Produce  json
+// @Success 200 {object} templatesRespOK "Sucess"
+// @Failure 500 {object} responseErr "Internal server error"
+// @Router /templates [get]
+func (h *CustomRouter) templates() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+
+		defer r.Body.Close()
+
+		ctx, cancel := context.WithTimeout(context.Background(), h.timeout)
+		defer cancel()
+
+		tmpls, err := h.cmdr.GetTemplateList(ctx)
+		if err != nil {
+			h.templateError(w, "Can't get list of templates", err)
+			return
+		}
+
+		respBody := templatesRespBodyOK{Templates: make([]templateResponse, 0, len(tmpls))}
+
+		for _, tmpl := range tmpls {
+			respBody.Templates = append(respBody.Templates, newTemplateResponse(tmpl))
+		}
+
+		if err = templatesRespJSONOk(w, http.StatusOK, respBody); err != nil {
+			h.log.Error("Can't make response", h.log.Attr("error", err))
+		}
+	}
+}
+
+// template godoc
+// @Summary Show one template
+// @Description Show template by id
+// @Tags  templates
+// @Produce  json
+// @Param id path int true  "Template id"
+// @Success 200 {object} templateRespOK "Sucess"
+// @Failure 400 {object} responseErr "Bad request"
+// @Failure 404 {object} responseErr "Template not found"
+// @Failure 500 {object} responseErr "Internal server error"
+// @Router /templates/{id} [get]
+func (h *CustomRouter) template() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+
+		defer r.Body.Close()
+
+		id, err := strconv.Atoi(chi.URLParam(r, "id"))
+		if err != nil {
+			h.log.Debug("Can't convert id to int", h.log.Attr("error", err))
+
+			err = responseJSONError(w, http.StatusBadRequest, http.StatusText(http.StatusBadRequest))
+			if err != nil {
+				h.log.Error("Can't make response", h.log.Attr("error", err))
+			}
+			return
+		}
+
+		ctx, cancel := context.WithTimeout(context.Background(), h.timeout)
+		defer cancel()
+
+		tmpl, err := h.cmdr.GetTemplate(ctx, int64(id))
+		if err != nil {
+			h.templateError(w, "Can't get template", err)
+			return
+		}
+
+		respBody := templateRespBodyOK{Template: newTemplateResponse(*tmpl)}
+
+		if err = templateRespJSONOk(w, http.StatusOK, respBody); err != nil {
+			h.log.Error("Can't make response", h.log.Attr("error", err))
+		}
+	}
+}
+
+// updateTemplate godoc
+// @Summary Update template
+// @Description Replace template by id
+// @Tags  templates
+// @Accept  json
+// @Produce  json
+// @Param id path int true  "Template id"
+// @Param template body templateRequest true "Template"
+// @Success 200 {object} idRespOK "Sucess"
+// @Failure 400 {object} responseErr "Bad request"
+// @Failure 404 {object} responseErr "Template not found"
+// @Failure 409 {object} responseErr "Template with this name already exists"
+// @Failure 500 {object} responseErr "Internal server error"
+// @Router /templates/{id} [put]
+func (h *CustomRouter) updateTemplate() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+
+		defer r.Body.Close()
+
+		id, err := strconv.Atoi(chi.URLParam(r, "id"))
+		if err != nil {
+			h.log.Debug("Can't convert id to int", h.log.Attr("error", err))
+
+			err = responseJSONError(w, http.StatusBadRequest, http.StatusText(http.StatusBadRequest))
+			if err != nil {
+				h.log.Error("Can't make response", h.log.Attr("error", err))
+			}
+			return
+		}
+
+		req := templateRequest{}
+
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			h.log.Debug("Can't decode body from update template request", h.log.Attr("error", err))
+
+			err = responseJSONError(w, http.StatusBadRequest, http.StatusText(http.StatusBadRequest))
+			if err != nil {
+				h.log.Error("Can't make response", h.log.Attr("error", err))
+			}
+			return
+		}
+
+		ctx, cancel := context.WithTimeout(context.Background(), h.timeout)
+		defer cancel()
+
+		if err = h.cmdr.UpdateTemplate(ctx, req.template(int64(id))); err != nil {
+			h.templateError(w, "Can't update template", err)
+			return
+		}
+
+		if err = idRespJSONOk(w, http.StatusOK, idRespBodyOK{TemplateID: int64(id)}); err != nil {
+			h.log.Error("Can't make response", h.log.Attr("error", err))
+		}
+	}
+}
+
+// deleteTemplate godoc
+// @Summary Delete template
+// @Description Delete template by id, commands made from it are kept
+// @Tags  templates
+// @Produce  json
+// @Param id path int true  "Template id"
+// @Success 200 {object} idRespOK "Sucess"
+// @Failure 400 {object} responseErr "Bad request"
+// @Failure 404 {object} responseErr "Template not found"
+// @Failure 500 {object} responseErr "Internal server error"
+// @Router /templates/{id} [delete]
+func (h *CustomRouter) deleteTemplate() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+
+		defer r.Body.Close()
+
+		id, err := strconv.Atoi(chi.URLParam(r, "id"))
+		if err != nil {
+			h.log.Debug("Can't convert id to int", h.log.Attr("error", err))
+
+			err = responseJSONError(w, http.StatusBadRequest, http.StatusText(http.StatusBadRequest))
+			if err != nil {
+				h.log.Error("Can't make response", h.log.Attr("error", err))
+			}
+			return
+		}
+
+		ctx, cancel := context.WithTimeout(context.Background(), h.timeout)
+		defer cancel()
+
+		if err = h.cmdr.DeleteTemplate(ctx, int64(id)); err != nil {
+			h.templateError(w, "Can't delete template", err)
+			return
+		}
+
+		if err = idRespJSONOk(w, http.StatusOK, idRespBodyOK{TemplateID: int64(id)}); err != nil {
+			h.log.Error("Can't make response", h.log.Attr("error", err))
+		}
+	}
+}
+
+// runTemplate godoc
+// @Summary Run template
+// @Description Run new command from template.
+// @Description Values of parameters are passed to the script as environment variables.
+// @Tags  templates
+// @Accept  json
+// @Produce  json
+// @Param id path int true  "Template id"
+// @Param params body runTemplateRequest false "Values of parameters"
+// @Success 201 {object} idRespOK "Sucess"
+// @Failure 400 {object} responseErr "Bad request"
+// @Failure 404 {object} responseErr "Template not found"
+// @Failure 500 {object} responseErr "Internal server error"
+// @Router /templates/{id}/run [post]
+func (h *CustomRouter) runTemplate() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+
+		defer r.Body.Close()
+
+		id, err := strconv.Atoi(chi.URLParam(r, "id"))
+		if err != nil {
+			h.log.Debug("Can't convert id to int", h.log.Attr("error", err))
+
+			err = responseJSONError(w, http.StatusBadRequest, http.StatusText(http.StatusBadRequest))
+			if err != nil {
+				h.log.Error("Can't make response", h.log.Attr("error", err))
+			}
+			return
+		}
+
+		req := runTemplateRequest{}
+
+		dec := json.NewDecoder(r.Body)
+		dec.UseNumber()
+
+		if err := dec.Decode(&req); err != nil && !errors.Is(err, io.EOF) {
+			h.log.Debug("Can't decode body from run template request", h.log.Attr("error", err))
+
+			err = responseJSONError(w, http.StatusBadRequest, http.StatusText(http.StatusBadRequest))
+			if err != nil {
+				h.log.Error("Can't make response", h.log.Attr("error", err))
+			}
+			return
+		}
+
+		ctx, cancel := context.WithTimeout(context.Background(), h.timeout)
+		defer cancel()
+
+		cmdID, err := h.cmdr.RunTemplate(ctx, int64(id), req.Params, req.SecretEnv)
+		if err != nil {
+			h.templateError(w, "Can't run template", err)
+			return
+		}
+
+		if err = idRespJSONOk(w, http.StatusCreated, idRespBodyOK{CommandID: cmdID}); err != nil {
+			h.log.Error("Can't make response", h.log.Attr("error", err))
+		}
+	}
+}
+
+// templateError writes the response with the status matching the template's error ...
+func (h *CustomRouter) templateError(w http.ResponseWriter, msg string, err error) {
+	status, text := http.StatusInternalServerError, http.StatusText(http.StatusInternalServerError)
+
+	switch {
+	case errors.Is(err, services.ErrInvalidOptions), errors.Is(err, services.ErrInvalidParams):
+		status, text = http.StatusBadRequest, err.Error()
+	case errors.Is(err, services.ErrTemplateNotFound):
+		status, text = http.StatusNotFound, services.ErrTemplateNotFound.Error()
+	case errors.Is(err, services.ErrTemplateExists):
+		status, text = http.StatusConflict, services.ErrTemplateExists.Error()
+	}
+
+	if status == http.StatusInternalServerError {
+		h.log.Error(msg, h.log.Attr("error", err))
+	} else {
+		h.log.Debug(msg, h.log.Attr("error", err))
+	}
+
+	if err = responseJSONError(w, status, text); err != nil {
+		h.log.Error("Can't make response", h.log.Attr("error", err))
+	}
+}
@@ -0,0 +1,282 @@
+package commander
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/enchik0reo/commandApi/internal/config"
+	"github.com/enchik0reo/commandApi/internal/models"
+	"github.com/enchik0reo/commandApi/internal/services"
+
+	"golang.org/x/sys/unix"
+)
+
+const maxArtifactPatterns = 16
+
+var errArtifactTooLarge = errors.New("artifact is too large")
+
+// GetArtifactList returns artifacts collected from the command's workspace ...
+func (c *Commander) GetArtifactList(ctx context.Context, id int64) ([]models.Artifact, error) {
+	const op = "commander.GetArtifactList"
+
+	artifacts, err := c.cmdStorage.GetArtifacts(ctx, id)
+	if err != nil {
+		if errors.Is(err, services.ErrCommandNotFound) {
+			return nil, services.ErrCommandNotFound
+		}
+
+		return nil, fmt.Errorf("can't get artifacts of command on id: %d: %s: %v", id, op, err)
+	}
+
+	return artifacts, nil
+}
+
+// OpenArtifact returns the command's artifact with its opened file, the caller closes the file.
+// The artifact whose file is missing in the store isn't found ...
+func (c *Commander) OpenArtifact(ctx context.Context, id, artifactID int64) (*models.Artifact, *os.File, error) {
+	const op = "commander.OpenArtifact"
+
+	artifact, err := c.cmdStorage.GetArtifact(ctx, id, artifactID)
+	if err != nil {
+		if errors.Is(err, services.ErrArtifactNotFound) {
+			return nil, nil, services.ErrArtifactNotFound
+		}
+
+		return nil, nil, fmt.Errorf("can't get artifact on id: %d: %s: %v", artifactID, op, err)
+	}
+
+	f, err := c.artifacts.open(id, artifact.Name)
+	if err != nil {
+		if errors.Is(err, os.ErrNotExist) {
+			return nil, nil, services.ErrArtifactNotFound
+		}
+
+		return nil, nil, fmt.Errorf("can't open artifact on id: %d: %s: %v", artifactID, op, err)
+	}
+
+	return artifact, f, nil
+}
+
+// collectArtifacts copies files matching artifact patterns of the finished command to the store
+// and saves them in storage, errors are logged ...
+func (c *Commander) collectArtifacts(j job) {
+	const op = "commander.collectArtifacts"
+
+	if j.script.Workspace == "" || len(j.script.Artifacts) == 0 {
+		return
+	}
+
+	artifacts, err := c.artifacts.collect(j.id, j.script.Workspace, j.script.Artifacts)
+	if err != nil {
+		c.log.Error("can't collect artifacts", c.log.Attr("op", op), c.log.Attr("command_id", j.id),
+			c.log.Attr("error", err))
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), contextDuration)
+	defer cancel()
+
+	for _, artifact := range artifacts {
+		if _, err := c.cmdStorage.CreateArtifact(ctx, artifact); err != nil {
+			c.log.Error("can't save artifact in storage", c.log.Attr("op", op), c.log.Attr("error", err))
+		}
+	}
+}
+
+// artifactStore keeps files collected from workspaces of finished commands.
+// Files of the command are kept in its own directory under their names relative to the workspace ...
+type artifactStore struct {
+	root         string
+	maxFiles     int
+	maxFileBytes int64
+}
+
+// newArtifactStore creates a new instance of artifactStore.
+// Empty root means the directory in the system temporary one, zero limit means no limit ...
+func newArtifactStore(cfg config.Artifacts) *artifactStore {
+	root := cfg.Root
+	if root == "" {
+		root = filepath.Join(os.TempDir(), "commandApi-artifacts")
+	}
+
+	return &artifactStore{root: root, maxFiles: cfg.MaxFiles, maxFileBytes: cfg.MaxFileMB << 20}
+}
+
+// path returns the path of the command's artifact in the store ...
+func (s *artifactStore) path(id int64, name string) string {
+	return filepath.Join(s.root, fmt.Sprintf("%s%d", workspacePrefix, id), filepath.FromSlash(name))
+}
+
+// collect copies files of the workspace matching the patterns to the store.
+// Only regular files inside the workspace are collected, each file is collected once.
+// Files which can't be copied are skipped, so collected artifacts are returned with the first error ...
+func (s *artifactStore) collect(id int64, workspace string, patterns []string) ([]models.Artifact, error) {
+	const op = "commander.artifactStore.collect"
+
+	root, err := filepath.EvalSymlinks(workspace)
+	if err != nil {
+		return nil, fmt.Errorf("can't resolve workspace: %s: %v", op, err)
+	}
+
+	var artifacts []models.Artifact
+	var firstErr error
+
+	seen := map[string]bool{}
+
+	fail := func(err error) {
+		if firstErr == nil {
+			firstErr = err
+		}
+	}
+
+	for _, pattern := range patterns {
+		matches, err := filepath.Glob(filepath.Join(root, pattern))
+		if err != nil {
+			fail(fmt.Errorf("can't match artifact pattern %q: %s: %v", pattern, op, err))
+			continue
+		}
+
+		for _, match := range matches {
+			in, name, ok := openArtifact(root, match)
+			if !ok {
+				continue
+			}
+
+			if seen[name] {
+				in.Close()
+				continue
+			}
+
+			seen[name] = true
+
+			if s.maxFiles > 0 && len(artifacts) >= s.maxFiles {
+				in.Close()
+				fail(fmt.Errorf("can't collect artifact %s, too many files: %s", name, op))
+				continue
+			}
+
+			artifact, err := s.save(id, name, in)
+			in.Close()
+
+			if err != nil {
+				fail(fmt.Errorf("can't collect artifact %s: %s: %v", name, op, err))
+				continue
+			}
+
+			artifacts = append(artifacts, *artifact)
+		}
+	}
+
+	return artifacts, firstErr
+}
+
+// openArtifact opens the matched file and returns it with its slash separated path relative to the workspace.
+// The file is opened without following symlinks and its path is checked by the opened handle,
+// so the script can't swap it for a link outside the workspace after it is matched.
+// It's false for anything but regular files and for files outside the workspace ...
+func openArtifact(root, path string) (*os.File, string, bool) {
+	f, err := os.OpenFile(path, os.O_RDONLY|unix.O_NOFOLLOW|unix.O_NONBLOCK, 0)
+	if err != nil {
+		return nil, "", false
+	}
+
+	info, err := f.Stat()
+	if err != nil || !info.Mode().IsRegular() {
+		f.Close()
+		return nil, "", false
+	}
+
+	real, err := os.Readlink(fmt.Sprintf("/proc/self/fd/%d", f.Fd()))
+	if err != nil {
+		f.Close()
+		return nil, "", false
+	}
+
+	rel, err := filepath.Rel(root, real)
+	if err != nil || rel == ".." || strings.HasPrefix(rel, ".."+string(filepath.Separator)) {
+		f.Close()
+		return nil, "", false
+	}
+
+	return f, filepath.ToSlash(rel), true
+}
+
+// save copies the opened file to the store and counts its size and checksum.
+// The file over the size limit isn't kept ...
+func (s *artifactStore) save(id int64, name string, in *os.File) (*models.Artifact, error) {
+	dst := s.path(id, name)
+
+	if err := os.MkdirAll(filepath.Dir(dst), 0o700); err != nil {
+		return nil, err
+	}
+
+	out, err := os.CreateTemp(filepath.Dir(dst), ".artifact-")
+	if err != nil {
+		return nil, err
+	}
+	defer os.Remove(out.Name())
+
+	var r io.Reader = in
+	if s.maxFileBytes > 0 {
+		r = io.LimitReader(in, s.maxFileBytes+1)
+	}
+
+	hash := sha256.New()
+
+	size, err := io.Copy(io.MultiWriter(out, hash), r)
+	if closeErr := out.Close(); err == nil {
+		err = closeErr
+	}
+
+	if err != nil {
+		return nil, err
+	}
+
+	if s.maxFileBytes > 0 && size > s.maxFileBytes {
+		return nil, errArtifactTooLarge
+	}
+
+	if err := os.Rename(out.Name(), dst); err != nil {
+		return nil, err
+	}
+
+	return &models.Artifact{CommandID: id, Name: name, Size: size, SHA256: hex.EncodeToString(hash.Sum(nil))}, nil
+}
+
+// open opens the command's artifact in the store ...
+func (s *artifactStore) open(id int64, name string) (*os.File, error) {
+	return os.Open(s.path(id, name))
+}
+
+// checkArtifacts checks patterns of artifacts, they must be relative to the workspace ...
+func checkArtifacts(patterns []string) error {
+	if len(patterns) > maxArtifactPatterns {
+		return fmt.Errorf("%w: artifacts can't have more than %d patterns", services.ErrInvalidOptions, maxArtifactPatterns)
+	}
+
+	for _, pattern := range patterns {
+		if pattern == "" {
+			return fmt.Errorf("%w: artifact pattern can't be empty", services.ErrInvalidOptions)
+		}
+
+		if filepath.IsAbs(pattern) {
+			return fmt.Errorf("%w: artifact pattern %q must be relative to the workspace", services.ErrInvalidOptions, pattern)
+		}
+
+		if clean := filepath.Clean(pattern); clean == ".." || strings.HasPrefix(clean, ".."+string(filepath.Separator)) {
+			return fmt.Errorf("%w: artifact pattern %q is outside the workspace", services.ErrInvalidOptions, pattern)
+		}
+
+		if _, err := filepath.Match(pattern, ""); err != nil {
+			return fmt.Errorf("%w: invalid artifact pattern %q", services.ErrInvalidOptions, pattern)
+		}
+	}
+
+	return nil
+}
@@ -0,0 +1,81 @@
+package commander
+
+import (
+	"sync"
+
+	"github.com/enchik0reo/commandApi/internal/models"
+)
+
+const subscriberBuffer = 256
+
+// broker delivers events of running commands to every subscriber ...
+type broker struct {
+	mu   sync.Mutex
+	subs map[int64]map[chan models.Event]struct{}
+}
+
+// newBroker creates a new instance of broker ...
+func newBroker() *broker {
+	return &broker{subs: make(map[int64]map[chan models.Event]struct{})}
+}
+
+// subscribe returns channel with events of the command and function to unsubscribe.
+// The channel is closed when the command ends or the subscriber can't keep up ...
+func (b *broker) subscribe(id int64) (<-chan models.Event, func()) {
+	ch := make(chan models.Event, subscriberBuffer)
+
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	if b.subs[id] == nil {
+		b.subs[id] = make(map[chan models.Event]struct{})
+	}
+
+	b.subs[id][ch] = struct{}{}
+
+	return ch, func() {
+		b.mu.Lock()
+		defer b.mu.Unlock()
+
+		b.remove(id, ch)
+	}
+}
+
+// publish sends the event to all subscribers of the command without blocking.
+// Slow subscribers are dropped ...
+func (b *broker) publish(id int64, ev models.Event) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	for ch := range b.subs[id] {
+		select {
+		case ch <- ev:
+		default:
+			b.remove(id, ch)
+		}
+	}
+}
+
+// closeAll closes all subscriptions of the command ...
+func (b *broker) closeAll(id int64) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	for ch := range b.subs[id] {
+		b.remove(id, ch)
+	}
+}
+
+// remove closes subscriber's channel. Caller must hold the lock ...
+func (b *broker) remove(id int64, ch chan models.Event) {
+	if _, ok := b.subs[id][ch]; !ok {
+		return
+	}
+
+	delete(b.subs[id], ch)
+	close(ch)
+
+	if len(b.subs[id]) == 0 {
+		delete(b.subs, id)
+	}
+}
@@ -2,123 +2,536 @@ package commander
 
 import (
 	"context"
+	"crypto/sha256"
+	"encoding/hex"
 	"errors"
 	"fmt"
+	"os"
+	"path"
+	"path/filepath"
+	"regexp"
+	"slices"
 	"strings"
 	"sync"
+	"syscall"
 	"time"
 
+	"github.com/enchik0reo/commandApi/internal/config"
 	"github.com/enchik0reo/commandApi/internal/logs"
 	"github.com/enchik0reo/commandApi/internal/models"
 	"github.com/enchik0reo/commandApi/internal/services"
+
+	"golang.org/x/sys/unix"
 )
 
 //go:generate mockgen -destination=mocks/commander.go -package=mocks -source=commander.go
 
 //go:generate go run github.com/vektra/mockery/v2@v2.42.2 --name=Storager
 type Storager interface {
-	CreateNew(context.Context, string) (int64, error)
-	GetList(context.Context, int64) ([]models.Command, error)
+	CreateNew(context.Context, models.Command) (int64, error)
+	GetList(context.Context, models.CommandFilter) ([]models.Command, error)
 	GetOne(context.Context, int64) (*models.Command, error)
+	GetQueued(context.Context) ([]models.Command, error)
+	StartOne(context.Context, int64) (int64, error)
 	StopOne(context.Context, int64) (int64, error)
-	SaveOutput(context.Context, int64, string) (int64, error)
+	FinishOne(context.Context, int64, models.Result) (int64, error)
+	IndexOutput(context.Context, int64) error
+	SaveOutputs(context.Context, int64, []models.Output) error
+	TruncateOne(context.Context, int64) (int64, error)
+	GetOutput(context.Context, int64, models.OutputRange) ([]models.Output, int64, error)
+	EachOutput(context.Context, int64, func(models.Output) error) error
+	CreateArtifact(context.Context, models.Artifact) (int64, error)
+	GetArtifacts(context.Context, int64) ([]models.Artifact, error)
+	GetArtifact(context.Context, int64, int64) (*models.Artifact, error)
+	CreateTemplate(context.Context, models.Template) (int64, error)
+	GetTemplates(context.Context) ([]models.Template, error)
+	GetTemplate(context.Context, int64) (*models.Template, error)
+	UpdateTemplate(context.Context, models.Template) (int64, error)
+	DeleteTemplate(context.Context, int64) (int64, error)
+	CreateSchedule(context.Context, models.Schedule) (int64, error)
+	GetSchedules(context.Context) ([]models.Schedule, error)
+	GetSchedule(context.Context, int64) (*models.Schedule, error)
+	UpdateSchedule(context.Context, models.Schedule) (int64, error)
+	DeleteSchedule(context.Context, int64) (int64, error)
+	SetLastCommand(context.Context, int64, int64) (int64, error)
 }
 
 //go:generate go run github.com/vektra/mockery/v2@v2.42.2 --name=Executor
 type Executor interface {
-	RunScript(string, string, <-chan struct{}) (<-chan string, <-chan error)
+	RunScript(models.Script, <-chan syscall.Signal, <-chan []byte) (<-chan models.Output, <-chan error)
+	HasCgroup() bool
 }
 
 const (
-	contextDuration = 3 * time.Second
-	maxScriptLenght = 27
+	defaultOutputLimit = 1000
+	maxOutputLimit     = 10000
+)
+
+const (
+	defaultBatchSize     = 500
+	defaultFlushInterval = 200 * time.Millisecond
 )
 
+const (
+	contextDuration   = 3 * time.Second
+	indexTimeout      = time.Minute
+	killTimeout       = 10 * time.Second
+	maxScriptLenght   = 27
+	scriptPlaceholder = "{script}"
+)
+
+// defaultInterpreters are available when there are no configured interpreters ...
+var defaultInterpreters = map[string][]string{
+	"bash": {"/bin/bash", "-c", scriptPlaceholder, "bash"},
+	"sh":   {"/bin/sh", "-c", scriptPlaceholder, "sh"},
+}
+
+var statuses = []string{models.StatusQueued, models.StatusRunning, models.StatusSucceeded, models.StatusFailed,
+	models.StatusStopped, models.StatusTimedOut, models.StatusLimitExceeded}
+
+// stopSignals are signals which can stop the command ...
+var stopSignals = []syscall.Signal{syscall.SIGTERM, syscall.SIGINT, syscall.SIGHUP, syscall.SIGQUIT, syscall.SIGKILL}
+
+var envName = regexp.MustCompile(`^[A-Za-z_][A-Za-z0-9_]*$`)
+
 type Commander struct {
 	cmdStorage Storager
 	exec       Executor
+	cfg        *config.Commander
 
-	log       *logs.CustomLog
-	stopChans *sync.Map
+	log        *logs.CustomLog
+	stopChans  *sync.Map
+	finished   sync.Map // Channels closed when the executor of the command ends
+	inputs     *sync.Map
+	events     *broker
+	queue      *jobQueue
+	workspaces *workspaces
+	artifacts  *artifactStore
+	schedules  *scheduler
+	running    sync.WaitGroup
 }
 
-// NewCommander creates a new instance of Commander ...
-func NewCommander(l *logs.CustomLog, s Storager, e Executor) *Commander {
+// NewCommander creates a new instance of Commander.
+// Schedules use UTC if the configured timezone is unknown,
+// new lines over the output limits are dropped if the configured policy is unknown ...
+func NewCommander(l *logs.CustomLog, s Storager, e Executor, cfg *config.Commander) *Commander {
+	loc, err := time.LoadLocation(cfg.ScheduleTimezone)
+	if err != nil {
+		l.Error("Unknown timezone of schedules, UTC is used", l.Attr("error", err))
+		loc = time.UTC
+	}
+
+	switch cfg.OutputLimits.Policy {
+	case policyStop, policyDrop, policyHeadTail:
+	default:
+		l.Error("Unknown policy of output limits, new lines are dropped", l.Attr("policy", cfg.OutputLimits.Policy))
+	}
+
 	c := &Commander{
 		log:        l,
 		cmdStorage: s,
 		exec:       e,
+		cfg:        cfg,
 		stopChans:  &sync.Map{},
+		inputs:     &sync.Map{},
+		events:     newBroker(),
+		queue:      newJobQueue(cfg.MaxParallel),
+		workspaces: newWorkspaces(l, cfg.Workspace),
+		artifacts:  newArtifactStore(cfg.Artifacts),
+		schedules:  newScheduler(loc),
 	}
 
 	return c
 }
 
-// CreateNewCommand starts new script.
-// It creates new record in storage and runs the script in new gorutine ...
-func (c *Commander) CreateNewCommand(ctx context.Context, script string) (int64, error) {
+// CreateNewCommand adds new script to the queue.
+// It creates new record in storage, the script runs in new gorutine when there is a free slot.
+// The interpreter of the script's shebang line is used if no interpreter is set and it's known.
+// The script is killed when its timeout elapses ...
+func (c *Commander) CreateNewCommand(ctx context.Context, script models.Script) (int64, error) {
 	const op = "commander.CreateNewCommand"
 
-	sName := scriptName(script)
+	if _, ok := c.interpreters()[script.ShebangInterpreter]; ok && script.Interpreter == "" {
+		script.Interpreter = script.ShebangInterpreter
+	}
 
-	id, err := c.cmdStorage.CreateNew(ctx, sName)
+	opts, err := c.scriptOptions(script.Options)
 	if err != nil {
-		return -1, fmt.Errorf("can't create new command in storage: %s: %v", op, err)
+		return -1, err
 	}
 
-	stopCh := make(chan struct{})
+	if err := c.checkEnv(script.SecretEnv); err != nil {
+		return -1, err
+	}
 
-	c.stopChans.Store(id, stopCh)
+	script.Name = scriptName(script.Body)
+	script.Options = opts
 
-	resCh, errCh := c.exec.RunScript(script, sName, stopCh)
+	id, err := c.cmdStorage.CreateNew(ctx, models.Command{
+		Name:        script.Name,
+		Script:      script.Body,
+		ScriptHash:  scriptHash(script.Body),
+		Interpreter: script.Interpreter,
+		TemplateID:  script.TemplateID,
+		ParentID:    script.ParentID,
+		Status:      models.StatusQueued,
+		Options:     &script.Options,
+	})
+	if err != nil {
+		return -1, fmt.Errorf("can't create new command in storage: %s: %v", op, err)
+	}
 
-	go c.saveOutput(id, resCh, errCh, stopCh)
+	c.enqueue(id, script)
 
 	return id, nil
 }
 
-// GetCommandList returns the list of command with limit from storage ...
-func (c *Commander) GetCommandList(ctx context.Context, limit int64) ([]models.Command, error) {
+// RerunCommand creates new command from the stored script and options of the command.
+// Secret environment variables aren't stored, so they must be passed again ...
+func (c *Commander) RerunCommand(ctx context.Context, id int64, secretEnv map[string]string) (int64, error) {
+	const op = "commander.RerunCommand"
+
+	cmd, err := c.cmdStorage.GetOne(ctx, id)
+	if err != nil {
+		if errors.Is(err, services.ErrCommandNotFound) {
+			return -1, services.ErrCommandNotFound
+		}
+
+		return -1, fmt.Errorf("can't get command on id: %d: %s: %v", id, op, err)
+	}
+
+	if cmd.Script == "" {
+		return -1, fmt.Errorf("%w: command %d has no stored script", services.ErrInvalidOptions, id)
+	}
+
+	script := models.Script{Body: cmd.Script, SecretEnv: secretEnv, TemplateID: cmd.TemplateID, ParentID: id}
+
+	if cmd.Options != nil {
+		script.Options = *cmd.Options
+	}
+
+	return c.CreateNewCommand(ctx, script)
+}
+
+// RestoreQueue puts the commands which were left queued in storage back to the queue ...
+func (c *Commander) RestoreQueue(ctx context.Context) error {
+	const op = "commander.RestoreQueue"
+
+	cmds, err := c.cmdStorage.GetQueued(ctx)
+	if err != nil {
+		return fmt.Errorf("can't get queued commands: %s: %v", op, err)
+	}
+
+	for _, cmd := range cmds {
+		script := models.Script{Name: cmd.Name, Body: cmd.Script}
+
+		if cmd.Options != nil {
+			script.Options = *cmd.Options
+		}
+
+		c.enqueue(cmd.ID, script)
+	}
+
+	return nil
+}
+
+// PruneWorkspaces removes workspaces which were left after the previous run ...
+func (c *Commander) PruneWorkspaces() error {
+	return c.workspaces.prune()
+}
+
+// GetCommandList returns the page of commands matching the filter from storage.
+// The cursor of the next page is zero when there are no more commands ...
+func (c *Commander) GetCommandList(ctx context.Context, f models.CommandFilter) ([]models.Command, int64, error) {
 	const op = "commander.GetCommandList"
-	cmds, err := c.cmdStorage.GetList(ctx, limit)
+
+	if err := checkFilter(f); err != nil {
+		return nil, 0, err
+	}
+
+	cmds, err := c.cmdStorage.GetList(ctx, f)
 	if err != nil {
-		return nil, fmt.Errorf("can't get list if command: %s: %v", op, err)
+		return nil, 0, fmt.Errorf("can't get list if command: %s: %v", op, err)
+	}
+
+	var next int64
+
+	if f.Limit > 0 && int64(len(cmds)) == f.Limit {
+		next = cmds[len(cmds)-1].ID
 	}
 
-	return cmds, nil
+	return cmds, next, nil
 }
 
-// GetOneCommandDescription returns the command's info from storage ...
+// GetOneCommandDescription returns the command's info from storage with the last lines of its output.
+// The whole output is read by ranges with GetCommandOutput ...
 func (c *Commander) GetOneCommandDescription(ctx context.Context, id int64) (*models.Command, error) {
 	const op = "commander.GetCommandDescription"
 	cmd, err := c.cmdStorage.GetOne(ctx, id)
 	if err != nil {
+		if errors.Is(err, services.ErrCommandNotFound) {
+			return nil, services.ErrCommandNotFound
+		}
+
 		return nil, fmt.Errorf("can't get command description on id: %d: %s: %v", id, op, err)
 	}
 
+	if cmd.Output, _, err = c.cmdStorage.GetOutput(ctx, id, models.OutputRange{Tail: defaultOutputLimit}); err != nil {
+		return nil, fmt.Errorf("can't get output of command on id: %d: %s: %v", id, op, err)
+	}
+
 	return cmd, nil
 }
 
-// StopCommand stops the command by id.
-// It updates record in storage ...
-func (c *Commander) StopCommand(ctx context.Context, id int64) (int64, error) {
+// GetCommandOutput returns the range of the command's output and the number of its last line.
+// Zero limit means the default one ...
+func (c *Commander) GetCommandOutput(ctx context.Context, id int64, r models.OutputRange) ([]models.Output, int64, error) {
+	const op = "commander.GetCommandOutput"
+
+	switch {
+	case r.Since < 0, r.Offset < 0, r.Limit < 0, r.Tail < 0:
+		return nil, 0, fmt.Errorf("%w: output range can't be negative", services.ErrInvalidOptions)
+	case r.Tail > 0 && r.Offset > 0:
+		return nil, 0, fmt.Errorf("%w: tail can't be used with offset", services.ErrInvalidOptions)
+	case r.Limit > maxOutputLimit, r.Tail > maxOutputLimit:
+		return nil, 0, fmt.Errorf("%w: output range can't be more than %d lines", services.ErrInvalidOptions, maxOutputLimit)
+	case r.Limit == 0:
+		r.Limit = defaultOutputLimit
+	}
+
+	lines, last, err := c.cmdStorage.GetOutput(ctx, id, r)
+	if err != nil {
+		if errors.Is(err, services.ErrCommandNotFound) {
+			return nil, 0, services.ErrCommandNotFound
+		}
+
+		return nil, 0, fmt.Errorf("can't get output of command on id: %d: %s: %v", id, op, err)
+	}
+
+	return lines, last, nil
+}
+
+// EachCommandOutput calls the function for every line of the command's output in order,
+// an error of the function stops reading ...
+func (c *Commander) EachCommandOutput(ctx context.Context, id int64, each func(models.Output) error) error {
+	const op = "commander.EachCommandOutput"
+
+	if err := c.cmdStorage.EachOutput(ctx, id, each); err != nil {
+		if errors.Is(err, services.ErrCommandNotFound) {
+			return services.ErrCommandNotFound
+		}
+
+		return fmt.Errorf("can't read output of command on id: %d: %s: %v", id, op, err)
+	}
+
+	return nil
+}
+
+// StreamCommand returns channel with the command's output events.
+// It replays stored output after the line number after first by pages and then follows the running command.
+// If the broker drops the stream which can't keep up, the missed output is replayed from storage again.
+// The channel is closed after the status event or when ctx is done ...
+func (c *Commander) StreamCommand(ctx context.Context, id, after int64) (<-chan models.Event, error) {
+	const op = "commander.StreamCommand"
+
+	live, unsubscribe := c.events.subscribe(id)
+
+	_, running := c.stopChans.Load(id)
+
+	storageCtx, cancel := context.WithTimeout(ctx, contextDuration)
+	defer cancel()
+
+	cmd, err := c.cmdStorage.GetOne(storageCtx, id)
+	if err != nil {
+		unsubscribe()
+
+		if errors.Is(err, services.ErrCommandNotFound) {
+			return nil, services.ErrCommandNotFound
+		}
+
+		return nil, fmt.Errorf("can't get command on id: %d: %s: %v", id, op, err)
+	}
+
+	out := make(chan models.Event)
+
+	go func() {
+		defer func() {
+			unsubscribe()
+			close(out)
+		}()
+
+		send := func(ev models.Event) bool {
+			select {
+			case out <- ev:
+				return true
+			case <-ctx.Done():
+				return false
+			}
+		}
+
+		lastID := after
+
+		for {
+			for {
+				pageCtx, cancel := context.WithTimeout(ctx, contextDuration)
+				page, last, err := c.cmdStorage.GetOutput(pageCtx, id, models.OutputRange{Since: lastID + 1, Limit: maxOutputLimit})
+				cancel()
+
+				if err != nil {
+					c.log.Error("can't replay output", c.log.Attr("op", op), c.log.Attr("command_id", id),
+						c.log.Attr("error", err))
+					return
+				}
+
+				for i := range page {
+					if !send(models.Event{ID: page[i].Line, Type: models.EventOutput, Output: &page[i]}) {
+						return
+					}
+
+					lastID = page[i].Line
+				}
+
+				if len(page) == 0 || lastID >= last {
+					break
+				}
+			}
+
+			if !running {
+				send(models.Event{Type: models.EventStatus, Result: &models.Result{
+					Status:   cmd.Status,
+					ExitCode: cmd.ExitCode,
+					Signal:   cmd.Signal,
+				}})
+				return
+			}
+
+			if !c.follow(ctx, live, &lastID, send) {
+				return
+			}
+
+			// The subscriber was dropped by the broker, the rest is replayed from storage after subscribing again.
+			live, unsubscribe = c.events.subscribe(id)
+
+			_, running = c.stopChans.Load(id)
+
+			storageCtx, cancel := context.WithTimeout(ctx, contextDuration)
+			cmd, err = c.cmdStorage.GetOne(storageCtx, id)
+			cancel()
+
+			if err != nil {
+				c.log.Error("can't get command", c.log.Attr("op", op), c.log.Attr("command_id", id),
+					c.log.Attr("error", err))
+				return
+			}
+		}
+	}()
+
+	return out, nil
+}
+
+// follow sends live events of the running command after the line number last.
+// It returns true if the subscriber was dropped before the status event,
+// false if the status event is sent or ctx is done ...
+func (c *Commander) follow(ctx context.Context, live <-chan models.Event, last *int64, send func(models.Event) bool) bool {
+	for {
+		select {
+		case ev, open := <-live:
+			if !open {
+				return true
+			}
+
+			if ev.Type == models.EventOutput && ev.ID <= *last {
+				continue
+			}
+
+			if !send(ev) || ev.Type == models.EventStatus {
+				return false
+			}
+
+			if ev.Type == models.EventOutput {
+				*last = ev.ID
+			}
+		case <-ctx.Done():
+			return false
+		}
+	}
+}
+
+// WriteInput sends data to stdin of the running interactive command ...
+func (c *Commander) WriteInput(ctx context.Context, id int64, data []byte) error {
+	in, err := c.input(id)
+	if err != nil {
+		return err
+	}
+
+	return in.write(ctx, data)
+}
+
+// CloseInput sends EOF to stdin of the running interactive command ...
+func (c *Commander) CloseInput(id int64) error {
+	in, err := c.input(id)
+	if err != nil {
+		return err
+	}
+
+	in.close()
+
+	return nil
+}
+
+// input returns stdin of the running interactive command ...
+func (c *Commander) input(id int64) (*input, error) {
+	if _, ok := c.stopChans.Load(id); !ok {
+		return nil, services.ErrNoExecutingCommand
+	}
+
+	val, ok := c.inputs.Load(id)
+	if !ok {
+		return nil, services.ErrNotInteractive
+	}
+
+	in, ok := val.(*input)
+	if !ok {
+		return nil, errors.New("can't convert value to *input")
+	}
+
+	return in, nil
+}
+
+// StopCommand sends the signal to the running command, SIGTERM is used by default.
+// The command is killed if it is still running after the grace period.
+// Queued command is removed from the queue ...
+func (c *Commander) StopCommand(ctx context.Context, id int64, signal string) (int64, error) {
 	const op = "commander.StopCommand"
 	var res int64
 	var err error
 
+	sig, err := stopSignal(signal)
+	if err != nil {
+		return 0, err
+	}
+
+	if c.queue.remove(id) {
+		return c.cancelQueued(ctx, id)
+	}
+
 	val, ok := c.stopChans.Load(id)
 	if !ok {
 		return 0, services.ErrNoExecutingCommand
 	}
 
-	ch, ok := val.(chan struct{})
+	ch, ok := val.(chan syscall.Signal)
 	if !ok {
-		return 0, errors.New("can't convert value to cahn struct{}")
+		return 0, errors.New("can't convert value to chan syscall.Signal")
 	}
 
-	ch <- struct{}{}
+	if err = sendSignal(ctx, ch, c.done(id), sig); err != nil {
+		if errors.Is(err, services.ErrNoExecutingCommand) {
+			return 0, services.ErrNoExecutingCommand
+		}
 
-	c.stopChans.Delete(id)
+		return 0, fmt.Errorf("can't send signal: %s: %v", op, err)
+	}
 
 	if res, err = c.cmdStorage.StopOne(ctx, id); err != nil {
 		c.log.Error("can't save output in storage", c.log.Attr("op", op), c.log.Attr("error", err))
@@ -127,101 +540,757 @@ func (c *Commander) StopCommand(ctx context.Context, id int64) (int64, error) {
 	return res, nil
 }
 
-// StopAllRunningScripts stops all running commands ...
+// StopAllRunningScripts stops all running commands gracefully.
+// Commands which are still running when the context is done are killed,
+// then it waits until their output and results are saved, but no longer than kill timeout.
+// Queued commands stay queued in storage ...
 func (c *Commander) StopAllRunningScripts(ctx context.Context) error {
 	const op = "commander.StopAllRunningScripts"
 	var resErr error
 
+	c.queue.close()
+
 	c.stopChans.Range(func(key, value any) bool {
 		id, ok := key.(int64)
 		if !ok {
-			resErr = errors.Join(errors.New("can't convert key to int64"))
+			resErr = errors.Join(resErr, errors.New("can't convert key to int64"))
 			return false
 		}
 
-		ch, ok := value.(chan struct{})
+		if c.queue.has(id) {
+			return true
+		}
+
+		ch, ok := value.(chan syscall.Signal)
 		if !ok {
-			resErr = errors.Join(errors.New("can't convert value to chan struct{}"))
+			resErr = errors.Join(resErr, errors.New("can't convert value to chan syscall.Signal"))
 			return false
 		}
 
-		ch <- struct{}{}
+		if err := sendSignal(ctx, ch, c.done(id), syscall.SIGTERM); err != nil {
+			if errors.Is(err, services.ErrNoExecutingCommand) {
+				return true
+			}
 
-		c.stopChans.Delete(id)
+			c.log.Error("can't send signal", c.log.Attr("op", op), c.log.Attr("error", err))
+			resErr = errors.Join(resErr, err)
+			return true
+		}
 
 		if _, err := c.cmdStorage.StopOne(ctx, id); err != nil {
 			c.log.Error("can't save output in storage", c.log.Attr("op", op), c.log.Attr("error", err))
-			resErr = errors.Join(err)
+			resErr = errors.Join(resErr, err)
+		}
+
+		return true
+	})
+
+	done := make(chan struct{})
+
+	go func() {
+		c.running.Wait()
+		close(done)
+	}()
+
+	select {
+	case <-done:
+		return resErr
+	case <-ctx.Done():
+	}
+
+	killCtx, cancel := context.WithTimeout(context.Background(), killTimeout)
+	defer cancel()
+
+	c.stopChans.Range(func(key, value any) bool {
+		id, _ := key.(int64)
+
+		if ch, ok := value.(chan syscall.Signal); ok && !c.queue.has(id) {
+			err := sendSignal(killCtx, ch, c.done(id), syscall.SIGKILL)
+			if err != nil && !errors.Is(err, services.ErrNoExecutingCommand) {
+				c.log.Error("can't kill command", c.log.Attr("op", op), c.log.Attr("command_id", id),
+					c.log.Attr("error", err))
+			}
 		}
 
 		return true
 	})
 
+	select {
+	case <-done:
+	case <-killCtx.Done():
+		resErr = errors.Join(resErr, fmt.Errorf("killed commands haven't finished: %s: %v", op, killCtx.Err()))
+	}
+
 	return resErr
 }
 
+// enqueue adds the script to the queue and starts queued scripts if there are free slots ...
+func (c *Commander) enqueue(id int64, script models.Script) {
+	stopCh := make(chan syscall.Signal)
+
+	c.stopChans.Store(id, stopCh)
+	c.finished.Store(id, make(chan struct{}))
+
+	c.queue.push(job{id: id, script: script, stop: stopCh})
+
+	c.dispatch()
+}
+
+// dispatch starts queued scripts while there are free slots ...
+func (c *Commander) dispatch() {
+	for {
+		j, ok := c.queue.next()
+		if !ok {
+			return
+		}
+
+		c.start(j)
+	}
+}
+
+// start runs the script of the job in new gorutine.
+// The script runs in its workspace unless the working directory is set ...
+func (c *Commander) start(j job) {
+	const op = "commander.start"
+
+	ctx, cancel := context.WithTimeout(context.Background(), contextDuration)
+	defer cancel()
+
+	if _, err := c.cmdStorage.StartOne(ctx, j.id); err != nil {
+		c.log.Error("can't start command in storage", c.log.Attr("op", op), c.log.Attr("error", err))
+	}
+
+	c.running.Add(1)
+
+	invocation, err := c.invocation(j.script)
+	if err != nil {
+		c.fail(j, err)
+		return
+	}
+
+	workspace, err := c.workspaces.create(j.id)
+	if err != nil {
+		c.fail(j, err)
+		return
+	}
+
+	j.script.Invocation = invocation
+	j.script.UnlimitedAddressSpace = slices.Contains(c.cfg.UnlimitedAddressSpace, j.script.Interpreter)
+	j.script.Workspace = workspace
+
+	var stdin chan []byte
+
+	if j.script.Interactive {
+		in := newInput()
+		stdin = in.ch
+
+		c.inputs.Store(j.id, in)
+	}
+
+	resCh, errCh := c.exec.RunScript(j.script, j.stop, stdin)
+
+	go c.saveOutput(j, resCh, errCh)
+}
+
+// fail finishes the job which can't be started with the error ...
+func (c *Commander) fail(j job, err error) {
+	resCh, errCh := make(chan models.Output), make(chan error, 1)
+	errCh <- err
+
+	close(resCh)
+	close(errCh)
+
+	go c.saveOutput(j, resCh, errCh)
+}
+
+// cancelQueued stops the command which was removed from the queue ...
+func (c *Commander) cancelQueued(ctx context.Context, id int64) (int64, error) {
+	const op = "commander.cancelQueued"
+
+	c.stopChans.Delete(id)
+	c.finish(id)
+
+	res, err := c.cmdStorage.StopOne(ctx, id)
+	if err != nil {
+		c.log.Error("can't save output in storage", c.log.Attr("op", op), c.log.Attr("error", err))
+	}
+
+	c.events.publish(id, models.Event{Type: models.EventStatus, Result: &models.Result{Status: models.StatusStopped}})
+	c.events.closeAll(id)
+
+	return res, nil
+}
+
 // saveOutput waits output information form running script
-// and creates new record in storage for every output event ...
-func (c *Commander) saveOutput(id int64, resCh <-chan string, errCh <-chan error, stopCh chan struct{}) {
+// and saves output lines in batches, lines are numbered from 1 and stamped with the time they are captured.
+// A batch is flushed when it's full or the flush interval elapses, the rest is flushed before the result is saved.
+// Lines over the output limits aren't saved, the tail kept by head and tail policy is saved at the end.
+// It stops the script when timeout elapses or the output exceeds limits with stop policy,
+// the signal is kept until the executor takes it or the script ends.
+// When the script ends it collects artifacts, saves the result of execution and releases the workspace ...
+func (c *Commander) saveOutput(j job, resCh <-chan models.Output, errCh <-chan error) {
 	const op = "commander.saveOutput"
 
+	id, stopCh, timeout := j.id, j.stop, j.script.Timeout
+
+	exitCode := 0
+	result := models.Result{Status: models.StatusSucceeded, ExitCode: &exitCode}
+
+	size, interval := c.cfg.OutputBatch.Size, c.cfg.OutputBatch.Interval
+	if size <= 0 {
+		size = defaultBatchSize
+	}
+
+	if interval <= 0 {
+		interval = defaultFlushInterval
+	}
+
+	flushTicker := time.NewTicker(interval)
+	defer flushTicker.Stop()
+
+	limiter := newOutputLimiter(c.cfg.OutputLimits)
+	outputExceeded := false
+
+	// signalCh is the stop channel while the signal is waiting to be taken by the executor,
+	// sent is called when it's taken.
+	var signalCh chan<- syscall.Signal
+	var sent func()
+
+	signal := func(then func()) {
+		if signalCh == nil {
+			signalCh, sent = stopCh, then
+		}
+	}
+
+	var line int64
+	var batch []models.Output
+
+	flush := func() {
+		if len(batch) > 0 {
+			c.saveLines(id, batch)
+			batch = nil
+		}
+	}
+
+	stamp := func(output models.Output) models.Output {
+		at := time.Now()
+
+		line++
+		output.Line, output.At = line, &at
+
+		return output
+	}
+
+	keep := func(output models.Output) {
+		batch = append(batch, output)
+		if len(batch) >= size {
+			flush()
+		}
+	}
+
+	keepTail := func() {
+		for _, output := range limiter.rest() {
+			keep(output)
+		}
+	}
+
+	save := func(output models.Output) {
+		output = stamp(output)
+
+		truncated := limiter.truncated
+
+		if limiter.admit(output) {
+			keep(output)
+			return
+		}
+
+		if truncated {
+			return
+		}
+
+		c.truncate(id)
+
+		if limiter.policy == policyStop {
+			signal(func() {
+				outputExceeded = true
+
+				c.log.Debug("script exceeded output limits", c.log.Attr("op", op), c.log.Attr("command_id", id))
+			})
+		}
+	}
+
+	note := func(output models.Output) {
+		keepTail()
+		keep(stamp(output))
+	}
+
+	var timeoutCh <-chan time.Time
+	timedOut := false
+
+	if timeout > 0 {
+		timer := time.NewTimer(timeout)
+		defer timer.Stop()
+
+		timeoutCh = timer.C
+	}
+
 	defer func() {
+		c.finish(id)
+
+		keepTail()
+		flush()
+
+		c.collectArtifacts(j)
+
 		ctx, cancel := context.WithTimeout(context.Background(), contextDuration)
 
-		if _, err := c.cmdStorage.StopOne(ctx, id); err != nil {
-			c.log.Error("can't save output in storage", c.log.Attr("op", op), c.log.Attr("error", err))
+		if _, err := c.cmdStorage.FinishOne(ctx, id, result); err != nil {
+			c.log.Error("can't save result in storage", c.log.Attr("op", op), c.log.Attr("error", err))
 		}
 
 		cancel()
 
+		if j.script.Workspace != "" {
+			c.workspaces.release(j.script.Workspace)
+		}
+
 		c.stopChans.Delete(id)
 
-		close(stopCh)
+		if val, ok := c.inputs.LoadAndDelete(id); ok {
+			if in, ok := val.(*input); ok {
+				in.close()
+			}
+		}
+
+		c.events.publish(id, models.Event{Type: models.EventStatus, Result: &result})
+		c.events.closeAll(id)
+
+		// The output is indexed after the result is saved, so a slow index never leaves the command running.
+		c.running.Add(1)
+		go c.indexOutput(id)
+
+		c.running.Done()
+
+		c.queue.done()
+		c.dispatch()
 	}()
 
-	for {
+	for resCh != nil || errCh != nil {
 		select {
 		case res, open := <-resCh:
-			if open {
-				ctx, cancel := context.WithTimeout(context.Background(), contextDuration)
+			if !open {
+				resCh = nil
+				continue
+			}
 
-				if _, err := c.cmdStorage.SaveOutput(ctx, id, res); err != nil {
-					c.log.Error("can't save output in storage", c.log.Attr("op", op), c.log.Attr("error", err))
-				}
+			save(res)
+		case err, open := <-errCh:
+			if !open {
+				errCh = nil
+				continue
+			}
 
-				cancel()
+			result = executionResult(err)
+
+			if timedOut && errors.Is(err, services.ErrStoppedManually) {
+				result.Status = models.StatusTimedOut
+
+				note(models.Output{
+					Stream: models.StreamStderr,
+					Text:   fmt.Sprintf("Execution timed out after %s", timeout),
+				})
+			} else if outputExceeded && errors.Is(err, services.ErrStoppedManually) {
+				result.Status, result.Limit = models.StatusLimitExceeded, models.LimitOutput
+
+				note(models.Output{
+					Stream: models.StreamStderr,
+					Text:   "Execution was stopped, output limit exceeded",
+				})
+			} else if errors.Is(err, services.ErrStoppedManually) {
+				note(models.Output{
+					Stream: models.StreamStderr,
+					Text:   "Execution was interrupted",
+				})
 			} else {
-				return
+				note(models.Output{
+					Stream: models.StreamStderr,
+					Text:   fmt.Sprintf("Stopped with error: %s", err.Error()),
+				})
+
+				c.log.Info("can't execute sctipt", c.log.Attr("op", op), c.log.Attr("error", err))
 			}
-		case err, open := <-errCh:
-			if open {
-				if errors.Is(err, services.ErrStoppedManually) {
-					ctx, cancel := context.WithTimeout(context.Background(), contextDuration)
+		case <-flushTicker.C:
+			flush()
+		case <-timeoutCh:
+			timeoutCh = nil
 
-					if _, errOut := c.cmdStorage.SaveOutput(ctx, id, "Execution was interrupted"); errOut != nil {
-						c.log.Error("can't save info in storage", c.log.Attr("op", op), c.log.Attr("error", errOut))
-					}
+			signal(func() {
+				timedOut = true
 
-					cancel()
-					return
-				} else {
-					ctx, cancel := context.WithTimeout(context.Background(), contextDuration)
+				c.log.Debug("script timed out", c.log.Attr("op", op), c.log.Attr("command_id", id))
+			})
+		case signalCh <- syscall.SIGTERM:
+			signalCh = nil
 
-					if _, errOut := c.cmdStorage.SaveOutput(ctx,
-						id, fmt.Sprintf("Stopped with error: %s", err.Error())); errOut != nil {
-						c.log.Error("can't save error in storage", c.log.Attr("op", op), c.log.Attr("error", errOut))
-					}
+			sent()
+		}
+	}
+}
 
-					cancel()
+// truncate marks the command's output as truncated in storage ...
+func (c *Commander) truncate(id int64) {
+	const op = "commander.truncate"
 
-					c.log.Info("can't execute sctipt", c.log.Attr("op", op), c.log.Attr("error", err))
-					return
-				}
-			} else {
-				return
-			}
+	ctx, cancel := context.WithTimeout(context.Background(), contextDuration)
+	defer cancel()
+
+	if _, err := c.cmdStorage.TruncateOne(ctx, id); err != nil {
+		c.log.Error("can't mark output truncated in storage", c.log.Attr("op", op), c.log.Attr("error", err))
+	}
+}
+
+// indexOutput indexes the finished command's output for search in storage ...
+func (c *Commander) indexOutput(id int64) {
+	const op = "commander.indexOutput"
+
+	defer c.running.Done()
+
+	ctx, cancel := context.WithTimeout(context.Background(), indexTimeout)
+	defer cancel()
+
+	if err := c.cmdStorage.IndexOutput(ctx, id); err != nil {
+		c.log.Error("can't index output in storage", c.log.Attr("op", op), c.log.Attr("error", err))
+	}
+}
+
+// saveLines saves the batch of output lines in storage and publishes them.
+// Lines are published after they are saved, so the stream never misses them ...
+func (c *Commander) saveLines(id int64, outputs []models.Output) {
+	const op = "commander.saveLines"
+
+	ctx, cancel := context.WithTimeout(context.Background(), contextDuration)
+	defer cancel()
+
+	if err := c.cmdStorage.SaveOutputs(ctx, id, outputs); err != nil {
+		c.log.Error("can't save output in storage", c.log.Attr("op", op), c.log.Attr("error", err))
+		return
+	}
+
+	for i := range outputs {
+		c.events.publish(id, models.Event{ID: outputs[i].Line, Type: models.EventOutput, Output: &outputs[i]})
+	}
+}
+
+// executionResult converts the script's error to the result of execution ...
+func executionResult(err error) models.Result {
+	result := models.Result{Status: models.StatusFailed}
+
+	if errors.Is(err, services.ErrStoppedManually) {
+		result.Status = models.StatusStopped
+	}
+
+	var exitErr *services.ExitError
+
+	if errors.As(err, &exitErr) {
+		if exitErr.Signal != "" {
+			result.Signal = exitErr.Signal
+		} else {
+			code := exitErr.Code
+			result.ExitCode = &code
+		}
+
+		if exitErr.Limit != "" && result.Status == models.StatusFailed {
+			result.Status = models.StatusLimitExceeded
+			result.Limit = exitErr.Limit
+		}
+	}
+
+	return result
+}
+
+// checkFilter checks statuses and the date range of the filter ...
+func checkFilter(f models.CommandFilter) error {
+	for _, status := range f.Statuses {
+		if !slices.Contains(statuses, status) {
+			return fmt.Errorf("%w: unknown status %s", services.ErrInvalidOptions, status)
 		}
 	}
+
+	if !f.From.IsZero() && !f.To.IsZero() && !f.From.Before(f.To) {
+		return fmt.Errorf("%w: start of date range must be before its end", services.ErrInvalidOptions)
+	}
+
+	return nil
+}
+
+// scriptOptions checks options of the script and returns them with defaults applied ...
+func (c *Commander) scriptOptions(opts models.Options) (models.Options, error) {
+	var err error
+
+	if opts.Timeout, err = c.scriptTimeout(opts.Timeout); err != nil {
+		return opts, err
+	}
+
+	if opts.Limits, err = c.scriptLimits(opts.Limits); err != nil {
+		return opts, err
+	}
+
+	if err = c.checkEnv(opts.Env); err != nil {
+		return opts, err
+	}
+
+	if opts.WorkingDir, err = c.workingDir(opts.WorkingDir); err != nil {
+		return opts, err
+	}
+
+	if opts.Interpreter, err = c.scriptInterpreter(opts.Interpreter); err != nil {
+		return opts, err
+	}
+
+	if err = c.checkCgroupLimits(opts); err != nil {
+		return opts, err
+	}
+
+	if err = checkArtifacts(opts.Artifacts); err != nil {
+		return opts, err
+	}
+
+	return opts, nil
+}
+
+// scriptLimits returns resource limits of the script bounded by configured maxima.
+// Zero limit means the default one ...
+func (c *Commander) scriptLimits(l models.Limits) (models.Limits, error) {
+	var err error
+	res := models.Limits{}
+
+	def, maxima := c.cfg.DefaultLimits, c.cfg.MaxLimits
+
+	if res.CPUTime, err = boundedLimit(models.LimitCPUTime, l.CPUTime, def.CPUTime, maxima.CPUTime); err != nil {
+		return res, err
+	}
+
+	if res.MemoryMB, err = boundedLimit(models.LimitMemory, l.MemoryMB, def.MemoryMB, maxima.MemoryMB); err != nil {
+		return res, err
+	}
+
+	if res.OpenFiles, err = boundedLimit(models.LimitOpenFiles, l.OpenFiles, def.OpenFiles, maxima.OpenFiles); err != nil {
+		return res, err
+	}
+
+	if res.Processes, err = boundedLimit(models.LimitProcesses, l.Processes, def.Processes, maxima.Processes); err != nil {
+		return res, err
+	}
+
+	return res, nil
+}
+
+// checkCgroupLimits rejects limits which are applied only by the cgroup of the executor
+// if the executor has no cgroup ...
+func (c *Commander) checkCgroupLimits(opts models.Options) error {
+	if opts.Limits.Processes > 0 && !c.exec.HasCgroup() {
+		return fmt.Errorf("%w: %s limit requires cgroup of executor", services.ErrInvalidOptions, models.LimitProcesses)
+	}
+
+	return nil
+}
+
+// boundedLimit returns the limit or the default one if the limit is zero.
+// The default limit never exceeds the maximum ...
+func boundedLimit[T time.Duration | int64](name string, limit, def, maxLimit T) (T, error) {
+	switch {
+	case limit < 0:
+		return 0, fmt.Errorf("%w: %s limit can't be negative", services.ErrInvalidOptions, name)
+	case limit == 0 && maxLimit > 0 && (def == 0 || def > maxLimit):
+		return maxLimit, nil
+	case limit == 0:
+		return def, nil
+	case maxLimit > 0 && limit > maxLimit:
+		return 0, fmt.Errorf("%w: %s limit can't be more than %v", services.ErrInvalidOptions, name, maxLimit)
+	default:
+		return limit, nil
+	}
+}
+
+// checkEnv checks names of environment variables against allowed and denied patterns ...
+func (c *Commander) checkEnv(env map[string]string) error {
+	for name := range env {
+		switch {
+		case !envName.MatchString(name):
+			return fmt.Errorf("%w: invalid environment variable name %q", services.ErrInvalidOptions, name)
+		case matchAny(c.cfg.DeniedEnv, name):
+			return fmt.Errorf("%w: environment variable %s is denied", services.ErrInvalidOptions, name)
+		case len(c.cfg.AllowedEnv) > 0 && !matchAny(c.cfg.AllowedEnv, name):
+			return fmt.Errorf("%w: environment variable %s isn't allowed", services.ErrInvalidOptions, name)
+		}
+	}
+
+	return nil
+}
+
+// workingDir returns the cleaned working directory of the script.
+// The directory must exist and be inside one of configured base directories ...
+func (c *Commander) workingDir(dir string) (string, error) {
+	if dir == "" {
+		return "", nil
+	}
+
+	if !filepath.IsAbs(dir) {
+		return "", fmt.Errorf("%w: working directory must be absolute path", services.ErrInvalidOptions)
+	}
+
+	resolved, err := filepath.EvalSymlinks(dir)
+	if err != nil {
+		return "", fmt.Errorf("%w: working directory %s doesn't exist", services.ErrInvalidOptions, dir)
+	}
+
+	if info, err := os.Stat(resolved); err != nil || !info.IsDir() {
+		return "", fmt.Errorf("%w: working directory %s isn't a directory", services.ErrInvalidOptions, dir)
+	}
+
+	for _, base := range c.cfg.BaseDirs {
+		if base, err = filepath.EvalSymlinks(base); err != nil {
+			continue
+		}
+
+		if rel, err := filepath.Rel(base, resolved); err == nil && rel != ".." && !strings.HasPrefix(rel, "../") {
+			return resolved, nil
+		}
+	}
+
+	return "", fmt.Errorf("%w: working directory %s isn't allowed", services.ErrInvalidOptions, dir)
+}
+
+// scriptInterpreter returns the name of the script's interpreter, empty name means the default one ...
+func (c *Commander) scriptInterpreter(name string) (string, error) {
+	if name == "" {
+		name = c.cfg.DefaultInterpreter
+	}
+
+	if name == "" {
+		name = "bash"
+	}
+
+	if _, ok := c.interpreters()[name]; !ok {
+		return "", fmt.Errorf("%w: unknown interpreter %s", services.ErrInvalidOptions, name)
+	}
+
+	return name, nil
+}
+
+// invocation returns the command line of the script's interpreter with the script's body ...
+func (c *Commander) invocation(script models.Script) ([]string, error) {
+	const op = "commander.invocation"
+
+	name := script.Interpreter
+	if name == "" {
+		name = "bash"
+	}
+
+	template, ok := c.interpreters()[name]
+	if !ok {
+		return nil, fmt.Errorf("unknown interpreter %s: %s", name, op)
+	}
+
+	res := make([]string, 0, len(template))
+
+	for _, arg := range template {
+		res = append(res, strings.ReplaceAll(arg, scriptPlaceholder, script.Body))
+	}
+
+	return res, nil
+}
+
+// interpreters returns configured interpreters or the default ones ...
+func (c *Commander) interpreters() map[string][]string {
+	if len(c.cfg.Interpreters) == 0 {
+		return defaultInterpreters
+	}
+
+	return c.cfg.Interpreters
+}
+
+// matchAny reports whether the name matches any of glob patterns ...
+func matchAny(patterns []string, name string) bool {
+	for _, pattern := range patterns {
+		if ok, _ := path.Match(pattern, name); ok {
+			return true
+		}
+	}
+
+	return false
+}
+
+// stopSignal returns the signal by its name, SIGTERM is used for empty name ...
+func stopSignal(name string) (syscall.Signal, error) {
+	if name == "" {
+		return syscall.SIGTERM, nil
+	}
+
+	name = strings.ToUpper(name)
+	if !strings.HasPrefix(name, "SIG") {
+		name = "SIG" + name
+	}
+
+	sig := unix.SignalNum(name)
+	if sig == 0 {
+		return 0, fmt.Errorf("%w: unknown signal %s", services.ErrInvalidOptions, name)
+	}
+
+	if !slices.Contains(stopSignals, sig) {
+		return 0, fmt.Errorf("%w: signal %s can't stop the command", services.ErrInvalidOptions, name)
+	}
+
+	return sig, nil
+}
+
+// done returns the channel which is closed when the executor of the command ends,
+// it's nil if the command isn't known ...
+func (c *Commander) done(id int64) <-chan struct{} {
+	if val, ok := c.finished.Load(id); ok {
+		if ch, ok := val.(chan struct{}); ok {
+			return ch
+		}
+	}
+
+	return nil
+}
+
+// finish closes the channel of the command whose executor has ended ...
+func (c *Commander) finish(id int64) {
+	if val, ok := c.finished.LoadAndDelete(id); ok {
+		if ch, ok := val.(chan struct{}); ok {
+			close(ch)
+		}
+	}
+}
+
+// sendSignal sends the signal to the executor of running command.
+// It gives no executing command error if the executor ends before it takes the signal ...
+func sendSignal(ctx context.Context, ch chan<- syscall.Signal, done <-chan struct{}, sig syscall.Signal) error {
+	select {
+	case ch <- sig:
+		return nil
+	case <-done:
+		return services.ErrNoExecutingCommand
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+// scriptTimeout returns timeout of the script bounded by configured maximum.
+// Zero timeout means the default one ...
+func (c *Commander) scriptTimeout(timeout time.Duration) (time.Duration, error) {
+	switch {
+	case timeout < 0:
+		return 0, fmt.Errorf("%w: timeout can't be negative", services.ErrInvalidOptions)
+	case timeout == 0:
+		return c.cfg.DefaultTimeout, nil
+	case c.cfg.MaxTimeout > 0 && timeout > c.cfg.MaxTimeout:
+		return 0, fmt.Errorf("%w: timeout can't be more than %s", services.ErrInvalidOptions, c.cfg.MaxTimeout)
+	default:
+		return timeout, nil
+	}
 }
 
 // scriptName returns valid name of script ...
@@ -236,3 +1305,10 @@ func scriptName(script string) string {
 
 	return string(res)
 }
+
+// scriptHash returns hex encoded sha256 hash of the script ...
+func scriptHash(script string) string {
+	sum := sha256.Sum256([]byte(script))
+
+	return hex.EncodeToString(sum[:])
+}
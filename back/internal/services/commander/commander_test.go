@@ -2,12 +2,20 @@ package commander
 
 import (
 	"context"
+	"encoding/json"
 	"errors"
+	"io"
+	"os"
+	"path/filepath"
 	"sync"
+	"syscall"
 	"testing"
+	"time"
 
+	"github.com/enchik0reo/commandApi/internal/config"
 	"github.com/enchik0reo/commandApi/internal/logs"
 	"github.com/enchik0reo/commandApi/internal/models"
+	"github.com/enchik0reo/commandApi/internal/services"
 	"github.com/enchik0reo/commandApi/internal/services/commander/mocks"
 
 	"github.com/golang/mock/gomock"
@@ -17,14 +25,17 @@ import (
 
 func TestCommander_CreateNewCommand(t *testing.T) {
 	type fields struct {
-		Storager  *mocks.Storager
-		Executor  *mocks.Executor
-		log       *logs.CustomLog
-		stopChans *sync.Map
+		Storager   *mocks.Storager
+		Executor   *mocks.Executor
+		log        *logs.CustomLog
+		stopChans  *sync.Map
+		inputs     *sync.Map
+		queue      *jobQueue
+		workspaces *workspaces
 	}
 	type args struct {
 		ctx    context.Context
-		script string
+		script models.Script
 	}
 	tests := []struct {
 		name    string
@@ -33,27 +44,43 @@ func TestCommander_CreateNewCommand(t *testing.T) {
 		want    int64
 		wantErr bool
 		prepare func(args2 args, fields *fields)
+		check   func(t *testing.T, fields *fields)
 	}{
 		{
 			name: "test_1, no error",
 			args: args{
 				ctx:    context.Background(),
-				script: "whoami",
+				script: models.Script{Body: "whoami"},
 			},
 			want: 1,
 			prepare: func(args2 args, fields *fields) {
 				script := "whoami"
 
-				fields.Storager.On("CreateNew", mock.Anything, mock.Anything).Return(int64(1), nil)
-				fields.Executor.On("RunScript", script, script, mock.Anything).
-					Return(make(<-chan string), make(<-chan error))
+				fields.Storager.On("CreateNew", mock.Anything, models.Command{
+					Name:        script,
+					Script:      script,
+					ScriptHash:  "f25297859cf0a70af5c053a5464a5fa647a35ceee1d91397331903846d79ffc1",
+					Interpreter: "bash",
+					Status:      models.StatusQueued,
+					Options:     &models.Options{Timeout: time.Minute, Interpreter: "bash"},
+				}).Return(int64(1), nil)
+				fields.Storager.On("StartOne", mock.Anything, int64(1)).Return(int64(1), nil)
+				fields.Executor.On("RunScript", models.Script{Name: script, Body: script, Workspace: fields.workspaces.path(1),
+					Invocation: []string{"/bin/bash", "-c", script, "bash"},
+					Options:    models.Options{Timeout: time.Minute, Interpreter: "bash"}},
+					mock.Anything, mock.Anything).
+					Return(make(<-chan models.Output), make(<-chan error))
+			},
+			check: func(t *testing.T, fields *fields) {
+				_, ok := fields.inputs.Load(int64(1))
+				require.False(t, ok)
 			},
 		},
 		{
 			name: "test_2, with db error",
 			args: args{
 				ctx:    context.Background(),
-				script: "whoami",
+				script: models.Script{Body: "whoami"},
 			},
 			want:    -1,
 			wantErr: true,
@@ -62,16 +89,133 @@ func TestCommander_CreateNewCommand(t *testing.T) {
 					Return(int64(0), errors.New("some db error"))
 			},
 		},
+		{
+			name: "test_3, interactive script",
+			args: args{
+				ctx: context.Background(),
+				script: models.Script{Body: "read name", Options: models.Options{Interactive: true, Timeout: 5 * time.Second,
+					Interpreter: "sh"}},
+			},
+			want: 2,
+			prepare: func(args2 args, fields *fields) {
+				fields.Storager.On("CreateNew", mock.Anything, mock.Anything).Return(int64(2), nil)
+				fields.Storager.On("StartOne", mock.Anything, int64(2)).Return(int64(2), nil)
+				fields.Executor.On("RunScript",
+					models.Script{Name: "read name", Body: "read name", Workspace: fields.workspaces.path(2),
+						Invocation: []string{"/bin/sh", "-c", "read name", "sh"},
+						Options:    models.Options{Interactive: true, Timeout: 5 * time.Second, Interpreter: "sh"}},
+					mock.Anything, mock.Anything).
+					Return(make(<-chan models.Output), make(<-chan error))
+			},
+			check: func(t *testing.T, fields *fields) {
+				_, ok := fields.inputs.Load(int64(2))
+				require.True(t, ok)
+			},
+		},
+		{
+			name: "test_4, timeout is too long",
+			args: args{
+				ctx:    context.Background(),
+				script: models.Script{Body: "sleep 10000", Options: models.Options{Timeout: 2 * time.Hour}},
+			},
+			want:    -1,
+			wantErr: true,
+			prepare: func(args2 args, fields *fields) {},
+		},
+		{
+			name: "test_5, unknown interpreter",
+			args: args{
+				ctx:    context.Background(),
+				script: models.Script{Body: "print(1)", Options: models.Options{Interpreter: "python3"}},
+			},
+			want:    -1,
+			wantErr: true,
+			prepare: func(args2 args, fields *fields) {},
+		},
+		{
+			name: "test_7, interpreter with unlimited address space",
+			args: args{
+				ctx:    context.Background(),
+				script: models.Script{Body: "console.log(1)", Options: models.Options{Interpreter: "node"}},
+			},
+			want: 4,
+			prepare: func(args2 args, fields *fields) {
+				fields.Storager.On("CreateNew", mock.Anything, mock.Anything).Return(int64(4), nil)
+				fields.Storager.On("StartOne", mock.Anything, int64(4)).Return(int64(4), nil)
+				fields.Executor.On("RunScript",
+					models.Script{Name: "console.log(1)", Body: "console.log(1)", Workspace: fields.workspaces.path(4),
+						Invocation:            []string{"/usr/bin/env", "node", "-e", "console.log(1)"},
+						UnlimitedAddressSpace: true,
+						Options:               models.Options{Timeout: time.Minute, Interpreter: "node"}},
+					mock.Anything, mock.Anything).
+					Return(make(<-chan models.Output), make(<-chan error))
+			},
+		},
+		{
+			name: "test_8, known shebang interpreter",
+			args: args{
+				ctx:    context.Background(),
+				script: models.Script{Body: "#!/bin/sh\necho hi", ShebangInterpreter: "sh"},
+			},
+			want: 5,
+			prepare: func(args2 args, fields *fields) {
+				fields.Storager.On("CreateNew", mock.Anything, mock.Anything).Return(int64(5), nil)
+				fields.Storager.On("StartOne", mock.Anything, int64(5)).Return(int64(5), nil)
+				fields.Executor.On("RunScript", mock.MatchedBy(func(s models.Script) bool {
+					return s.Interpreter == "sh" && s.Invocation[0] == "/bin/sh"
+				}), mock.Anything, mock.Anything).
+					Return(make(<-chan models.Output), make(<-chan error))
+			},
+		},
+		{
+			name: "test_9, unknown shebang interpreter",
+			args: args{
+				ctx:    context.Background(),
+				script: models.Script{Body: "#!/bin/zsh\necho hi", ShebangInterpreter: "zsh"},
+			},
+			want: 6,
+			prepare: func(args2 args, fields *fields) {
+				fields.Storager.On("CreateNew", mock.Anything, mock.Anything).Return(int64(6), nil)
+				fields.Storager.On("StartOne", mock.Anything, int64(6)).Return(int64(6), nil)
+				fields.Executor.On("RunScript", mock.MatchedBy(func(s models.Script) bool {
+					return s.Interpreter == "bash" && s.Invocation[0] == "/bin/bash"
+				}), mock.Anything, mock.Anything).
+					Return(make(<-chan models.Output), make(<-chan error))
+			},
+		},
+		{
+			name: "test_6, no free slots",
+			args: args{
+				ctx:    context.Background(),
+				script: models.Script{Body: "whoami"},
+			},
+			want: 3,
+			prepare: func(args2 args, fields *fields) {
+				fields.queue.limit = 1
+				fields.queue.running = 1
+
+				fields.Storager.On("CreateNew", mock.Anything, mock.Anything).Return(int64(3), nil)
+			},
+			check: func(t *testing.T, fields *fields) {
+				require.True(t, fields.queue.has(3))
+
+				_, ok := fields.stopChans.Load(int64(3))
+				require.True(t, ok)
+			},
+		},
 	}
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
 			dlog := logs.NewDiscardLogger()
 
 			f := fields{
-				Storager:  mocks.NewStorager(t),
-				Executor:  mocks.NewExecutor(t),
-				log:       dlog,
-				stopChans: &sync.Map{},
+				Storager:   mocks.NewStorager(t),
+				Executor:   mocks.NewExecutor(t),
+				log:        dlog,
+				stopChans:  &sync.Map{},
+				inputs:     &sync.Map{},
+				queue:      newJobQueue(0),
+				workspaces: newWorkspaces(dlog, config.Workspace{Root: t.TempDir()}),
 			}
 
 			tt.prepare(tt.args, &f)
@@ -79,8 +223,19 @@ func TestCommander_CreateNewCommand(t *testing.T) {
 			c := &Commander{
 				cmdStorage: f.Storager,
 				exec:       f.Executor,
+				cfg: &config.Commander{DefaultTimeout: time.Minute, MaxTimeout: time.Hour,
+					Interpreters: map[string][]string{
+						"bash": {"/bin/bash", "-c", "{script}", "bash"},
+						"sh":   {"/bin/sh", "-c", "{script}", "sh"},
+						"node": {"/usr/bin/env", "node", "-e", "{script}"},
+					},
+					UnlimitedAddressSpace: []string{"node"}},
 				log:        f.log,
 				stopChans:  f.stopChans,
+				inputs:     f.inputs,
+				queue:      f.queue,
+				workspaces: f.workspaces,
+				events:     newBroker(),
 			}
 
 			got, err := c.CreateNewCommand(tt.args.ctx, tt.args.script)
@@ -95,6 +250,10 @@ func TestCommander_CreateNewCommand(t *testing.T) {
 			}
 
 			require.Equal(t, tt.want, got)
+
+			if tt.check != nil {
+				tt.check(t, &f)
+			}
 		})
 	}
 }
@@ -106,60 +265,83 @@ func TestCommander_GetCommandList(t *testing.T) {
 		log      *logs.CustomLog
 	}
 	type args struct {
-		ctx   context.Context
-		limit int64
+		ctx    context.Context
+		filter models.CommandFilter
 	}
 	tests := []struct {
-		name    string
-		fields  fields
-		args    args
-		want    []models.Command
-		wantErr bool
-		prepare func(args2 args, fields fields)
+		name     string
+		fields   fields
+		args     args
+		want     []models.Command
+		wantNext int64
+		wantErr  bool
+		prepare  func(args2 args, fields fields)
 	}{
 		{
 			name: "test_1, no error",
 			args: args{
-				ctx:   context.Background(),
-				limit: 1,
+				ctx:    context.Background(),
+				filter: models.CommandFilter{Limit: 1},
 			},
-			want: []models.Command{{}},
+			want:     []models.Command{{ID: 7}},
+			wantNext: 7,
 			prepare: func(args2 args, fields fields) {
 				fields.Storager.EXPECT().GetList(
 					context.Background(),
-					int64(1)).
-					Return([]models.Command{{}}, nil)
+					models.CommandFilter{Limit: 1}).
+					Return([]models.Command{{ID: 7}}, nil)
 			},
 		},
 		{
-			name: "test_2, no error",
+			name: "test_2, last page",
 			args: args{
-				ctx:   context.Background(),
-				limit: 5,
+				ctx:    context.Background(),
+				filter: models.CommandFilter{Limit: 5, Cursor: 4, Statuses: []string{models.StatusFailed}},
 			},
-			want: []models.Command{{}, {}, {}, {}, {}},
+			want: []models.Command{{ID: 3}, {ID: 1}},
 			prepare: func(args2 args, fields fields) {
 				fields.Storager.EXPECT().GetList(
 					context.Background(),
-					int64(5)).
-					Return([]models.Command{{}, {}, {}, {}, {}}, nil)
+					models.CommandFilter{Limit: 5, Cursor: 4, Statuses: []string{models.StatusFailed}}).
+					Return([]models.Command{{ID: 3}, {ID: 1}}, nil)
 			},
 		},
 		{
 			name: "test_3, with error",
 			args: args{
-				ctx:   context.Background(),
-				limit: -1,
+				ctx:    context.Background(),
+				filter: models.CommandFilter{Limit: -1},
 			},
 			want:    nil,
 			wantErr: true,
 			prepare: func(args2 args, fields fields) {
 				fields.Storager.EXPECT().GetList(
 					context.Background(),
-					int64(-1)).
+					models.CommandFilter{Limit: -1}).
 					Return(nil, errors.New("limit might be more then 0"))
 			},
 		},
+		{
+			name: "test_4, unknown status",
+			args: args{
+				ctx:    context.Background(),
+				filter: models.CommandFilter{Limit: 5, Statuses: []string{"done"}},
+			},
+			want:    nil,
+			wantErr: true,
+			prepare: func(args2 args, fields fields) {},
+		},
+		{
+			name: "test_5, empty date range",
+			args: args{
+				ctx: context.Background(),
+				filter: models.CommandFilter{Limit: 5, From: time.Date(2024, 1, 2, 0, 0, 0, 0, time.UTC),
+					To: time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)},
+			},
+			want:    nil,
+			wantErr: true,
+			prepare: func(args2 args, fields fields) {},
+		},
 	}
 
 	for _, tt := range tests {
@@ -176,17 +358,18 @@ func TestCommander_GetCommandList(t *testing.T) {
 
 			tt.prepare(tt.args, f)
 
-			c := NewCommander(dlog, f.Storager, f.Executor)
+			c := NewCommander(dlog, f.Storager, f.Executor, &config.Commander{})
 
-			got, err := c.GetCommandList(tt.args.ctx, tt.args.limit)
+			got, next, err := c.GetCommandList(tt.args.ctx, tt.args.filter)
 
-			if err != nil {
-				if !tt.wantErr {
-					require.Error(t, err)
-				}
+			if tt.wantErr {
+				require.Error(t, err)
+			} else {
+				require.NoError(t, err)
 			}
 
 			require.Equal(t, tt.want, got)
+			require.Equal(t, tt.wantNext, next)
 		})
 	}
 }
@@ -218,7 +401,7 @@ func TestCommander_GetOneCommandDescription(t *testing.T) {
 			want: &models.Command{
 				ID:        1,
 				Name:      "whoami",
-				Output:    []string{"root"},
+				Output:    []models.Output{{Stream: models.StreamStdout, Text: "root"}},
 				IsWorking: false,
 			},
 			prepare: func(args2 args, fields fields) {
@@ -227,9 +410,14 @@ func TestCommander_GetOneCommandDescription(t *testing.T) {
 					int64(1)).Return(&models.Command{
 					ID:        1,
 					Name:      "whoami",
-					Output:    []string{"root"},
 					IsWorking: false,
 				}, nil)
+
+				fields.Storager.EXPECT().GetOutput(
+					context.Background(),
+					int64(1),
+					models.OutputRange{Tail: defaultOutputLimit}).Return(
+					[]models.Output{{Stream: models.StreamStdout, Text: "root"}}, int64(1), nil)
 			},
 		}, {
 			name: "test_2, with error",
@@ -245,6 +433,39 @@ func TestCommander_GetOneCommandDescription(t *testing.T) {
 					int64(1)).Return(nil, errors.New("there's no script"))
 			},
 		},
+		{
+			name: "test_3, not found",
+			args: args{
+				ctx: context.Background(),
+				id:  2,
+			},
+			want:    nil,
+			wantErr: true,
+			prepare: func(args2 args, fields fields) {
+				fields.Storager.EXPECT().GetOne(
+					context.Background(),
+					int64(2)).Return(nil, services.ErrCommandNotFound)
+			},
+		},
+		{
+			name: "test_4, output error",
+			args: args{
+				ctx: context.Background(),
+				id:  1,
+			},
+			want:    nil,
+			wantErr: true,
+			prepare: func(args2 args, fields fields) {
+				fields.Storager.EXPECT().GetOne(
+					context.Background(),
+					int64(1)).Return(&models.Command{ID: 1}, nil)
+
+				fields.Storager.EXPECT().GetOutput(
+					context.Background(),
+					int64(1),
+					models.OutputRange{Tail: defaultOutputLimit}).Return(nil, int64(0), errors.New("db error"))
+			},
+		},
 	}
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
@@ -260,7 +481,7 @@ func TestCommander_GetOneCommandDescription(t *testing.T) {
 
 			tt.prepare(tt.args, f)
 
-			c := NewCommander(dlog, f.Storager, f.Executor)
+			c := NewCommander(dlog, f.Storager, f.Executor, &config.Commander{})
 
 			got, err := c.GetOneCommandDescription(tt.args.ctx, tt.args.id)
 
@@ -275,7 +496,7 @@ func TestCommander_GetOneCommandDescription(t *testing.T) {
 	}
 }
 
-func TestCommander_StopCommand(t *testing.T) {
+func TestCommander_StreamCommand(t *testing.T) {
 	type fields struct {
 		Storager  *mocks.MockStorager
 		Executor  *mocks.MockExecutor
@@ -283,69 +504,143 @@ func TestCommander_StopCommand(t *testing.T) {
 		stopChans *sync.Map
 	}
 	type args struct {
-		ctx context.Context
-		id  int64
+		ctx   context.Context
+		id    int64
+		after int64
 	}
 	tests := []struct {
 		name    string
 		fields  fields
 		args    args
-		want    int64
+		want    []models.Event
 		wantErr bool
 		prepare func(args2 args, fields *fields)
+		publish func(c *Commander)
 	}{
 		{
-			name: "test_1, no error",
+			name: "test_1, finished command",
+			args: args{
+				ctx:   context.Background(),
+				id:    1,
+				after: 1,
+			},
+			want: []models.Event{
+				{ID: 2, Type: models.EventOutput, Output: &models.Output{Line: 2, Stream: models.StreamStderr, Text: "two"}},
+				{ID: 3, Type: models.EventOutput, Output: &models.Output{Line: 3, Stream: models.StreamStdout, Text: "three"}},
+				{Type: models.EventStatus, Result: &models.Result{Status: models.StatusFailed, Signal: "SIGKILL"}},
+			},
+			prepare: func(args2 args, fields *fields) {
+				fields.Storager.EXPECT().GetOne(gomock.Any(), int64(1)).Return(&models.Command{
+					ID:     1,
+					Status: models.StatusFailed,
+					Signal: "SIGKILL",
+				}, nil)
+
+				// The output is replayed by pages until the last line.
+				fields.Storager.EXPECT().GetOutput(gomock.Any(), int64(1),
+					models.OutputRange{Since: 2, Limit: maxOutputLimit}).Return([]models.Output{
+					{Line: 2, Stream: models.StreamStderr, Text: "two"},
+				}, int64(3), nil)
+
+				fields.Storager.EXPECT().GetOutput(gomock.Any(), int64(1),
+					models.OutputRange{Since: 3, Limit: maxOutputLimit}).Return([]models.Output{
+					{Line: 3, Stream: models.StreamStdout, Text: "three"},
+				}, int64(3), nil)
+			},
+		},
+		{
+			name: "test_2, running command",
 			args: args{
 				ctx: context.Background(),
 				id:  1,
 			},
-			want: 1,
+			want: []models.Event{
+				{ID: 1, Type: models.EventOutput, Output: &models.Output{Line: 1, Stream: models.StreamStdout, Text: "one"}},
+				{ID: 2, Type: models.EventOutput, Output: &models.Output{Line: 2, Stream: models.StreamStdout, Text: "two"}},
+				{Type: models.EventStatus, Result: &models.Result{Status: models.StatusSucceeded}},
+			},
 			prepare: func(args2 args, fields *fields) {
-				ch := make(chan struct{})
-
-				go func() {
-					<-ch
-					close(ch)
-				}()
+				fields.stopChans.Store(int64(1), make(chan syscall.Signal))
 
-				fields.stopChans.Store(int64(1), ch)
+				fields.Storager.EXPECT().GetOne(gomock.Any(), int64(1)).Return(&models.Command{
+					ID:        1,
+					IsWorking: true,
+				}, nil)
 
-				fields.Storager.EXPECT().StopOne(
-					context.Background(),
-					int64(1)).Return(int64(1), nil)
+				fields.Storager.EXPECT().GetOutput(gomock.Any(), int64(1),
+					models.OutputRange{Since: 1, Limit: maxOutputLimit}).Return([]models.Output{
+					{Line: 1, Stream: models.StreamStdout, Text: "one"},
+				}, int64(1), nil)
+			},
+			publish: func(c *Commander) {
+				c.events.publish(1, models.Event{ID: 1, Type: models.EventOutput,
+					Output: &models.Output{Line: 1, Stream: models.StreamStdout, Text: "one"}})
+				c.events.publish(1, models.Event{ID: 2, Type: models.EventOutput,
+					Output: &models.Output{Line: 2, Stream: models.StreamStdout, Text: "two"}})
+				c.events.publish(1, models.Event{Type: models.EventStatus,
+					Result: &models.Result{Status: models.StatusSucceeded}})
 			},
 		},
 		{
-			name: "test_2, with error",
+			name: "test_3, with db error",
 			args: args{
 				ctx: context.Background(),
-				id:  10,
+				id:  1,
 			},
-			want:    0,
 			wantErr: true,
-			prepare: func(args2 args, fields *fields) {},
+			prepare: func(args2 args, fields *fields) {
+				fields.Storager.EXPECT().GetOne(gomock.Any(), int64(1)).Return(nil, errors.New("db error"))
+			},
 		},
 		{
-			name: "test_3, with db error",
+			name: "test_4, dropped subscriber",
 			args: args{
 				ctx: context.Background(),
 				id:  1,
 			},
-			want: 0,
+			want: func() []models.Event {
+				want := []models.Event{}
+				for line := int64(1); line <= subscriberBuffer+1; line++ {
+					want = append(want, models.Event{ID: line, Type: models.EventOutput,
+						Output: &models.Output{Line: line, Stream: models.StreamStdout, Text: "line"}})
+				}
+
+				return append(want, models.Event{Type: models.EventStatus, Result: &models.Result{Status: models.StatusSucceeded}})
+			}(),
 			prepare: func(args2 args, fields *fields) {
-				ch := make(chan struct{})
+				fields.stopChans.Store(int64(1), make(chan syscall.Signal))
 
-				go func() {
-					<-ch
-					close(ch)
-				}()
+				fields.Storager.EXPECT().GetOne(gomock.Any(), int64(1)).Return(&models.Command{
+					ID:        1,
+					IsWorking: true,
+				}, nil)
 
-				fields.stopChans.Store(int64(1), ch)
+				fields.Storager.EXPECT().GetOutput(gomock.Any(), int64(1),
+					models.OutputRange{Since: 1, Limit: maxOutputLimit}).Return([]models.Output{
+					{Line: 1, Stream: models.StreamStdout, Text: "line"},
+				}, int64(1), nil)
 
-				fields.Storager.EXPECT().StopOne(
-					context.Background(),
-					int64(1)).Return(int64(0), errors.New("db error"))
+				// The stream is dropped, so the last line and the status are read from storage.
+				fields.Storager.EXPECT().GetOne(gomock.Any(), int64(1)).Return(&models.Command{
+					ID:     1,
+					Status: models.StatusSucceeded,
+				}, nil)
+
+				fields.Storager.EXPECT().GetOutput(gomock.Any(), int64(1),
+					models.OutputRange{Since: subscriberBuffer + 1, Limit: maxOutputLimit}).Return([]models.Output{
+					{Line: subscriberBuffer + 1, Stream: models.StreamStdout, Text: "line"},
+				}, int64(subscriberBuffer+1), nil)
+			},
+			publish: func(c *Commander) {
+				c.stopChans.Delete(int64(1))
+
+				for line := int64(1); line <= subscriberBuffer+1; line++ {
+					c.events.publish(1, models.Event{ID: line, Type: models.EventOutput,
+						Output: &models.Output{Line: line, Stream: models.StreamStdout, Text: "line"}})
+				}
+
+				c.events.publish(1, models.Event{Type: models.EventStatus,
+					Result: &models.Result{Status: models.StatusSucceeded}})
 			},
 		},
 	}
@@ -369,17 +664,24 @@ func TestCommander_StopCommand(t *testing.T) {
 				exec:       f.Executor,
 				log:        f.log,
 				stopChans:  f.stopChans,
+				events:     newBroker(),
 			}
 
-			got, err := c.StopCommand(tt.args.ctx, tt.args.id)
+			events, err := c.StreamCommand(tt.args.ctx, tt.args.id, tt.args.after)
 
 			if tt.wantErr {
 				require.Error(t, err)
 				return
 			}
 
-			if err != nil && !tt.wantErr {
-				t.Errorf("Unexpected error: %s", err.Error())
+			if tt.publish != nil {
+				tt.publish(c)
+			}
+
+			got := []models.Event{}
+
+			for ev := range events {
+				got = append(got, ev)
 			}
 
 			require.Equal(t, tt.want, got)
@@ -387,81 +689,74 @@ func TestCommander_StopCommand(t *testing.T) {
 	}
 }
 
-func TestCommander_StopAllRunningScripts(t *testing.T) {
-	type fields struct {
-		Storager  *mocks.MockStorager
-		Executor  *mocks.MockExecutor
-		log       *logs.CustomLog
-		stopChans *sync.Map
-	}
+func TestCommander_WriteInput(t *testing.T) {
 	type args struct {
-		ctx context.Context
+		ctx  context.Context
+		id   int64
+		data []byte
 	}
 	tests := []struct {
 		name    string
-		fields  fields
 		args    args
-		wantErr bool
-		prepare func(args2 args, fields *fields)
+		wantErr error
+		prepare func(c *Commander) chan []byte
 	}{
 		{
 			name: "test_1, no error",
 			args: args{
-				ctx: context.Background(),
+				ctx:  context.Background(),
+				id:   1,
+				data: []byte("root\n"),
 			},
-			prepare: func(args2 args, fields *fields) {
-				ch := make(chan struct{})
-
-				go func() {
-					<-ch
-					close(ch)
-				}()
-
-				ch2 := make(chan struct{})
-
-				go func() {
-					<-ch2
-					close(ch2)
-				}()
-
-				fields.stopChans.Store(int64(1), ch)
-				fields.stopChans.Store(int64(2), ch2)
+			prepare: func(c *Commander) chan []byte {
+				in := newInput()
 
-				fields.Storager.EXPECT().StopOne(
-					context.Background(),
-					int64(1)).Return(int64(1), nil)
+				c.stopChans.Store(int64(1), make(chan syscall.Signal))
+				c.inputs.Store(int64(1), in)
 
-				fields.Storager.EXPECT().StopOne(
-					context.Background(),
-					int64(2)).Return(int64(2), nil)
+				return in.ch
 			},
 		},
 		{
-			name: "test_2, no error empty line",
+			name: "test_2, not running",
 			args: args{
-				ctx: context.Background(),
+				ctx:  context.Background(),
+				id:   1,
+				data: []byte("root\n"),
 			},
-			prepare: func(args2 args, fields *fields) {},
+			wantErr: services.ErrNoExecutingCommand,
+			prepare: func(c *Commander) chan []byte { return nil },
 		},
 		{
-			name: "test_3, with db error",
+			name: "test_3, not interactive",
 			args: args{
-				ctx: context.Background(),
+				ctx:  context.Background(),
+				id:   1,
+				data: []byte("root\n"),
 			},
-			wantErr: true,
-			prepare: func(args2 args, fields *fields) {
-				ch := make(chan struct{})
+			wantErr: services.ErrNotInteractive,
+			prepare: func(c *Commander) chan []byte {
+				c.stopChans.Store(int64(1), make(chan syscall.Signal))
 
-				go func() {
-					<-ch
-					close(ch)
-				}()
+				return nil
+			},
+		},
+		{
+			name: "test_4, closed input",
+			args: args{
+				ctx:  context.Background(),
+				id:   1,
+				data: []byte("root\n"),
+			},
+			wantErr: services.ErrInputClosed,
+			prepare: func(c *Commander) chan []byte {
+				in := newInput()
+				in.close()
 
-				fields.stopChans.Store(int64(1), ch)
+				c.stopChans.Store(int64(1), make(chan syscall.Signal))
+				c.inputs.Store(int64(1), in)
 
-				fields.Storager.EXPECT().StopOne(
-					context.Background(),
-					int64(1)).Return(int64(0), errors.New("db error"))
+				return nil
 			},
 		},
 	}
@@ -469,27 +764,194 @@ func TestCommander_StopAllRunningScripts(t *testing.T) {
 		t.Run(tt.name, func(t *testing.T) {
 			dlog := logs.NewDiscardLogger()
 
-			ctrl := gomock.NewController(t)
-
-			f := fields{
-				Storager:  mocks.NewMockStorager(ctrl),
-				Executor:  mocks.NewMockExecutor(ctrl),
+			c := &Commander{
 				log:       dlog,
 				stopChans: &sync.Map{},
+				inputs:    &sync.Map{},
 			}
 
-			tt.prepare(tt.args, &f)
+			stdin := tt.prepare(c)
 
-			c := &Commander{
-				cmdStorage: f.Storager,
-				exec:       f.Executor,
-				log:        f.log,
-				stopChans:  f.stopChans,
+			got := make(chan []byte, 1)
+
+			if stdin != nil {
+				go func() {
+					got <- <-stdin
+				}()
 			}
 
-			err := c.StopAllRunningScripts(tt.args.ctx)
+			err := c.WriteInput(tt.args.ctx, tt.args.id, tt.args.data)
 
-			if tt.wantErr {
+			if tt.wantErr != nil {
+				require.ErrorIs(t, err, tt.wantErr)
+				return
+			}
+
+			require.NoError(t, err)
+			require.Equal(t, tt.args.data, <-got)
+		})
+	}
+}
+
+func TestCommander_CloseInput(t *testing.T) {
+	dlog := logs.NewDiscardLogger()
+
+	c := &Commander{
+		log:       dlog,
+		stopChans: &sync.Map{},
+		inputs:    &sync.Map{},
+	}
+
+	require.ErrorIs(t, c.CloseInput(1), services.ErrNoExecutingCommand)
+
+	in := newInput()
+
+	c.stopChans.Store(int64(1), make(chan syscall.Signal))
+	c.inputs.Store(int64(1), in)
+
+	require.NoError(t, c.CloseInput(1))
+	require.NoError(t, c.CloseInput(1))
+
+	_, open := <-in.ch
+	require.False(t, open)
+}
+
+func TestCommander_StopCommand(t *testing.T) {
+	type fields struct {
+		Storager  *mocks.MockStorager
+		Executor  *mocks.MockExecutor
+		log       *logs.CustomLog
+		stopChans *sync.Map
+		queue     *jobQueue
+	}
+	type args struct {
+		ctx    context.Context
+		id     int64
+		signal string
+	}
+	tests := []struct {
+		name    string
+		fields  fields
+		args    args
+		want    int64
+		wantErr bool
+		prepare func(args2 args, fields *fields)
+	}{
+		{
+			name: "test_1, no error",
+			args: args{
+				ctx: context.Background(),
+				id:  1,
+			},
+			want: 1,
+			prepare: func(args2 args, fields *fields) {
+				ch := make(chan syscall.Signal)
+
+				go func() {
+					<-ch
+					close(ch)
+				}()
+
+				fields.stopChans.Store(int64(1), ch)
+
+				fields.Storager.EXPECT().StopOne(
+					context.Background(),
+					int64(1)).Return(int64(1), nil)
+			},
+		},
+		{
+			name: "test_2, with error",
+			args: args{
+				ctx: context.Background(),
+				id:  10,
+			},
+			want:    0,
+			wantErr: true,
+			prepare: func(args2 args, fields *fields) {},
+		},
+		{
+			name: "test_3, with db error",
+			args: args{
+				ctx:    context.Background(),
+				id:     1,
+				signal: "SIGKILL",
+			},
+			want: 0,
+			prepare: func(args2 args, fields *fields) {
+				ch := make(chan syscall.Signal)
+
+				go func() {
+					<-ch
+					close(ch)
+				}()
+
+				fields.stopChans.Store(int64(1), ch)
+
+				fields.Storager.EXPECT().StopOne(
+					context.Background(),
+					int64(1)).Return(int64(0), errors.New("db error"))
+			},
+		},
+		{
+			name: "test_4, with unknown signal",
+			args: args{
+				ctx:    context.Background(),
+				id:     1,
+				signal: "SIGFOO",
+			},
+			want:    0,
+			wantErr: true,
+			prepare: func(args2 args, fields *fields) {
+				fields.stopChans.Store(int64(1), make(chan syscall.Signal))
+			},
+		},
+		{
+			name: "test_5, queued command",
+			args: args{
+				ctx: context.Background(),
+				id:  3,
+			},
+			want: 3,
+			prepare: func(args2 args, fields *fields) {
+				ch := make(chan syscall.Signal)
+
+				fields.stopChans.Store(int64(3), ch)
+				fields.queue.push(job{id: 3, stop: ch})
+
+				fields.Storager.EXPECT().StopOne(
+					context.Background(),
+					int64(3)).Return(int64(3), nil)
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			dlog := logs.NewDiscardLogger()
+
+			ctrl := gomock.NewController(t)
+
+			f := fields{
+				Storager:  mocks.NewMockStorager(ctrl),
+				Executor:  mocks.NewMockExecutor(ctrl),
+				log:       dlog,
+				stopChans: &sync.Map{},
+				queue:     newJobQueue(0),
+			}
+
+			tt.prepare(tt.args, &f)
+
+			c := &Commander{
+				cmdStorage: f.Storager,
+				exec:       f.Executor,
+				log:        f.log,
+				stopChans:  f.stopChans,
+				queue:      f.queue,
+				events:     newBroker(),
+			}
+
+			got, err := c.StopCommand(tt.args.ctx, tt.args.id, tt.args.signal)
+
+			if tt.wantErr {
 				require.Error(t, err)
 				return
 			}
@@ -497,40 +959,1684 @@ func TestCommander_StopAllRunningScripts(t *testing.T) {
 			if err != nil && !tt.wantErr {
 				t.Errorf("Unexpected error: %s", err.Error())
 			}
+
+			require.Equal(t, tt.want, got)
 		})
 	}
 }
 
-func TestScriptName(t *testing.T) {
+func TestCommander_StopAllRunningScripts(t *testing.T) {
+	type fields struct {
+		Storager  *mocks.MockStorager
+		Executor  *mocks.MockExecutor
+		log       *logs.CustomLog
+		stopChans *sync.Map
+		queue     *jobQueue
+	}
+	type args struct {
+		ctx context.Context
+	}
 	tests := []struct {
-		name  string
-		input string
-		want  string
+		name    string
+		fields  fields
+		args    args
+		wantErr bool
+		prepare func(args2 args, fields *fields)
 	}{
 		{
-			name:  "test_1, empty script",
-			input: "",
-			want:  "",
+			name: "test_1, no error",
+			args: args{
+				ctx: context.Background(),
+			},
+			prepare: func(args2 args, fields *fields) {
+				ch := make(chan syscall.Signal)
+
+				go func() {
+					<-ch
+					close(ch)
+				}()
+
+				ch2 := make(chan syscall.Signal)
+
+				go func() {
+					<-ch2
+					close(ch2)
+				}()
+
+				fields.stopChans.Store(int64(1), ch)
+				fields.stopChans.Store(int64(2), ch2)
+
+				fields.Storager.EXPECT().StopOne(
+					context.Background(),
+					int64(1)).Return(int64(1), nil)
+
+				fields.Storager.EXPECT().StopOne(
+					context.Background(),
+					int64(2)).Return(int64(2), nil)
+			},
 		},
 		{
-			name:  "test_2, short script",
-			input: "ls",
-			want:  "ls",
+			name: "test_2, no error empty line",
+			args: args{
+				ctx: context.Background(),
+			},
+			prepare: func(args2 args, fields *fields) {},
 		},
 		{
-			name:  "test_2, long script",
-			input: "echo \"Hello, World! I'm a very long script.\"",
-			want:  "echo \"Hello, World! I'm a v...",
+			name: "test_3, with db error",
+			args: args{
+				ctx: context.Background(),
+			},
+			wantErr: true,
+			prepare: func(args2 args, fields *fields) {
+				ch := make(chan syscall.Signal)
+
+				go func() {
+					<-ch
+					close(ch)
+				}()
+
+				fields.stopChans.Store(int64(1), ch)
+
+				fields.Storager.EXPECT().StopOne(
+					context.Background(),
+					int64(1)).Return(int64(0), errors.New("db error"))
+			},
 		},
-	}
+		{
+			name: "test_4, queued command stays queued",
+			args: args{
+				ctx: context.Background(),
+			},
+			prepare: func(args2 args, fields *fields) {
+				ch := make(chan syscall.Signal)
 
+				fields.stopChans.Store(int64(1), ch)
+				fields.queue.push(job{id: 1, stop: ch})
+			},
+		},
+	}
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
-			got := scriptName(tt.input)
+			dlog := logs.NewDiscardLogger()
 
-			if got != tt.want {
-				t.Errorf("Expected: %q, got %q\n", tt.want, got)
+			ctrl := gomock.NewController(t)
+
+			f := fields{
+				Storager:  mocks.NewMockStorager(ctrl),
+				Executor:  mocks.NewMockExecutor(ctrl),
+				log:       dlog,
+				stopChans: &sync.Map{},
+				queue:     newJobQueue(0),
+			}
+
+			tt.prepare(tt.args, &f)
+
+			c := &Commander{
+				cmdStorage: f.Storager,
+				exec:       f.Executor,
+				log:        f.log,
+				stopChans:  f.stopChans,
+				queue:      f.queue,
+				events:     newBroker(),
+			}
+
+			err := c.StopAllRunningScripts(tt.args.ctx)
+
+			if tt.wantErr {
+				require.Error(t, err)
+				return
+			}
+
+			if err != nil && !tt.wantErr {
+				t.Errorf("Unexpected error: %s", err.Error())
 			}
 		})
 	}
 }
+
+func TestCommander_StopAllRunningScriptsKilled(t *testing.T) {
+	st := mocks.NewStorager(t)
+
+	c := &Commander{
+		cmdStorage: st,
+		cfg:        &config.Commander{OutputBatch: config.OutputBatch{Size: 100, Interval: time.Hour}},
+		log:        logs.NewDiscardLogger(),
+		stopChans:  &sync.Map{},
+		inputs:     &sync.Map{},
+		queue:      newJobQueue(1),
+		events:     newBroker(),
+	}
+	c.queue.running = 1
+	c.running.Add(1)
+
+	var saved []string
+	var result models.Result
+
+	st.On("StopOne", mock.Anything, int64(1)).Return(int64(1), nil)
+	st.On("SaveOutputs", mock.Anything, int64(1), mock.Anything).Run(func(args mock.Arguments) {
+		for _, output := range args.Get(2).([]models.Output) {
+			saved = append(saved, output.Text)
+		}
+	}).Return(nil)
+	st.On("IndexOutput", mock.Anything, int64(1)).Return(nil)
+	st.On("FinishOne", mock.Anything, int64(1), mock.Anything).Run(func(args mock.Arguments) {
+		result = args.Get(2).(models.Result)
+	}).Return(int64(1), nil)
+
+	stopCh := make(chan syscall.Signal)
+	c.stopChans.Store(int64(1), stopCh)
+	c.finished.Store(int64(1), make(chan struct{}))
+
+	resCh, errCh := make(chan models.Output), make(chan error)
+
+	// The script ignores SIGTERM and takes a while to exit after SIGKILL.
+	go func() {
+		<-stopCh
+		resCh <- models.Output{Stream: models.StreamStdout, Text: "ignored"}
+
+		for sig := range stopCh {
+			if sig == syscall.SIGKILL {
+				break
+			}
+		}
+
+		time.Sleep(50 * time.Millisecond)
+
+		close(resCh)
+		errCh <- services.ErrStoppedManually
+		close(errCh)
+	}()
+
+	go c.saveOutput(job{id: 1, stop: stopCh}, resCh, errCh)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 20*time.Millisecond)
+	defer cancel()
+
+	require.NoError(t, c.StopAllRunningScripts(ctx))
+
+	require.Equal(t, []string{"ignored", "Execution was interrupted"}, saved)
+	require.Equal(t, models.StatusStopped, result.Status)
+}
+
+func TestScriptName(t *testing.T) {
+	tests := []struct {
+		name  string
+		input string
+		want  string
+	}{
+		{
+			name:  "test_1, empty script",
+			input: "",
+			want:  "",
+		},
+		{
+			name:  "test_2, short script",
+			input: "ls",
+			want:  "ls",
+		},
+		{
+			name:  "test_2, long script",
+			input: "echo \"Hello, World! I'm a very long script.\"",
+			want:  "echo \"Hello, World! I'm a v...",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := scriptName(tt.input)
+
+			if got != tt.want {
+				t.Errorf("Expected: %q, got %q\n", tt.want, got)
+			}
+		})
+	}
+}
+
+func TestExecutionResult(t *testing.T) {
+	exitCode := 2
+
+	tests := []struct {
+		name  string
+		input error
+		want  models.Result
+	}{
+		{
+			name:  "test_1, non-zero exit code",
+			input: &services.ExitError{Code: 2},
+			want:  models.Result{Status: models.StatusFailed, ExitCode: &exitCode},
+		},
+		{
+			name:  "test_2, stopped manually",
+			input: &services.ExitError{Code: -1, Signal: "SIGKILL", Err: services.ErrStoppedManually},
+			want:  models.Result{Status: models.StatusStopped, Signal: "SIGKILL"},
+		},
+		{
+			name:  "test_3, can't start script",
+			input: errors.New("can't start script"),
+			want:  models.Result{Status: models.StatusFailed},
+		},
+		{
+			name:  "test_4, limit exceeded",
+			input: &services.ExitError{Code: -1, Signal: "SIGXCPU", Limit: models.LimitCPUTime},
+			want:  models.Result{Status: models.StatusLimitExceeded, Signal: "SIGXCPU", Limit: models.LimitCPUTime},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := executionResult(tt.input)
+
+			require.Equal(t, tt.want, got)
+		})
+	}
+}
+
+func TestScriptHash(t *testing.T) {
+	tests := []struct {
+		name  string
+		input string
+		want  string
+	}{
+		{
+			name:  "test_1, empty script",
+			input: "",
+			want:  "e3b0c44298fc1c149afbf4c8996fb92427ae41e4649b934ca495991b7852b855",
+		},
+		{
+			name:  "test_2, short script",
+			input: "ls",
+			want:  "c7b68ac37f364473e922936708e7f43c293dd07b295171566c07ff5fe024fab9",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := scriptHash(tt.input)
+
+			if got != tt.want {
+				t.Errorf("Expected: %q, got %q\n", tt.want, got)
+			}
+		})
+	}
+}
+
+func TestCommander_scriptTimeout(t *testing.T) {
+	c := &Commander{cfg: &config.Commander{DefaultTimeout: time.Minute, MaxTimeout: time.Hour}}
+
+	tests := []struct {
+		name    string
+		input   time.Duration
+		want    time.Duration
+		wantErr error
+	}{
+		{
+			name:  "test_1, default timeout",
+			input: 0,
+			want:  time.Minute,
+		},
+		{
+			name:  "test_2, custom timeout",
+			input: 30 * time.Second,
+			want:  30 * time.Second,
+		},
+		{
+			name:    "test_3, negative timeout",
+			input:   -time.Second,
+			wantErr: services.ErrInvalidOptions,
+		},
+		{
+			name:    "test_4, timeout more than maximum",
+			input:   time.Hour + time.Second,
+			wantErr: services.ErrInvalidOptions,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := c.scriptTimeout(tt.input)
+
+			if tt.wantErr != nil {
+				require.ErrorIs(t, err, tt.wantErr)
+				return
+			}
+
+			require.NoError(t, err)
+			require.Equal(t, tt.want, got)
+		})
+	}
+}
+
+func TestCommander_StopCommandEnded(t *testing.T) {
+	c := &Commander{
+		log:       logs.NewDiscardLogger(),
+		stopChans: &sync.Map{},
+		queue:     newJobQueue(0),
+	}
+
+	// The executor has ended and doesn't read signals, but the command isn't removed yet.
+	c.stopChans.Store(int64(1), make(chan syscall.Signal))
+	c.finished.Store(int64(1), make(chan struct{}))
+
+	go c.finish(1)
+
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+	defer cancel()
+
+	_, err := c.StopCommand(ctx, 1, "")
+	require.ErrorIs(t, err, services.ErrNoExecutingCommand)
+	require.NoError(t, ctx.Err())
+}
+
+func TestStopSignal(t *testing.T) {
+	tests := []struct {
+		name    string
+		input   string
+		want    syscall.Signal
+		wantErr error
+	}{
+		{
+			name:  "test_1, default signal",
+			input: "",
+			want:  syscall.SIGTERM,
+		},
+		{
+			name:  "test_2, full name",
+			input: "SIGKILL",
+			want:  syscall.SIGKILL,
+		},
+		{
+			name:  "test_3, short name in lower case",
+			input: "int",
+			want:  syscall.SIGINT,
+		},
+		{
+			name:    "test_4, unknown signal",
+			input:   "SIGFOO",
+			wantErr: services.ErrInvalidOptions,
+		},
+		{
+			name:    "test_5, signal which doesn't stop",
+			input:   "SIGSTOP",
+			wantErr: services.ErrInvalidOptions,
+		},
+		{
+			name:    "test_6, signal which is ignored by default",
+			input:   "chld",
+			wantErr: services.ErrInvalidOptions,
+		},
+		{
+			name:  "test_7, hangup",
+			input: "SIGHUP",
+			want:  syscall.SIGHUP,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := stopSignal(tt.input)
+
+			if tt.wantErr != nil {
+				require.ErrorIs(t, err, tt.wantErr)
+				return
+			}
+
+			require.NoError(t, err)
+			require.Equal(t, tt.want, got)
+		})
+	}
+}
+
+func TestCommander_scriptLimits(t *testing.T) {
+	c := &Commander{cfg: &config.Commander{
+		DefaultLimits: config.Limits{CPUTime: time.Minute, MemoryMB: 512},
+		MaxLimits:     config.Limits{CPUTime: time.Hour, MemoryMB: 1024, Processes: 100},
+	}}
+
+	tests := []struct {
+		name    string
+		input   models.Limits
+		want    models.Limits
+		wantErr error
+	}{
+		{
+			name:  "test_1, default limits",
+			input: models.Limits{},
+			want:  models.Limits{CPUTime: time.Minute, MemoryMB: 512, Processes: 100},
+		},
+		{
+			name:  "test_2, custom limits",
+			input: models.Limits{CPUTime: time.Second, MemoryMB: 1024, OpenFiles: 10, Processes: 5},
+			want:  models.Limits{CPUTime: time.Second, MemoryMB: 1024, OpenFiles: 10, Processes: 5},
+		},
+		{
+			name:    "test_3, negative limit",
+			input:   models.Limits{OpenFiles: -1},
+			wantErr: services.ErrInvalidOptions,
+		},
+		{
+			name:    "test_4, limit more than maximum",
+			input:   models.Limits{MemoryMB: 2048},
+			wantErr: services.ErrInvalidOptions,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := c.scriptLimits(tt.input)
+
+			if tt.wantErr != nil {
+				require.ErrorIs(t, err, tt.wantErr)
+				return
+			}
+
+			require.NoError(t, err)
+			require.Equal(t, tt.want, got)
+		})
+	}
+}
+
+func TestCommander_checkCgroupLimits(t *testing.T) {
+	tests := []struct {
+		name    string
+		opts    models.Options
+		cgroup  bool
+		wantErr error
+	}{
+		{
+			name: "test_1, no limits of cgroup",
+			opts: models.Options{Limits: models.Limits{MemoryMB: 512, OpenFiles: 10}},
+		},
+		{
+			name:   "test_2, processes limit with cgroup",
+			opts:   models.Options{Limits: models.Limits{Processes: 5}},
+			cgroup: true,
+		},
+		{
+			name:    "test_3, processes limit without cgroup",
+			opts:    models.Options{Limits: models.Limits{Processes: 5}},
+			wantErr: services.ErrInvalidOptions,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			e := mocks.NewExecutor(t)
+			e.On("HasCgroup").Return(tt.cgroup).Maybe()
+
+			c := &Commander{exec: e, cfg: &config.Commander{}}
+
+			err := c.checkCgroupLimits(tt.opts)
+
+			if tt.wantErr != nil {
+				require.ErrorIs(t, err, tt.wantErr)
+				return
+			}
+
+			require.NoError(t, err)
+		})
+	}
+}
+
+func TestJobQueue(t *testing.T) {
+	q := newJobQueue(2)
+
+	for id := int64(1); id <= 4; id++ {
+		q.push(job{id: id})
+	}
+
+	j, ok := q.next()
+	require.True(t, ok)
+	require.Equal(t, int64(1), j.id)
+
+	require.True(t, q.remove(2))
+	require.False(t, q.remove(2))
+
+	j, ok = q.next()
+	require.True(t, ok)
+	require.Equal(t, int64(3), j.id)
+
+	_, ok = q.next()
+	require.False(t, ok, "all slots are taken")
+
+	q.done()
+
+	j, ok = q.next()
+	require.True(t, ok)
+	require.Equal(t, int64(4), j.id)
+
+	q.push(job{id: 5})
+	q.done()
+	q.close()
+
+	_, ok = q.next()
+	require.False(t, ok, "queue is closed")
+	require.True(t, q.has(5))
+}
+
+func TestCommander_checkEnv(t *testing.T) {
+	tests := []struct {
+		name    string
+		cfg     config.Commander
+		input   map[string]string
+		wantErr bool
+	}{
+		{
+			name:  "test_1, no restrictions",
+			cfg:   config.Commander{},
+			input: map[string]string{"NAME": "value", "_X1": ""},
+		},
+		{
+			name:    "test_2, invalid name",
+			cfg:     config.Commander{},
+			input:   map[string]string{"1NAME": "value"},
+			wantErr: true,
+		},
+		{
+			name:    "test_3, denied name",
+			cfg:     config.Commander{DeniedEnv: []string{"LD_*"}},
+			input:   map[string]string{"LD_PRELOAD": "/tmp/lib.so"},
+			wantErr: true,
+		},
+		{
+			name:  "test_4, allowed name",
+			cfg:   config.Commander{AllowedEnv: []string{"APP_*"}},
+			input: map[string]string{"APP_MODE": "dev"},
+		},
+		{
+			name:    "test_5, name isn't allowed",
+			cfg:     config.Commander{AllowedEnv: []string{"APP_*"}},
+			input:   map[string]string{"HOME": "/"},
+			wantErr: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			c := &Commander{cfg: &tt.cfg}
+
+			err := c.checkEnv(tt.input)
+
+			if tt.wantErr {
+				require.ErrorIs(t, err, services.ErrInvalidOptions)
+				return
+			}
+
+			require.NoError(t, err)
+		})
+	}
+}
+
+func TestCommander_workingDir(t *testing.T) {
+	base := t.TempDir()
+	inside := filepath.Join(base, "project")
+	require.NoError(t, os.Mkdir(inside, 0o700))
+
+	outside := t.TempDir()
+	require.NoError(t, os.Symlink(outside, filepath.Join(base, "link")))
+
+	c := &Commander{cfg: &config.Commander{BaseDirs: []string{base}}}
+
+	tests := []struct {
+		name    string
+		input   string
+		want    string
+		wantErr bool
+	}{
+		{
+			name: "test_1, not set",
+		},
+		{
+			name:  "test_2, inside base directory",
+			input: inside + "/",
+			want:  inside,
+		},
+		{
+			name:    "test_3, relative path",
+			input:   "project",
+			wantErr: true,
+		},
+		{
+			name:    "test_4, outside base directories",
+			input:   outside,
+			wantErr: true,
+		},
+		{
+			name:    "test_5, escape by dots",
+			input:   inside + "/../..",
+			wantErr: true,
+		},
+		{
+			name:    "test_6, escape by symlink",
+			input:   filepath.Join(base, "link"),
+			wantErr: true,
+		},
+		{
+			name:    "test_7, doesn't exist",
+			input:   filepath.Join(base, "none"),
+			wantErr: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := c.workingDir(tt.input)
+
+			if tt.wantErr {
+				require.ErrorIs(t, err, services.ErrInvalidOptions)
+				return
+			}
+
+			require.NoError(t, err)
+			require.Equal(t, tt.want, got)
+		})
+	}
+}
+
+func TestWorkspaces(t *testing.T) {
+	root := filepath.Join(t.TempDir(), "workspaces")
+	w := newWorkspaces(logs.NewDiscardLogger(), config.Workspace{Root: root})
+
+	dir, err := w.create(1)
+	require.NoError(t, err)
+	require.Equal(t, filepath.Join(root, "cmd-1"), dir)
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "file"), []byte("data"), 0o600))
+
+	dir, err = w.create(1)
+	require.NoError(t, err)
+
+	entries, err := os.ReadDir(dir)
+	require.NoError(t, err)
+	require.Empty(t, entries)
+
+	w.release(dir)
+	require.NoDirExists(t, dir)
+
+	_, err = w.create(2)
+	require.NoError(t, err)
+	require.NoError(t, os.Mkdir(filepath.Join(root, "other"), 0o700))
+
+	w.retention = time.Hour
+	require.NoError(t, w.prune())
+	require.DirExists(t, w.path(2))
+
+	w.retention = 0
+	require.NoError(t, w.prune())
+	require.NoDirExists(t, w.path(2))
+	require.DirExists(t, filepath.Join(root, "other"))
+}
+
+func TestCommander_invocation(t *testing.T) {
+	c := &Commander{cfg: &config.Commander{Interpreters: map[string][]string{
+		"python3": {"/usr/bin/env", "python3", "-c", "{script}"},
+	}, DefaultInterpreter: "python3"}}
+
+	name, err := c.scriptInterpreter("")
+	require.NoError(t, err)
+	require.Equal(t, "python3", name)
+
+	_, err = c.scriptInterpreter("bash")
+	require.ErrorIs(t, err, services.ErrInvalidOptions)
+
+	got, err := c.invocation(models.Script{Body: "print(1)", Options: models.Options{Interpreter: name}})
+	require.NoError(t, err)
+	require.Equal(t, []string{"/usr/bin/env", "python3", "-c", "print(1)"}, got)
+
+	c.cfg = &config.Commander{}
+
+	name, err = c.scriptInterpreter("")
+	require.NoError(t, err)
+	require.Equal(t, "bash", name)
+
+	_, err = c.invocation(models.Script{Body: "ls", Options: models.Options{Interpreter: "python3"}})
+	require.Error(t, err)
+}
+
+func TestCommander_checkTemplate(t *testing.T) {
+	c := &Commander{cfg: &config.Commander{DeniedEnv: []string{"LD_*"}}}
+
+	tests := []struct {
+		name    string
+		tmpl    models.Template
+		wantErr error
+	}{
+		{
+			name: "test_1, invalid default value",
+			tmpl: models.Template{Name: "deploy", Script: "echo $TARGET $COUNT", Params: []models.Param{
+				{Name: "TARGET", Type: models.ParamEnum, Values: []string{"dev", "prod"}, Default: "dev"},
+				{Name: "COUNT", Type: models.ParamInt, Required: true},
+				{Name: "DRY_RUN", Type: models.ParamBool, Default: "yes"},
+			}},
+			wantErr: services.ErrInvalidParams,
+		},
+		{
+			name: "test_2, valid params",
+			tmpl: models.Template{Name: "deploy", Script: "echo $TARGET", Params: []models.Param{
+				{Name: "TARGET", Type: models.ParamEnum, Values: []string{"dev", "prod"}, Default: "dev"},
+				{Name: "DRY_RUN", Type: models.ParamBool, Default: "true"},
+			}},
+		},
+		{
+			name:    "test_3, empty name",
+			tmpl:    models.Template{Script: "ls"},
+			wantErr: services.ErrInvalidOptions,
+		},
+		{
+			name:    "test_4, empty script",
+			tmpl:    models.Template{Name: "ls"},
+			wantErr: services.ErrInvalidOptions,
+		},
+		{
+			name: "test_5, denied param name",
+			tmpl: models.Template{Name: "ls", Script: "ls", Params: []models.Param{
+				{Name: "LD_PRELOAD", Type: models.ParamString},
+			}},
+			wantErr: services.ErrInvalidParams,
+		},
+		{
+			name: "test_6, enum without values",
+			tmpl: models.Template{Name: "ls", Script: "ls", Params: []models.Param{
+				{Name: "MODE", Type: models.ParamEnum},
+			}},
+			wantErr: services.ErrInvalidParams,
+		},
+		{
+			name: "test_7, declared twice",
+			tmpl: models.Template{Name: "ls", Script: "ls", Params: []models.Param{
+				{Name: "MODE", Type: models.ParamString},
+				{Name: "MODE", Type: models.ParamInt},
+			}},
+			wantErr: services.ErrInvalidParams,
+		},
+		{
+			name: "test_8, conflicts with env",
+			tmpl: models.Template{Name: "ls", Script: "ls", Params: []models.Param{
+				{Name: "MODE", Type: models.ParamString},
+			}, Options: models.Options{Env: map[string]string{"MODE": "fast"}}},
+			wantErr: services.ErrInvalidParams,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := c.checkTemplate(tt.tmpl)
+
+			if tt.wantErr != nil {
+				require.ErrorIs(t, err, tt.wantErr)
+				return
+			}
+
+			require.NoError(t, err)
+		})
+	}
+}
+
+func TestParamsEnv(t *testing.T) {
+	declared := []models.Param{
+		{Name: "TARGET", Type: models.ParamEnum, Values: []string{"dev", "prod"}, Default: "dev"},
+		{Name: "COUNT", Type: models.ParamInt, Required: true},
+		{Name: "DRY_RUN", Type: models.ParamBool},
+		{Name: "MESSAGE", Type: models.ParamString},
+	}
+
+	tests := []struct {
+		name    string
+		params  map[string]any
+		want    map[string]string
+		wantErr bool
+	}{
+		{
+			name:   "test_1, defaults",
+			params: map[string]any{"COUNT": json.Number("3")},
+			want:   map[string]string{"TARGET": "dev", "COUNT": "3"},
+		},
+		{
+			name:   "test_2, all params",
+			params: map[string]any{"TARGET": "prod", "COUNT": float64(2), "DRY_RUN": "1", "MESSAGE": "$(rm -rf /)"},
+			want:   map[string]string{"TARGET": "prod", "COUNT": "2", "DRY_RUN": "true", "MESSAGE": "$(rm -rf /)"},
+		},
+		{
+			name:    "test_3, missing required",
+			params:  map[string]any{},
+			wantErr: true,
+		},
+		{
+			name:    "test_4, not int",
+			params:  map[string]any{"COUNT": 2.5},
+			wantErr: true,
+		},
+		{
+			name:    "test_5, not in enum",
+			params:  map[string]any{"COUNT": "1", "TARGET": "stage"},
+			wantErr: true,
+		},
+		{
+			name:    "test_6, unknown param",
+			params:  map[string]any{"COUNT": "1", "OTHER": "x"},
+			wantErr: true,
+		},
+		{
+			name:    "test_7, not bool",
+			params:  map[string]any{"COUNT": "1", "DRY_RUN": "maybe"},
+			wantErr: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := paramsEnv(declared, tt.params)
+
+			if tt.wantErr {
+				require.ErrorIs(t, err, services.ErrInvalidParams)
+				return
+			}
+
+			require.NoError(t, err)
+			require.Equal(t, tt.want, got)
+		})
+	}
+}
+
+func TestCommander_RunTemplate(t *testing.T) {
+	st := mocks.NewStorager(t)
+
+	c := &Commander{
+		cmdStorage: st,
+		cfg:        &config.Commander{},
+		log:        logs.NewDiscardLogger(),
+		stopChans:  &sync.Map{},
+		inputs:     &sync.Map{},
+		queue:      newJobQueue(1),
+		events:     newBroker(),
+	}
+	c.queue.running = 1
+
+	st.On("GetTemplate", mock.Anything, int64(1)).Return(&models.Template{
+		ID:      1,
+		Name:    "greet",
+		Script:  "echo $GREETING $NAME",
+		Params:  []models.Param{{Name: "NAME", Type: models.ParamString, Required: true}},
+		Options: models.Options{Env: map[string]string{"GREETING": "hello"}},
+	}, nil)
+	st.On("GetTemplate", mock.Anything, int64(2)).Return(nil, services.ErrTemplateNotFound)
+	st.On("CreateNew", mock.Anything, mock.MatchedBy(func(cmd models.Command) bool {
+		return cmd.TemplateID == 1 && cmd.Script == "echo $GREETING $NAME" &&
+			cmd.Options.Env["NAME"] == "world" && cmd.Options.Env["GREETING"] == "hello"
+	})).Return(int64(5), nil)
+
+	id, err := c.RunTemplate(context.Background(), 1, map[string]any{"NAME": "world"}, nil)
+	require.NoError(t, err)
+	require.Equal(t, int64(5), id)
+
+	_, err = c.RunTemplate(context.Background(), 1, map[string]any{}, nil)
+	require.ErrorIs(t, err, services.ErrInvalidParams)
+
+	_, err = c.RunTemplate(context.Background(), 2, nil, nil)
+	require.ErrorIs(t, err, services.ErrTemplateNotFound)
+}
+
+func TestCommander_checkSchedule(t *testing.T) {
+	st := mocks.NewStorager(t)
+
+	c := &Commander{cmdStorage: st, cfg: &config.Commander{}}
+
+	st.On("GetTemplate", mock.Anything, int64(1)).Return(&models.Template{
+		ID:     1,
+		Name:   "greet",
+		Script: "echo $NAME",
+		Params: []models.Param{{Name: "NAME", Type: models.ParamString, Required: true}},
+	}, nil)
+	st.On("GetTemplate", mock.Anything, int64(2)).Return(nil, services.ErrTemplateNotFound)
+
+	tests := []struct {
+		name    string
+		sch     models.Schedule
+		wantErr error
+	}{
+		{
+			name: "test_1, script",
+			sch:  models.Schedule{Name: "ls", Spec: "*/5 * * * *", Script: "ls"},
+		},
+		{
+			name: "test_2, template with params",
+			sch:  models.Schedule{Name: "greet", Spec: "@hourly", TemplateID: 1, Params: map[string]any{"NAME": "world"}},
+		},
+		{
+			name:    "test_3, invalid spec",
+			sch:     models.Schedule{Name: "ls", Spec: "* * *", Script: "ls"},
+			wantErr: services.ErrInvalidSchedule,
+		},
+		{
+			name:    "test_4, both script and template",
+			sch:     models.Schedule{Name: "ls", Spec: "@daily", Script: "ls", TemplateID: 1},
+			wantErr: services.ErrInvalidSchedule,
+		},
+		{
+			name:    "test_5, missing required param",
+			sch:     models.Schedule{Name: "greet", Spec: "@daily", TemplateID: 1},
+			wantErr: services.ErrInvalidParams,
+		},
+		{
+			name:    "test_6, unknown template",
+			sch:     models.Schedule{Name: "greet", Spec: "@daily", TemplateID: 2},
+			wantErr: services.ErrTemplateNotFound,
+		},
+		{
+			name: "test_7, options with template",
+			sch: models.Schedule{Name: "greet", Spec: "@daily", TemplateID: 1, Params: map[string]any{"NAME": "world"},
+				Options: models.Options{Timeout: time.Minute}},
+			wantErr: services.ErrInvalidSchedule,
+		},
+		{
+			name:    "test_8, empty name",
+			sch:     models.Schedule{Spec: "@daily", Script: "ls"},
+			wantErr: services.ErrInvalidSchedule,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := c.checkSchedule(context.Background(), tt.sch)
+
+			if tt.wantErr != nil {
+				require.ErrorIs(t, err, tt.wantErr)
+				return
+			}
+
+			require.NoError(t, err)
+		})
+	}
+}
+
+func TestCommander_NextRuns(t *testing.T) {
+	st := mocks.NewStorager(t)
+
+	c := &Commander{cmdStorage: st, schedules: newScheduler(time.UTC)}
+
+	st.On("GetSchedule", mock.Anything, int64(1)).Return(&models.Schedule{ID: 1, Spec: "0 * * * *"}, nil)
+	st.On("GetSchedule", mock.Anything, int64(2)).Return(nil, services.ErrScheduleNotFound)
+
+	runs, err := c.NextRuns(context.Background(), 1, 3)
+	require.NoError(t, err)
+	require.Len(t, runs, 3)
+
+	for i, run := range runs {
+		require.Zero(t, run.Minute())
+		require.True(t, run.After(time.Now()))
+
+		if i > 0 {
+			require.Equal(t, time.Hour, run.Sub(runs[i-1]))
+		}
+	}
+
+	_, err = c.NextRuns(context.Background(), 1, 0)
+	require.ErrorIs(t, err, services.ErrInvalidSchedule)
+
+	_, err = c.NextRuns(context.Background(), 2, 3)
+	require.ErrorIs(t, err, services.ErrScheduleNotFound)
+}
+
+func TestCommander_fire(t *testing.T) {
+	st := mocks.NewStorager(t)
+
+	c := &Commander{
+		cmdStorage: st,
+		cfg:        &config.Commander{},
+		log:        logs.NewDiscardLogger(),
+		stopChans:  &sync.Map{},
+		inputs:     &sync.Map{},
+		queue:      newJobQueue(1),
+		events:     newBroker(),
+		schedules:  newScheduler(time.UTC),
+	}
+	c.queue.running = 1
+
+	st.On("GetSchedule", mock.Anything, int64(1)).Return(&models.Schedule{
+		ID: 1, Spec: "@hourly", Script: "ls", NoOverlap: true, LastCommandID: 4,
+	}, nil)
+	st.On("GetSchedule", mock.Anything, int64(2)).Return(nil, services.ErrScheduleNotFound)
+	st.On("CreateNew", mock.Anything, mock.MatchedBy(func(cmd models.Command) bool {
+		return cmd.Script == "ls"
+	})).Return(int64(5), nil).Once()
+	st.On("SetLastCommand", mock.Anything, int64(1), int64(5)).Return(int64(1), nil).Once()
+
+	c.stopChans.Store(int64(4), make(chan syscall.Signal))
+	c.fire(1)
+	st.AssertNotCalled(t, "CreateNew", mock.Anything, mock.Anything)
+
+	c.stopChans.Delete(int64(4))
+	c.fire(1)
+
+	require.NoError(t, c.schedules.add(2, "@hourly", func() {}))
+	c.fire(2)
+	require.NotContains(t, c.schedules.entries, int64(2))
+}
+
+func TestCommander_StartScheduler(t *testing.T) {
+	st := mocks.NewStorager(t)
+
+	c := &Commander{
+		cmdStorage: st,
+		log:        logs.NewDiscardLogger(),
+		schedules:  newScheduler(time.UTC),
+	}
+
+	st.On("GetSchedules", mock.Anything).Return(nil, errors.New("some db error"))
+
+	require.Error(t, c.StartScheduler(context.Background()))
+	defer c.schedules.cron.Stop()
+
+	// Entries get their next run only in the running cron.
+	require.NoError(t, c.activate(models.Schedule{ID: 1, Spec: "@hourly"}))
+	require.False(t, c.schedules.cron.Entry(c.schedules.entries[1]).Next.IsZero())
+}
+
+func TestCommander_DeleteSchedule(t *testing.T) {
+	st := mocks.NewStorager(t)
+
+	c := &Commander{
+		cmdStorage: st,
+		log:        logs.NewDiscardLogger(),
+		schedules:  newScheduler(time.UTC),
+	}
+
+	require.NoError(t, c.schedules.add(1, "@hourly", func() {}))
+
+	st.On("DeleteSchedule", mock.Anything, int64(1)).Return(int64(0), errors.New("some db error")).Once()
+
+	require.Error(t, c.DeleteSchedule(context.Background(), 1))
+	require.Contains(t, c.schedules.entries, int64(1))
+
+	st.On("DeleteSchedule", mock.Anything, int64(1)).Return(int64(1), nil).Once()
+
+	require.NoError(t, c.DeleteSchedule(context.Background(), 1))
+	require.NotContains(t, c.schedules.entries, int64(1))
+}
+
+func TestCommander_RerunCommand(t *testing.T) {
+	st := mocks.NewStorager(t)
+
+	c := &Commander{
+		cmdStorage: st,
+		cfg:        &config.Commander{},
+		log:        logs.NewDiscardLogger(),
+		stopChans:  &sync.Map{},
+		inputs:     &sync.Map{},
+		queue:      newJobQueue(1),
+		events:     newBroker(),
+	}
+	c.queue.running = 1
+
+	st.On("GetOne", mock.Anything, int64(1)).Return(&models.Command{
+		ID:         1,
+		Script:     "echo $NAME $1",
+		TemplateID: 3,
+		Options: &models.Options{
+			Timeout:     time.Minute,
+			Env:         map[string]string{"NAME": "world"},
+			Args:        []string{"first"},
+			Interpreter: "bash",
+		},
+	}, nil)
+	st.On("GetOne", mock.Anything, int64(2)).Return(&models.Command{ID: 2}, nil)
+	st.On("GetOne", mock.Anything, int64(3)).Return(nil, services.ErrCommandNotFound)
+	st.On("CreateNew", mock.Anything, mock.MatchedBy(func(cmd models.Command) bool {
+		return cmd.ParentID == 1 && cmd.TemplateID == 3 && cmd.Script == "echo $NAME $1" &&
+			cmd.Options.Timeout == time.Minute && cmd.Options.Env["NAME"] == "world" && cmd.Options.Args[0] == "first"
+	})).Return(int64(4), nil)
+
+	id, err := c.RerunCommand(context.Background(), 1, map[string]string{"TOKEN": "secret"})
+	require.NoError(t, err)
+	require.Equal(t, int64(4), id)
+
+	_, err = c.RerunCommand(context.Background(), 2, nil)
+	require.ErrorIs(t, err, services.ErrInvalidOptions)
+
+	_, err = c.RerunCommand(context.Background(), 3, nil)
+	require.ErrorIs(t, err, services.ErrCommandNotFound)
+}
+
+func TestCommander_GetCommandOutput(t *testing.T) {
+	st := mocks.NewStorager(t)
+
+	c := &Commander{cmdStorage: st}
+
+	lines := []models.Output{{Line: 9, Stream: models.StreamStdout, Text: "b"}, {Line: 10, Stream: models.StreamStdout, Text: "c"}}
+
+	st.On("GetOutput", mock.Anything, int64(1), models.OutputRange{Tail: 2, Limit: defaultOutputLimit}).Return(lines, int64(10), nil)
+	st.On("GetOutput", mock.Anything, int64(1), models.OutputRange{Since: 9, Limit: 5}).Return(lines, int64(10), nil)
+
+	tests := []struct {
+		name     string
+		rng      models.OutputRange
+		want     []models.Output
+		wantLast int64
+		wantErr  error
+	}{
+		{
+			name:     "test_1, tail",
+			rng:      models.OutputRange{Tail: 2},
+			want:     lines,
+			wantLast: 10,
+		},
+		{
+			name:     "test_2, since",
+			rng:      models.OutputRange{Since: 9, Limit: 5},
+			want:     lines,
+			wantLast: 10,
+		},
+		{
+			name:    "test_3, tail with offset",
+			rng:     models.OutputRange{Tail: 2, Offset: 3},
+			wantErr: services.ErrInvalidOptions,
+		},
+		{
+			name:    "test_4, negative",
+			rng:     models.OutputRange{Since: -1},
+			wantErr: services.ErrInvalidOptions,
+		},
+		{
+			name:    "test_5, too many lines",
+			rng:     models.OutputRange{Limit: maxOutputLimit + 1},
+			wantErr: services.ErrInvalidOptions,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, last, err := c.GetCommandOutput(context.Background(), 1, tt.rng)
+
+			if tt.wantErr != nil {
+				require.ErrorIs(t, err, tt.wantErr)
+				return
+			}
+
+			require.NoError(t, err)
+			require.Equal(t, tt.want, got)
+			require.Equal(t, tt.wantLast, last)
+		})
+	}
+}
+
+func TestCommander_EachCommandOutput(t *testing.T) {
+	st := mocks.NewStorager(t)
+
+	c := &Commander{cmdStorage: st}
+
+	writeErr := errors.New("broken pipe")
+
+	st.On("EachOutput", mock.Anything, int64(1), mock.Anything).Run(func(args mock.Arguments) {
+		each := args.Get(2).(func(models.Output) error)
+		require.ErrorIs(t, each(models.Output{Line: 1, Text: "a"}), writeErr)
+	}).Return(writeErr)
+	st.On("EachOutput", mock.Anything, int64(42), mock.Anything).Return(services.ErrCommandNotFound)
+
+	var got []int64
+
+	err := c.EachCommandOutput(context.Background(), 1, func(output models.Output) error {
+		got = append(got, output.Line)
+		return writeErr
+	})
+	require.ErrorContains(t, err, "broken pipe")
+	require.Equal(t, []int64{1}, got)
+
+	err = c.EachCommandOutput(context.Background(), 42, func(models.Output) error { return nil })
+	require.ErrorIs(t, err, services.ErrCommandNotFound)
+}
+
+func TestCommander_saveOutputLines(t *testing.T) {
+	st := mocks.NewStorager(t)
+
+	c := &Commander{
+		cmdStorage: st,
+		cfg:        &config.Commander{OutputBatch: config.OutputBatch{Size: 2, Interval: time.Hour}},
+		log:        logs.NewDiscardLogger(),
+		stopChans:  &sync.Map{},
+		inputs:     &sync.Map{},
+		queue:      newJobQueue(1),
+		events:     newBroker(),
+	}
+	c.queue.running = 1
+	c.running.Add(1)
+
+	var got [][]int64
+
+	st.On("SaveOutputs", mock.Anything, int64(1), mock.Anything).Run(func(args mock.Arguments) {
+		var lines []int64
+		for _, output := range args.Get(2).([]models.Output) {
+			require.NotNil(t, output.At)
+			lines = append(lines, output.Line)
+		}
+
+		got = append(got, lines)
+	}).Return(nil)
+	st.On("IndexOutput", mock.Anything, int64(1)).Return(nil)
+	st.On("FinishOne", mock.Anything, int64(1), mock.Anything).Return(int64(1), nil)
+
+	resCh, errCh := make(chan models.Output), make(chan error)
+
+	go func() {
+		resCh <- models.Output{Stream: models.StreamStdout, Text: "a"}
+		resCh <- models.Output{Stream: models.StreamStdout, Text: "b"}
+		close(resCh)
+
+		errCh <- errors.New("exit status 1")
+		close(errCh)
+	}()
+
+	c.saveOutput(job{id: 1}, resCh, errCh)
+	c.running.Wait()
+
+	require.Equal(t, [][]int64{{1, 2}, {3}}, got)
+}
+
+func TestCommander_saveOutputTimeout(t *testing.T) {
+	st := mocks.NewStorager(t)
+
+	c := &Commander{
+		cmdStorage: st,
+		cfg:        &config.Commander{},
+		log:        logs.NewDiscardLogger(),
+		stopChans:  &sync.Map{},
+		inputs:     &sync.Map{},
+		queue:      newJobQueue(1),
+		events:     newBroker(),
+	}
+	c.queue.running = 1
+	c.running.Add(1)
+
+	var result models.Result
+	var notes []string
+
+	st.On("SaveOutputs", mock.Anything, int64(1), mock.Anything).Run(func(args mock.Arguments) {
+		for _, output := range args.Get(2).([]models.Output) {
+			notes = append(notes, output.Text)
+		}
+	}).Return(nil)
+	st.On("IndexOutput", mock.Anything, int64(1)).Return(nil)
+	st.On("FinishOne", mock.Anything, int64(1), mock.Anything).Run(func(args mock.Arguments) {
+		result = args.Get(2).(models.Result)
+	}).Return(int64(1), nil)
+
+	stopCh := make(chan syscall.Signal)
+	resCh, errCh := make(chan models.Output), make(chan error)
+
+	var sig syscall.Signal
+
+	// The executor doesn't take signals when the timeout elapses, the signal must wait for it.
+	go func() {
+		time.Sleep(50 * time.Millisecond)
+
+		select {
+		case sig = <-stopCh:
+			close(resCh)
+			errCh <- services.ErrStoppedManually
+		case <-time.After(time.Second):
+			close(resCh)
+			errCh <- &services.ExitError{Code: 0}
+		}
+
+		close(errCh)
+	}()
+
+	j := job{id: 1, stop: stopCh, script: models.Script{Options: models.Options{Timeout: 10 * time.Millisecond}}}
+
+	c.saveOutput(j, resCh, errCh)
+	c.running.Wait()
+
+	require.Equal(t, syscall.SIGTERM, sig)
+	require.Equal(t, models.StatusTimedOut, result.Status)
+	require.Equal(t, []string{"Execution timed out after 10ms"}, notes)
+}
+
+func Test_outputLimiter(t *testing.T) {
+	tests := []struct {
+		name          string
+		limits        config.OutputLimits
+		lines         []string
+		wantAdmitted  []string
+		wantTail      []string
+		wantTruncated bool
+	}{
+		{
+			name:         "test_1, no limits",
+			limits:       config.OutputLimits{Policy: policyDrop},
+			lines:        []string{"a", "b", "c"},
+			wantAdmitted: []string{"a", "b", "c"},
+		},
+		{
+			name:          "test_2, drop over lines",
+			limits:        config.OutputLimits{MaxLines: 2, Policy: policyDrop},
+			lines:         []string{"a", "b", "c", "d"},
+			wantAdmitted:  []string{"a", "b"},
+			wantTruncated: true,
+		},
+		{
+			name:          "test_3, stop over bytes",
+			limits:        config.OutputLimits{MaxBytes: 4, Policy: policyStop},
+			lines:         []string{"ab", "cd", "e", "f"},
+			wantAdmitted:  []string{"ab", "cd"},
+			wantTruncated: true,
+		},
+		{
+			name:          "test_4, head and tail",
+			limits:        config.OutputLimits{MaxLines: 4, Policy: policyHeadTail},
+			lines:         []string{"a", "b", "c", "d", "e", "f", "g"},
+			wantAdmitted:  []string{"a", "b"},
+			wantTail:      []string{"f", "g"},
+			wantTruncated: true,
+		},
+		{
+			name:          "test_5, head and tail over bytes",
+			limits:        config.OutputLimits{MaxBytes: 6, Policy: policyHeadTail},
+			lines:         []string{"ab", "c", "de", "fgh", "i", "jklm"},
+			wantAdmitted:  []string{"ab", "c"},
+			wantTail:      []string{},
+			wantTruncated: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			l := newOutputLimiter(tt.limits)
+
+			admitted := []string{}
+
+			for _, line := range tt.lines {
+				if l.admit(models.Output{Text: line}) {
+					admitted = append(admitted, line)
+				}
+			}
+
+			tail := []string{}
+
+			for _, output := range l.rest() {
+				tail = append(tail, output.Text)
+			}
+
+			require.Equal(t, tt.wantAdmitted, admitted)
+			require.Equal(t, tt.wantTruncated, l.truncated)
+
+			if tt.wantTail != nil {
+				require.Equal(t, tt.wantTail, tail)
+			}
+
+			require.Empty(t, l.rest())
+		})
+	}
+}
+
+func TestCommander_saveOutputLimits(t *testing.T) {
+	tests := []struct {
+		name       string
+		limits     config.OutputLimits
+		wantLines  []int64
+		wantResult models.Result
+		wantStop   bool
+	}{
+		{
+			name:       "test_1, head and tail",
+			limits:     config.OutputLimits{MaxLines: 2, Policy: policyHeadTail},
+			wantLines:  []int64{1, 3, 4},
+			wantResult: models.Result{Status: models.StatusFailed, ExitCode: new(int)},
+		},
+		{
+			name:       "test_2, stop",
+			limits:     config.OutputLimits{MaxLines: 1, Policy: policyStop},
+			wantLines:  []int64{1, 4},
+			wantResult: models.Result{Status: models.StatusLimitExceeded, Limit: models.LimitOutput},
+			wantStop:   true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			st := mocks.NewStorager(t)
+
+			c := &Commander{
+				cmdStorage: st,
+				cfg:        &config.Commander{OutputLimits: tt.limits},
+				log:        logs.NewDiscardLogger(),
+				stopChans:  &sync.Map{},
+				inputs:     &sync.Map{},
+				queue:      newJobQueue(1),
+				events:     newBroker(),
+			}
+			c.queue.running = 1
+			c.running.Add(1)
+
+			var got []int64
+			var result models.Result
+
+			st.On("SaveOutputs", mock.Anything, int64(1), mock.Anything).Run(func(args mock.Arguments) {
+				for _, output := range args.Get(2).([]models.Output) {
+					got = append(got, output.Line)
+				}
+			}).Return(nil)
+			st.On("TruncateOne", mock.Anything, int64(1)).Return(int64(1), nil).Once()
+			st.On("IndexOutput", mock.Anything, int64(1)).Return(nil)
+			st.On("FinishOne", mock.Anything, int64(1), mock.Anything).Run(func(args mock.Arguments) {
+				result = args.Get(2).(models.Result)
+			}).Return(int64(1), nil)
+
+			err := error(&services.ExitError{Code: 0})
+			if tt.wantStop {
+				err = services.ErrStoppedManually
+			}
+
+			stopCh := make(chan syscall.Signal, 1)
+			resCh, errCh := make(chan models.Output), make(chan error)
+
+			go func() {
+				for _, text := range []string{"a", "b", "c"} {
+					resCh <- models.Output{Stream: models.StreamStdout, Text: text}
+				}
+				close(resCh)
+
+				errCh <- err
+				close(errCh)
+			}()
+
+			c.saveOutput(job{id: 1, stop: stopCh}, resCh, errCh)
+			c.running.Wait()
+
+			require.Equal(t, tt.wantLines, got)
+			require.Equal(t, tt.wantResult, result)
+			require.Equal(t, tt.wantStop, len(stopCh) == 1)
+		})
+	}
+}
+
+func TestCommander_saveOutputStopBusy(t *testing.T) {
+	st := mocks.NewStorager(t)
+
+	c := &Commander{
+		cmdStorage: st,
+		cfg:        &config.Commander{OutputLimits: config.OutputLimits{MaxLines: 1, Policy: policyStop}},
+		log:        logs.NewDiscardLogger(),
+		stopChans:  &sync.Map{},
+		inputs:     &sync.Map{},
+		queue:      newJobQueue(1),
+		events:     newBroker(),
+	}
+	c.queue.running = 1
+	c.running.Add(1)
+
+	var result models.Result
+
+	st.On("SaveOutputs", mock.Anything, int64(1), mock.Anything).Return(nil)
+	st.On("TruncateOne", mock.Anything, int64(1)).Return(int64(1), nil).Once()
+	st.On("IndexOutput", mock.Anything, int64(1)).Return(nil)
+	st.On("FinishOne", mock.Anything, int64(1), mock.Anything).Run(func(args mock.Arguments) {
+		result = args.Get(2).(models.Result)
+	}).Return(int64(1), nil)
+
+	stopCh := make(chan syscall.Signal)
+	resCh, errCh := make(chan models.Output), make(chan error)
+
+	var sig syscall.Signal
+
+	// The executor is busy with output when the limit is exceeded and takes the signal later.
+	go func() {
+		for _, text := range []string{"a", "b", "c"} {
+			resCh <- models.Output{Stream: models.StreamStdout, Text: text}
+		}
+
+		select {
+		case sig = <-stopCh:
+			close(resCh)
+			errCh <- services.ErrStoppedManually
+		case <-time.After(time.Second):
+			close(resCh)
+			errCh <- &services.ExitError{Code: 0}
+		}
+
+		close(errCh)
+	}()
+
+	c.saveOutput(job{id: 1, stop: stopCh}, resCh, errCh)
+	c.running.Wait()
+
+	require.Equal(t, syscall.SIGTERM, sig)
+	require.Equal(t, models.StatusLimitExceeded, result.Status)
+}
+
+func TestCheckArtifacts(t *testing.T) {
+	tests := []struct {
+		name     string
+		patterns []string
+		wantErr  bool
+	}{
+		{
+			name: "test_1, not set",
+		},
+		{
+			name:     "test_2, relative patterns",
+			patterns: []string{"report.txt", "out/*.log", "build/[a-z]*"},
+		},
+		{
+			name:     "test_3, empty pattern",
+			patterns: []string{""},
+			wantErr:  true,
+		},
+		{
+			name:     "test_4, absolute pattern",
+			patterns: []string{"/etc/passwd"},
+			wantErr:  true,
+		},
+		{
+			name:     "test_5, escape by dots",
+			patterns: []string{"out/../../*"},
+			wantErr:  true,
+		},
+		{
+			name:     "test_6, bad pattern",
+			patterns: []string{"out/[a-"},
+			wantErr:  true,
+		},
+		{
+			name:     "test_7, too many patterns",
+			patterns: make([]string, maxArtifactPatterns+1),
+			wantErr:  true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := checkArtifacts(tt.patterns)
+
+			if tt.wantErr {
+				require.ErrorIs(t, err, services.ErrInvalidOptions)
+				return
+			}
+
+			require.NoError(t, err)
+		})
+	}
+}
+
+func TestArtifactStore(t *testing.T) {
+	workspace := t.TempDir()
+	outside := t.TempDir()
+
+	require.NoError(t, os.MkdirAll(filepath.Join(workspace, "out", "logs"), 0o700))
+	require.NoError(t, os.WriteFile(filepath.Join(workspace, "report.txt"), []byte("report"), 0o600))
+	require.NoError(t, os.WriteFile(filepath.Join(workspace, "out", "a.log"), []byte("a"), 0o600))
+	require.NoError(t, os.WriteFile(filepath.Join(workspace, "out", "big.log"), make([]byte, 2<<20), 0o600))
+	require.NoError(t, os.WriteFile(filepath.Join(outside, "secret"), []byte("secret"), 0o600))
+	require.NoError(t, os.Symlink(filepath.Join(outside, "secret"), filepath.Join(workspace, "out", "secret.log")))
+	require.NoError(t, os.Symlink(outside, filepath.Join(workspace, "linked")))
+
+	s := newArtifactStore(config.Artifacts{Root: t.TempDir(), MaxFiles: 32, MaxFileMB: 1})
+
+	artifacts, err := s.collect(1, workspace, []string{"*.txt", "out/*", "report.txt", "linked/*"})
+	require.ErrorContains(t, err, errArtifactTooLarge.Error())
+	require.Equal(t, []models.Artifact{
+		{CommandID: 1, Name: "report.txt", Size: 6, SHA256: "845e91831319e89c4d656bdb80c278ac09a7230d61e5dfd2e1b1fbb436ac8917"},
+		{CommandID: 1, Name: "out/a.log", Size: 1, SHA256: "ca978112ca1bbdcafac231b39a23dc4da786eff8147c4e72b9807785afee48bb"},
+	}, artifacts)
+
+	f, err := s.open(1, "out/a.log")
+	require.NoError(t, err)
+	defer f.Close()
+
+	data, err := io.ReadAll(f)
+	require.NoError(t, err)
+	require.Equal(t, "a", string(data))
+
+	require.NoFileExists(t, s.path(1, "out/big.log"))
+	require.NoFileExists(t, s.path(1, "out/secret.log"))
+	require.NoFileExists(t, s.path(1, "linked/secret"))
+
+	_, _, ok := openArtifact(workspace, filepath.Join(workspace, "out", "secret.log"))
+	require.False(t, ok)
+
+	_, _, ok = openArtifact(workspace, filepath.Join(workspace, "linked", "secret"))
+	require.False(t, ok)
+
+	s.maxFiles = 1
+
+	artifacts, err = s.collect(2, workspace, []string{"out/a.log", "report.txt"})
+	require.Error(t, err)
+	require.Len(t, artifacts, 1)
+	require.Equal(t, "out/a.log", artifacts[0].Name)
+}
+
+func TestCommander_OpenArtifact(t *testing.T) {
+	st := mocks.NewStorager(t)
+
+	workspace := t.TempDir()
+	require.NoError(t, os.WriteFile(filepath.Join(workspace, "report.txt"), []byte("report"), 0o600))
+
+	c := &Commander{
+		cmdStorage: st,
+		log:        logs.NewDiscardLogger(),
+		artifacts:  newArtifactStore(config.Artifacts{Root: t.TempDir()}),
+	}
+
+	st.On("CreateArtifact", mock.Anything, mock.MatchedBy(func(a models.Artifact) bool {
+		return a.CommandID == 1 && a.Name == "report.txt" && a.Size == 6
+	})).Return(int64(7), nil)
+
+	c.collectArtifacts(job{id: 1, script: models.Script{Workspace: workspace,
+		Options: models.Options{Artifacts: []string{"*.txt"}}}})
+
+	st.On("GetArtifact", mock.Anything, int64(1), int64(7)).Return(&models.Artifact{ID: 7, CommandID: 1, Name: "report.txt"}, nil)
+	st.On("GetArtifact", mock.Anything, int64(1), int64(8)).Return(&models.Artifact{ID: 8, CommandID: 1, Name: "gone.txt"}, nil)
+	st.On("GetArtifact", mock.Anything, int64(1), int64(9)).Return(nil, services.ErrArtifactNotFound)
+
+	artifact, f, err := c.OpenArtifact(context.Background(), 1, 7)
+	require.NoError(t, err)
+	defer f.Close()
+
+	data, err := io.ReadAll(f)
+	require.NoError(t, err)
+	require.Equal(t, "report", string(data))
+	require.Equal(t, "report.txt", artifact.Name)
+
+	_, _, err = c.OpenArtifact(context.Background(), 1, 8)
+	require.ErrorIs(t, err, services.ErrArtifactNotFound)
+
+	_, _, err = c.OpenArtifact(context.Background(), 1, 9)
+	require.ErrorIs(t, err, services.ErrArtifactNotFound)
+}
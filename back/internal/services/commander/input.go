@@ -0,0 +1,49 @@
+package commander
+
+import (
+	"context"
+	"sync"
+
+	"github.com/enchik0reo/commandApi/internal/services"
+)
+
+// input is the stdin of the interactive command ...
+type input struct {
+	mu     sync.Mutex
+	ch     chan []byte
+	closed bool
+}
+
+// newInput creates a new instance of input ...
+func newInput() *input {
+	return &input{ch: make(chan []byte)}
+}
+
+// write passes data to the command's stdin.
+// It waits until the executor takes the data or ctx is done ...
+func (in *input) write(ctx context.Context, data []byte) error {
+	in.mu.Lock()
+	defer in.mu.Unlock()
+
+	if in.closed {
+		return services.ErrInputClosed
+	}
+
+	select {
+	case in.ch <- data:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+// close sends EOF to the command's stdin ...
+func (in *input) close() {
+	in.mu.Lock()
+	defer in.mu.Unlock()
+
+	if !in.closed {
+		in.closed = true
+		close(in.ch)
+	}
+}
@@ -2,35 +2,58 @@
 
 package mocks
 
-import mock "github.com/stretchr/testify/mock"
+import (
+	syscall "syscall"
+
+	models "github.com/enchik0reo/commandApi/internal/models"
+	mock "github.com/stretchr/testify/mock"
+)
 
 // Executor is an autogenerated mock type for the Executor type
 type Executor struct {
 	mock.Mock
 }
 
+// HasCgroup provides a mock function with given fields:
+func (_m *Executor) HasCgroup() bool {
+	ret := _m.Called()
+
+	if len(ret) == 0 {
+		panic("no return value specified for HasCgroup")
+	}
+
+	var r0 bool
+	if rf, ok := ret.Get(0).(func() bool); ok {
+		r0 = rf()
+	} else {
+		r0 = ret.Get(0).(bool)
+	}
+
+	return r0
+}
+
 // RunScript provides a mock function with given fields: _a0, _a1, _a2
-func (_m *Executor) RunScript(_a0 string, _a1 string, _a2 <-chan struct{}) (<-chan string, <-chan error) {
+func (_m *Executor) RunScript(_a0 models.Script, _a1 <-chan syscall.Signal, _a2 <-chan []byte) (<-chan models.Output, <-chan error) {
 	ret := _m.Called(_a0, _a1, _a2)
 
 	if len(ret) == 0 {
 		panic("no return value specified for RunScript")
 	}
 
-	var r0 <-chan string
+	var r0 <-chan models.Output
 	var r1 <-chan error
-	if rf, ok := ret.Get(0).(func(string, string, <-chan struct{}) (<-chan string, <-chan error)); ok {
+	if rf, ok := ret.Get(0).(func(models.Script, <-chan syscall.Signal, <-chan []byte) (<-chan models.Output, <-chan error)); ok {
 		return rf(_a0, _a1, _a2)
 	}
-	if rf, ok := ret.Get(0).(func(string, string, <-chan struct{}) <-chan string); ok {
+	if rf, ok := ret.Get(0).(func(models.Script, <-chan syscall.Signal, <-chan []byte) <-chan models.Output); ok {
 		r0 = rf(_a0, _a1, _a2)
 	} else {
 		if ret.Get(0) != nil {
-			r0 = ret.Get(0).(<-chan string)
+			r0 = ret.Get(0).(<-chan models.Output)
 		}
 	}
 
-	if rf, ok := ret.Get(1).(func(string, string, <-chan struct{}) <-chan error); ok {
+	if rf, ok := ret.Get(1).(func(models.Script, <-chan syscall.Signal, <-chan []byte) <-chan error); ok {
 		r1 = rf(_a0, _a1, _a2)
 	} else {
 		if ret.Get(1) != nil {
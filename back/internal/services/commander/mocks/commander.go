@@ -7,6 +7,7 @@ package mocks
 import (
 	context "context"
 	reflect "reflect"
+	syscall "syscall"
 
 	models "github.com/enchik0reo/commandApi/internal/models"
 	gomock "github.com/golang/mock/gomock"
@@ -35,8 +36,23 @@ func (m *MockStorager) EXPECT() *MockStoragerMockRecorder {
 	return m.recorder
 }
 
+// CreateArtifact mocks base method.
+func (m *MockStorager) CreateArtifact(arg0 context.Context, arg1 models.Artifact) (int64, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "CreateArtifact", arg0, arg1)
+	ret0, _ := ret[0].(int64)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// CreateArtifact indicates an expected call of CreateArtifact.
+func (mr *MockStoragerMockRecorder) CreateArtifact(arg0, arg1 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "CreateArtifact", reflect.TypeOf((*MockStorager)(nil).CreateArtifact), arg0, arg1)
+}
+
 // CreateNew mocks base method.
-func (m *MockStorager) CreateNew(arg0 context.Context, arg1 string) (int64, error) {
+func (m *MockStorager) CreateNew(arg0 context.Context, arg1 models.Command) (int64, error) {
 	m.ctrl.T.Helper()
 	ret := m.ctrl.Call(m, "CreateNew", arg0, arg1)
 	ret0, _ := ret[0].(int64)
@@ -50,8 +66,127 @@ func (mr *MockStoragerMockRecorder) CreateNew(arg0, arg1 interface{}) *gomock.Ca
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "CreateNew", reflect.TypeOf((*MockStorager)(nil).CreateNew), arg0, arg1)
 }
 
+// CreateSchedule mocks base method.
+func (m *MockStorager) CreateSchedule(arg0 context.Context, arg1 models.Schedule) (int64, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "CreateSchedule", arg0, arg1)
+	ret0, _ := ret[0].(int64)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// CreateSchedule indicates an expected call of CreateSchedule.
+func (mr *MockStoragerMockRecorder) CreateSchedule(arg0, arg1 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "CreateSchedule", reflect.TypeOf((*MockStorager)(nil).CreateSchedule), arg0, arg1)
+}
+
+// CreateTemplate mocks base method.
+func (m *MockStorager) CreateTemplate(arg0 context.Context, arg1 models.Template) (int64, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "CreateTemplate", arg0, arg1)
+	ret0, _ := ret[0].(int64)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// CreateTemplate indicates an expected call of CreateTemplate.
+func (mr *MockStoragerMockRecorder) CreateTemplate(arg0, arg1 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "CreateTemplate", reflect.TypeOf((*MockStorager)(nil).CreateTemplate), arg0, arg1)
+}
+
+// DeleteSchedule mocks base method.
+func (m *MockStorager) DeleteSchedule(arg0 context.Context, arg1 int64) (int64, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "DeleteSchedule", arg0, arg1)
+	ret0, _ := ret[0].(int64)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// DeleteSchedule indicates an expected call of DeleteSchedule.
+func (mr *MockStoragerMockRecorder) DeleteSchedule(arg0, arg1 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "DeleteSchedule", reflect.TypeOf((*MockStorager)(nil).DeleteSchedule), arg0, arg1)
+}
+
+// DeleteTemplate mocks base method.
+func (m *MockStorager) DeleteTemplate(arg0 context.Context, arg1 int64) (int64, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "DeleteTemplate", arg0, arg1)
+	ret0, _ := ret[0].(int64)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// DeleteTemplate indicates an expected call of DeleteTemplate.
+func (mr *MockStoragerMockRecorder) DeleteTemplate(arg0, arg1 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "DeleteTemplate", reflect.TypeOf((*MockStorager)(nil).DeleteTemplate), arg0, arg1)
+}
+
+// EachOutput mocks base method.
+func (m *MockStorager) EachOutput(arg0 context.Context, arg1 int64, arg2 func(models.Output) error) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "EachOutput", arg0, arg1, arg2)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// EachOutput indicates an expected call of EachOutput.
+func (mr *MockStoragerMockRecorder) EachOutput(arg0, arg1, arg2 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "EachOutput", reflect.TypeOf((*MockStorager)(nil).EachOutput), arg0, arg1, arg2)
+}
+
+// FinishOne mocks base method.
+func (m *MockStorager) FinishOne(arg0 context.Context, arg1 int64, arg2 models.Result) (int64, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "FinishOne", arg0, arg1, arg2)
+	ret0, _ := ret[0].(int64)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// FinishOne indicates an expected call of FinishOne.
+func (mr *MockStoragerMockRecorder) FinishOne(arg0, arg1, arg2 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "FinishOne", reflect.TypeOf((*MockStorager)(nil).FinishOne), arg0, arg1, arg2)
+}
+
+// GetArtifact mocks base method.
+func (m *MockStorager) GetArtifact(arg0 context.Context, arg1, arg2 int64) (*models.Artifact, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetArtifact", arg0, arg1, arg2)
+	ret0, _ := ret[0].(*models.Artifact)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetArtifact indicates an expected call of GetArtifact.
+func (mr *MockStoragerMockRecorder) GetArtifact(arg0, arg1, arg2 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetArtifact", reflect.TypeOf((*MockStorager)(nil).GetArtifact), arg0, arg1, arg2)
+}
+
+// GetArtifacts mocks base method.
+func (m *MockStorager) GetArtifacts(arg0 context.Context, arg1 int64) ([]models.Artifact, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetArtifacts", arg0, arg1)
+	ret0, _ := ret[0].([]models.Artifact)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetArtifacts indicates an expected call of GetArtifacts.
+func (mr *MockStoragerMockRecorder) GetArtifacts(arg0, arg1 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetArtifacts", reflect.TypeOf((*MockStorager)(nil).GetArtifacts), arg0, arg1)
+}
+
 // GetList mocks base method.
-func (m *MockStorager) GetList(arg0 context.Context, arg1 int64) ([]models.Command, error) {
+func (m *MockStorager) GetList(arg0 context.Context, arg1 models.CommandFilter) ([]models.Command, error) {
 	m.ctrl.T.Helper()
 	ret := m.ctrl.Call(m, "GetList", arg0, arg1)
 	ret0, _ := ret[0].([]models.Command)
@@ -80,19 +215,153 @@ func (mr *MockStoragerMockRecorder) GetOne(arg0, arg1 interface{}) *gomock.Call
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetOne", reflect.TypeOf((*MockStorager)(nil).GetOne), arg0, arg1)
 }
 
-// SaveOutput mocks base method.
-func (m *MockStorager) SaveOutput(arg0 context.Context, arg1 int64, arg2 string) (int64, error) {
+// GetOutput mocks base method.
+func (m *MockStorager) GetOutput(arg0 context.Context, arg1 int64, arg2 models.OutputRange) ([]models.Output, int64, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetOutput", arg0, arg1, arg2)
+	ret0, _ := ret[0].([]models.Output)
+	ret1, _ := ret[1].(int64)
+	ret2, _ := ret[2].(error)
+	return ret0, ret1, ret2
+}
+
+// GetOutput indicates an expected call of GetOutput.
+func (mr *MockStoragerMockRecorder) GetOutput(arg0, arg1, arg2 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetOutput", reflect.TypeOf((*MockStorager)(nil).GetOutput), arg0, arg1, arg2)
+}
+
+// GetQueued mocks base method.
+func (m *MockStorager) GetQueued(arg0 context.Context) ([]models.Command, error) {
 	m.ctrl.T.Helper()
-	ret := m.ctrl.Call(m, "SaveOutput", arg0, arg1, arg2)
+	ret := m.ctrl.Call(m, "GetQueued", arg0)
+	ret0, _ := ret[0].([]models.Command)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetQueued indicates an expected call of GetQueued.
+func (mr *MockStoragerMockRecorder) GetQueued(arg0 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetQueued", reflect.TypeOf((*MockStorager)(nil).GetQueued), arg0)
+}
+
+// GetSchedule mocks base method.
+func (m *MockStorager) GetSchedule(arg0 context.Context, arg1 int64) (*models.Schedule, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetSchedule", arg0, arg1)
+	ret0, _ := ret[0].(*models.Schedule)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetSchedule indicates an expected call of GetSchedule.
+func (mr *MockStoragerMockRecorder) GetSchedule(arg0, arg1 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetSchedule", reflect.TypeOf((*MockStorager)(nil).GetSchedule), arg0, arg1)
+}
+
+// GetSchedules mocks base method.
+func (m *MockStorager) GetSchedules(arg0 context.Context) ([]models.Schedule, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetSchedules", arg0)
+	ret0, _ := ret[0].([]models.Schedule)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetSchedules indicates an expected call of GetSchedules.
+func (mr *MockStoragerMockRecorder) GetSchedules(arg0 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetSchedules", reflect.TypeOf((*MockStorager)(nil).GetSchedules), arg0)
+}
+
+// GetTemplate mocks base method.
+func (m *MockStorager) GetTemplate(arg0 context.Context, arg1 int64) (*models.Template, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetTemplate", arg0, arg1)
+	ret0, _ := ret[0].(*models.Template)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetTemplate indicates an expected call of GetTemplate.
+func (mr *MockStoragerMockRecorder) GetTemplate(arg0, arg1 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetTemplate", reflect.TypeOf((*MockStorager)(nil).GetTemplate), arg0, arg1)
+}
+
+// GetTemplates mocks base method.
+func (m *MockStorager) GetTemplates(arg0 context.Context) ([]models.Template, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetTemplates", arg0)
+	ret0, _ := ret[0].([]models.Template)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetTemplates indicates an expected call of GetTemplates.
+func (mr *MockStoragerMockRecorder) GetTemplates(arg0 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetTemplates", reflect.TypeOf((*MockStorager)(nil).GetTemplates), arg0)
+}
+
+// IndexOutput mocks base method.
+func (m *MockStorager) IndexOutput(arg0 context.Context, arg1 int64) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "IndexOutput", arg0, arg1)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// IndexOutput indicates an expected call of IndexOutput.
+func (mr *MockStoragerMockRecorder) IndexOutput(arg0, arg1 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "IndexOutput", reflect.TypeOf((*MockStorager)(nil).IndexOutput), arg0, arg1)
+}
+
+// SaveOutputs mocks base method.
+func (m *MockStorager) SaveOutputs(arg0 context.Context, arg1 int64, arg2 []models.Output) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "SaveOutputs", arg0, arg1, arg2)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// SaveOutputs indicates an expected call of SaveOutputs.
+func (mr *MockStoragerMockRecorder) SaveOutputs(arg0, arg1, arg2 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "SaveOutputs", reflect.TypeOf((*MockStorager)(nil).SaveOutputs), arg0, arg1, arg2)
+}
+
+// SetLastCommand mocks base method.
+func (m *MockStorager) SetLastCommand(arg0 context.Context, arg1, arg2 int64) (int64, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "SetLastCommand", arg0, arg1, arg2)
+	ret0, _ := ret[0].(int64)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// SetLastCommand indicates an expected call of SetLastCommand.
+func (mr *MockStoragerMockRecorder) SetLastCommand(arg0, arg1, arg2 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "SetLastCommand", reflect.TypeOf((*MockStorager)(nil).SetLastCommand), arg0, arg1, arg2)
+}
+
+// StartOne mocks base method.
+func (m *MockStorager) StartOne(arg0 context.Context, arg1 int64) (int64, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "StartOne", arg0, arg1)
 	ret0, _ := ret[0].(int64)
 	ret1, _ := ret[1].(error)
 	return ret0, ret1
 }
 
-// SaveOutput indicates an expected call of SaveOutput.
-func (mr *MockStoragerMockRecorder) SaveOutput(arg0, arg1, arg2 interface{}) *gomock.Call {
+// StartOne indicates an expected call of StartOne.
+func (mr *MockStoragerMockRecorder) StartOne(arg0, arg1 interface{}) *gomock.Call {
 	mr.mock.ctrl.T.Helper()
-	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "SaveOutput", reflect.TypeOf((*MockStorager)(nil).SaveOutput), arg0, arg1, arg2)
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "StartOne", reflect.TypeOf((*MockStorager)(nil).StartOne), arg0, arg1)
 }
 
 // StopOne mocks base method.
@@ -110,6 +379,51 @@ func (mr *MockStoragerMockRecorder) StopOne(arg0, arg1 interface{}) *gomock.Call
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "StopOne", reflect.TypeOf((*MockStorager)(nil).StopOne), arg0, arg1)
 }
 
+// TruncateOne mocks base method.
+func (m *MockStorager) TruncateOne(arg0 context.Context, arg1 int64) (int64, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "TruncateOne", arg0, arg1)
+	ret0, _ := ret[0].(int64)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// TruncateOne indicates an expected call of TruncateOne.
+func (mr *MockStoragerMockRecorder) TruncateOne(arg0, arg1 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "TruncateOne", reflect.TypeOf((*MockStorager)(nil).TruncateOne), arg0, arg1)
+}
+
+// UpdateSchedule mocks base method.
+func (m *MockStorager) UpdateSchedule(arg0 context.Context, arg1 models.Schedule) (int64, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "UpdateSchedule", arg0, arg1)
+	ret0, _ := ret[0].(int64)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// UpdateSchedule indicates an expected call of UpdateSchedule.
+func (mr *MockStoragerMockRecorder) UpdateSchedule(arg0, arg1 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "UpdateSchedule", reflect.TypeOf((*MockStorager)(nil).UpdateSchedule), arg0, arg1)
+}
+
+// UpdateTemplate mocks base method.
+func (m *MockStorager) UpdateTemplate(arg0 context.Context, arg1 models.Template) (int64, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "UpdateTemplate", arg0, arg1)
+	ret0, _ := ret[0].(int64)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// UpdateTemplate indicates an expected call of UpdateTemplate.
+func (mr *MockStoragerMockRecorder) UpdateTemplate(arg0, arg1 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "UpdateTemplate", reflect.TypeOf((*MockStorager)(nil).UpdateTemplate), arg0, arg1)
+}
+
 // MockExecutor is a mock of Executor interface.
 type MockExecutor struct {
 	ctrl     *gomock.Controller
@@ -133,11 +447,25 @@ func (m *MockExecutor) EXPECT() *MockExecutorMockRecorder {
 	return m.recorder
 }
 
+// HasCgroup mocks base method.
+func (m *MockExecutor) HasCgroup() bool {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "HasCgroup")
+	ret0, _ := ret[0].(bool)
+	return ret0
+}
+
+// HasCgroup indicates an expected call of HasCgroup.
+func (mr *MockExecutorMockRecorder) HasCgroup() *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "HasCgroup", reflect.TypeOf((*MockExecutor)(nil).HasCgroup))
+}
+
 // RunScript mocks base method.
-func (m *MockExecutor) RunScript(arg0, arg1 string, arg2 <-chan struct{}) (<-chan string, <-chan error) {
+func (m *MockExecutor) RunScript(arg0 models.Script, arg1 <-chan syscall.Signal, arg2 <-chan []byte) (<-chan models.Output, <-chan error) {
 	m.ctrl.T.Helper()
 	ret := m.ctrl.Call(m, "RunScript", arg0, arg1, arg2)
-	ret0, _ := ret[0].(<-chan string)
+	ret0, _ := ret[0].(<-chan models.Output)
 	ret1, _ := ret[1].(<-chan error)
 	return ret0, ret1
 }
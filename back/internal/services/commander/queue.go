@@ -0,0 +1,100 @@
+package commander
+
+import (
+	"sync"
+	"syscall"
+
+	"github.com/enchik0reo/commandApi/internal/models"
+)
+
+// job is a command waiting for a free slot ...
+type job struct {
+	id     int64
+	script models.Script
+	stop   chan syscall.Signal
+}
+
+// jobQueue keeps jobs in FIFO order and counts running ones.
+// Zero limit means that the number of running jobs isn't limited ...
+type jobQueue struct {
+	mu      sync.Mutex
+	jobs    []job
+	running int
+	limit   int
+	closed  bool
+}
+
+func newJobQueue(limit int) *jobQueue {
+	return &jobQueue{limit: limit}
+}
+
+// push adds the job to the end of the queue ...
+func (q *jobQueue) push(j job) {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+
+	q.jobs = append(q.jobs, j)
+}
+
+// next takes the first job and a running slot for it.
+// It returns false if the queue is empty, closed or there are no free slots ...
+func (q *jobQueue) next() (job, bool) {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+
+	if q.closed || len(q.jobs) == 0 || (q.limit > 0 && q.running >= q.limit) {
+		return job{}, false
+	}
+
+	j := q.jobs[0]
+	q.jobs = q.jobs[1:]
+	q.running++
+
+	return j, true
+}
+
+// done frees the running slot ...
+func (q *jobQueue) done() {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+
+	q.running--
+}
+
+// remove deletes the job from the queue by command id.
+// It returns false if there's no such job ...
+func (q *jobQueue) remove(id int64) bool {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+
+	for i := range q.jobs {
+		if q.jobs[i].id == id {
+			q.jobs = append(q.jobs[:i], q.jobs[i+1:]...)
+			return true
+		}
+	}
+
+	return false
+}
+
+// has reports whether the job is in the queue by command id ...
+func (q *jobQueue) has(id int64) bool {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+
+	for i := range q.jobs {
+		if q.jobs[i].id == id {
+			return true
+		}
+	}
+
+	return false
+}
+
+// close stops giving jobs, the queued ones are left in the queue ...
+func (q *jobQueue) close() {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+
+	q.closed = true
+}
@@ -0,0 +1,335 @@
+package commander
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"reflect"
+	"sync"
+	"time"
+
+	"github.com/enchik0reo/commandApi/internal/models"
+	"github.com/enchik0reo/commandApi/internal/services"
+
+	"github.com/robfig/cron/v3"
+)
+
+const (
+	maxScheduleNameLength = 64
+	maxNextRuns           = 100
+)
+
+// scheduler keeps cron entries of active schedules ...
+type scheduler struct {
+	mu      sync.Mutex
+	cron    *cron.Cron
+	loc     *time.Location
+	entries map[int64]cron.EntryID
+}
+
+// newScheduler creates scheduler evaluating cron expressions in the location ...
+func newScheduler(loc *time.Location) *scheduler {
+	return &scheduler{
+		cron:    cron.New(cron.WithLocation(loc)),
+		loc:     loc,
+		entries: make(map[int64]cron.EntryID),
+	}
+}
+
+// add replaces the cron entry of the schedule ...
+func (s *scheduler) add(id int64, spec string, run func()) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if entry, ok := s.entries[id]; ok {
+		s.cron.Remove(entry)
+		delete(s.entries, id)
+	}
+
+	entry, err := s.cron.AddFunc(spec, run)
+	if err != nil {
+		return err
+	}
+
+	s.entries[id] = entry
+
+	return nil
+}
+
+// remove removes the cron entry of the schedule ...
+func (s *scheduler) remove(id int64) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if entry, ok := s.entries[id]; ok {
+		s.cron.Remove(entry)
+		delete(s.entries, id)
+	}
+}
+
+// StartScheduler starts firing schedules and activates ones loaded from storage.
+// Cron is started even if schedules can't be loaded, so schedules created later still fire ...
+func (c *Commander) StartScheduler(ctx context.Context) error {
+	const op = "commander.StartScheduler"
+
+	c.schedules.cron.Start()
+
+	schs, err := c.cmdStorage.GetSchedules(ctx)
+	if err != nil {
+		return fmt.Errorf("can't get schedules: %s: %v", op, err)
+	}
+
+	for _, sch := range schs {
+		if err := c.activate(sch); err != nil {
+			c.log.Error("can't activate schedule", c.log.Attr("op", op), c.log.Attr("schedule_id", sch.ID),
+				c.log.Attr("error", err))
+		}
+	}
+
+	return nil
+}
+
+// StopScheduler stops firing schedules and waits for the runs which are in progress ...
+func (c *Commander) StopScheduler(ctx context.Context) error {
+	select {
+	case <-c.schedules.cron.Stop().Done():
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+// CreateSchedule checks the schedule, saves it to storage and activates it ...
+func (c *Commander) CreateSchedule(ctx context.Context, sch models.Schedule) (int64, error) {
+	const op = "commander.CreateSchedule"
+
+	if err := c.checkSchedule(ctx, sch); err != nil {
+		return -1, err
+	}
+
+	id, err := c.cmdStorage.CreateSchedule(ctx, sch)
+	if err != nil {
+		if errors.Is(err, services.ErrTemplateNotFound) {
+			return -1, services.ErrTemplateNotFound
+		}
+
+		return -1, fmt.Errorf("can't create schedule in storage: %s: %v", op, err)
+	}
+
+	sch.ID = id
+
+	if err := c.activate(sch); err != nil {
+		return -1, fmt.Errorf("can't activate schedule: %s: %v", op, err)
+	}
+
+	return id, nil
+}
+
+// GetScheduleList returns all schedules from storage ...
+func (c *Commander) GetScheduleList(ctx context.Context) ([]models.Schedule, error) {
+	const op = "commander.GetScheduleList"
+
+	schs, err := c.cmdStorage.GetSchedules(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("can't get list of schedules: %s: %v", op, err)
+	}
+
+	return schs, nil
+}
+
+// GetSchedule returns the schedule from storage ...
+func (c *Commander) GetSchedule(ctx context.Context, id int64) (*models.Schedule, error) {
+	const op = "commander.GetSchedule"
+
+	sch, err := c.cmdStorage.GetSchedule(ctx, id)
+	if err != nil {
+		if errors.Is(err, services.ErrScheduleNotFound) {
+			return nil, services.ErrScheduleNotFound
+		}
+
+		return nil, fmt.Errorf("can't get schedule on id: %d: %s: %v", id, op, err)
+	}
+
+	return sch, nil
+}
+
+// UpdateSchedule checks the schedule, replaces the saved one and reactivates it ...
+func (c *Commander) UpdateSchedule(ctx context.Context, sch models.Schedule) error {
+	const op = "commander.UpdateSchedule"
+
+	if err := c.checkSchedule(ctx, sch); err != nil {
+		return err
+	}
+
+	if _, err := c.cmdStorage.UpdateSchedule(ctx, sch); err != nil {
+		if errors.Is(err, services.ErrScheduleNotFound) || errors.Is(err, services.ErrTemplateNotFound) {
+			return err
+		}
+
+		return fmt.Errorf("can't update schedule on id: %d: %s: %v", sch.ID, op, err)
+	}
+
+	if err := c.activate(sch); err != nil {
+		return fmt.Errorf("can't activate schedule: %s: %v", op, err)
+	}
+
+	return nil
+}
+
+// DeleteSchedule deletes the schedule from storage and deactivates it.
+// Commands made by the schedule are kept ...
+func (c *Commander) DeleteSchedule(ctx context.Context, id int64) error {
+	const op = "commander.DeleteSchedule"
+
+	if _, err := c.cmdStorage.DeleteSchedule(ctx, id); err != nil {
+		if errors.Is(err, services.ErrScheduleNotFound) {
+			return services.ErrScheduleNotFound
+		}
+
+		return fmt.Errorf("can't delete schedule on id: %d: %s: %v", id, op, err)
+	}
+
+	c.schedules.remove(id)
+
+	return nil
+}
+
+// NextRuns returns the next count times when the schedule fires.
+// Paused schedule still has the next runs, they are computed from its expression ...
+func (c *Commander) NextRuns(ctx context.Context, id int64, count int) ([]time.Time, error) {
+	sch, err := c.GetSchedule(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+
+	if count <= 0 || count > maxNextRuns {
+		return nil, fmt.Errorf("%w: count of next runs must be from 1 to %d", services.ErrInvalidSchedule, maxNextRuns)
+	}
+
+	spec, err := cron.ParseStandard(sch.Spec)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", services.ErrInvalidSchedule, err)
+	}
+
+	return nextRuns(spec, time.Now().In(c.schedules.loc), count), nil
+}
+
+// nextRuns returns the next count activation times of the cron schedule after the time ...
+func nextRuns(spec cron.Schedule, after time.Time, count int) []time.Time {
+	runs := make([]time.Time, 0, count)
+
+	for i := 0; i < count; i++ {
+		after = spec.Next(after)
+		if after.IsZero() {
+			break
+		}
+
+		runs = append(runs, after)
+	}
+
+	return runs
+}
+
+// activate adds the cron entry of the schedule, paused schedule is deactivated ...
+func (c *Commander) activate(sch models.Schedule) error {
+	if sch.Paused {
+		c.schedules.remove(sch.ID)
+		return nil
+	}
+
+	id := sch.ID
+
+	return c.schedules.add(id, sch.Spec, func() { c.fire(id) })
+}
+
+// fire runs the schedule's script or template.
+// The schedule is reloaded from storage, so the run uses its last saved version.
+// The run is skipped while the previous command of the schedule with no overlap is still working ...
+func (c *Commander) fire(id int64) {
+	const op = "commander.fire"
+
+	ctx, cancel := context.WithTimeout(context.Background(), contextDuration)
+	defer cancel()
+
+	sch, err := c.cmdStorage.GetSchedule(ctx, id)
+	if err != nil {
+		if errors.Is(err, services.ErrScheduleNotFound) {
+			c.schedules.remove(id)
+			return
+		}
+
+		c.log.Error("can't get schedule", c.log.Attr("op", op), c.log.Attr("schedule_id", id), c.log.Attr("error", err))
+		return
+	}
+
+	if sch.Paused {
+		c.schedules.remove(id)
+		return
+	}
+
+	if sch.NoOverlap && sch.LastCommandID > 0 {
+		if _, active := c.stopChans.Load(sch.LastCommandID); active {
+			c.log.Debug("schedule run skipped, previous command is still working", c.log.Attr("op", op),
+				c.log.Attr("schedule_id", id), c.log.Attr("command_id", sch.LastCommandID))
+			return
+		}
+	}
+
+	var cmdID int64
+
+	if sch.TemplateID > 0 {
+		cmdID, err = c.RunTemplate(ctx, sch.TemplateID, sch.Params, nil)
+	} else {
+		cmdID, err = c.CreateNewCommand(ctx, models.Script{Body: sch.Script, Options: sch.Options})
+	}
+
+	if err != nil {
+		c.log.Error("can't run schedule", c.log.Attr("op", op), c.log.Attr("schedule_id", id), c.log.Attr("error", err))
+		return
+	}
+
+	if _, err := c.cmdStorage.SetLastCommand(ctx, id, cmdID); err != nil {
+		c.log.Error("can't save last command of schedule", c.log.Attr("op", op), c.log.Attr("schedule_id", id),
+			c.log.Attr("error", err))
+	}
+}
+
+// checkSchedule checks the schedule's expression and what it runs.
+// Schedule runs either the script with options or the template with parameters ...
+func (c *Commander) checkSchedule(ctx context.Context, sch models.Schedule) error {
+	switch {
+	case sch.Name == "":
+		return fmt.Errorf("%w: schedule name is empty", services.ErrInvalidSchedule)
+	case len([]rune(sch.Name)) > maxScheduleNameLength:
+		return fmt.Errorf("%w: schedule name is longer than %d", services.ErrInvalidSchedule, maxScheduleNameLength)
+	case (sch.Script == "") == (sch.TemplateID == 0):
+		return fmt.Errorf("%w: schedule must have either script or template", services.ErrInvalidSchedule)
+	}
+
+	if _, err := cron.ParseStandard(sch.Spec); err != nil {
+		return fmt.Errorf("%w: %v", services.ErrInvalidSchedule, err)
+	}
+
+	if sch.Script != "" {
+		if len(sch.Params) > 0 {
+			return fmt.Errorf("%w: parameters are used only with template", services.ErrInvalidSchedule)
+		}
+
+		_, err := c.scriptOptions(sch.Options)
+
+		return err
+	}
+
+	if !reflect.ValueOf(sch.Options).IsZero() {
+		return fmt.Errorf("%w: options of template are used, schedule can't have its own", services.ErrInvalidSchedule)
+	}
+
+	tmpl, err := c.GetTemplate(ctx, sch.TemplateID)
+	if err != nil {
+		return err
+	}
+
+	_, err = paramsEnv(tmpl.Params, sch.Params)
+
+	return err
+}
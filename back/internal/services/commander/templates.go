@@ -0,0 +1,261 @@
+package commander
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math"
+	"slices"
+	"strconv"
+
+	"github.com/enchik0reo/commandApi/internal/models"
+	"github.com/enchik0reo/commandApi/internal/services"
+)
+
+const maxTemplateNameLength = 64
+
+// CreateTemplate checks the template and saves it to storage ...
+func (c *Commander) CreateTemplate(ctx context.Context, tmpl models.Template) (int64, error) {
+	const op = "commander.CreateTemplate"
+
+	if err := c.checkTemplate(tmpl); err != nil {
+		return -1, err
+	}
+
+	id, err := c.cmdStorage.CreateTemplate(ctx, tmpl)
+	if err != nil {
+		if errors.Is(err, services.ErrTemplateExists) {
+			return -1, services.ErrTemplateExists
+		}
+
+		return -1, fmt.Errorf("can't create template in storage: %s: %v", op, err)
+	}
+
+	return id, nil
+}
+
+// GetTemplateList returns all templates from storage ...
+func (c *Commander) GetTemplateList(ctx context.Context) ([]models.Template, error) {
+	const op = "commander.GetTemplateList"
+
+	tmpls, err := c.cmdStorage.GetTemplates(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("can't get list of templates: %s: %v", op, err)
+	}
+
+	return tmpls, nil
+}
+
+// GetTemplate returns the template from storage ...
+func (c *Commander) GetTemplate(ctx context.Context, id int64) (*models.Template, error) {
+	const op = "commander.GetTemplate"
+
+	tmpl, err := c.cmdStorage.GetTemplate(ctx, id)
+	if err != nil {
+		if errors.Is(err, services.ErrTemplateNotFound) {
+			return nil, services.ErrTemplateNotFound
+		}
+
+		return nil, fmt.Errorf("can't get template on id: %d: %s: %v", id, op, err)
+	}
+
+	return tmpl, nil
+}
+
+// UpdateTemplate checks the template and replaces the saved one ...
+func (c *Commander) UpdateTemplate(ctx context.Context, tmpl models.Template) error {
+	const op = "commander.UpdateTemplate"
+
+	if err := c.checkTemplate(tmpl); err != nil {
+		return err
+	}
+
+	if _, err := c.cmdStorage.UpdateTemplate(ctx, tmpl); err != nil {
+		if errors.Is(err, services.ErrTemplateNotFound) || errors.Is(err, services.ErrTemplateExists) {
+			return err
+		}
+
+		return fmt.Errorf("can't update template on id: %d: %s: %v", tmpl.ID, op, err)
+	}
+
+	return nil
+}
+
+// DeleteTemplate deletes the template from storage ...
+func (c *Commander) DeleteTemplate(ctx context.Context, id int64) error {
+	const op = "commander.DeleteTemplate"
+
+	if _, err := c.cmdStorage.DeleteTemplate(ctx, id); err != nil {
+		if errors.Is(err, services.ErrTemplateNotFound) {
+			return services.ErrTemplateNotFound
+		}
+
+		return fmt.Errorf("can't delete template on id: %d: %s: %v", id, op, err)
+	}
+
+	return nil
+}
+
+// RunTemplate creates new command from the template.
+// Values of parameters are checked against their types and passed to the script
+// as environment variables, they are never substituted into the script's body ...
+func (c *Commander) RunTemplate(ctx context.Context, id int64, params map[string]any, secretEnv map[string]string) (int64, error) {
+	tmpl, err := c.GetTemplate(ctx, id)
+	if err != nil {
+		return -1, err
+	}
+
+	env, err := paramsEnv(tmpl.Params, params)
+	if err != nil {
+		return -1, err
+	}
+
+	for name, value := range tmpl.Options.Env {
+		env[name] = value
+	}
+
+	script := models.Script{Body: tmpl.Script, SecretEnv: secretEnv, TemplateID: tmpl.ID, Options: tmpl.Options}
+	script.Env = env
+
+	return c.CreateNewCommand(ctx, script)
+}
+
+// checkTemplate checks the template's script, options and declared parameters ...
+func (c *Commander) checkTemplate(tmpl models.Template) error {
+	switch {
+	case tmpl.Name == "":
+		return fmt.Errorf("%w: template name is empty", services.ErrInvalidOptions)
+	case len([]rune(tmpl.Name)) > maxTemplateNameLength:
+		return fmt.Errorf("%w: template name is longer than %d", services.ErrInvalidOptions, maxTemplateNameLength)
+	case tmpl.Script == "":
+		return fmt.Errorf("%w: template script is empty", services.ErrInvalidOptions)
+	}
+
+	if _, err := c.scriptOptions(tmpl.Options); err != nil {
+		return err
+	}
+
+	names := make(map[string]struct{}, len(tmpl.Params))
+
+	for _, p := range tmpl.Params {
+		if _, ok := names[p.Name]; ok {
+			return fmt.Errorf("%w: parameter %s is declared twice", services.ErrInvalidParams, p.Name)
+		}
+
+		if _, ok := tmpl.Options.Env[p.Name]; ok {
+			return fmt.Errorf("%w: parameter %s conflicts with environment variable", services.ErrInvalidParams, p.Name)
+		}
+
+		if err := c.checkEnv(map[string]string{p.Name: ""}); err != nil {
+			return fmt.Errorf("%w: parameter %s: %v", services.ErrInvalidParams, p.Name, err)
+		}
+
+		if err := checkParam(p); err != nil {
+			return err
+		}
+
+		names[p.Name] = struct{}{}
+	}
+
+	return nil
+}
+
+// checkParam checks the parameter's type and its default value ...
+func checkParam(p models.Param) error {
+	switch p.Type {
+	case models.ParamString, models.ParamInt, models.ParamBool:
+		if len(p.Values) > 0 {
+			return fmt.Errorf("%w: parameter %s of type %s can't have values", services.ErrInvalidParams, p.Name, p.Type)
+		}
+	case models.ParamEnum:
+		if len(p.Values) == 0 {
+			return fmt.Errorf("%w: enum parameter %s has no values", services.ErrInvalidParams, p.Name)
+		}
+	default:
+		return fmt.Errorf("%w: parameter %s has unknown type %q", services.ErrInvalidParams, p.Name, p.Type)
+	}
+
+	if p.Default != "" {
+		if _, err := paramValue(p, p.Default); err != nil {
+			return fmt.Errorf("%w: default value: %v", services.ErrInvalidParams, err)
+		}
+	}
+
+	return nil
+}
+
+// paramsEnv returns environment variables with the values of the template's parameters.
+// Default value is used for the missing parameter ...
+func paramsEnv(declared []models.Param, params map[string]any) (map[string]string, error) {
+	env := make(map[string]string, len(declared))
+
+	for name := range params {
+		if !slices.ContainsFunc(declared, func(p models.Param) bool { return p.Name == name }) {
+			return nil, fmt.Errorf("%w: unknown parameter %s", services.ErrInvalidParams, name)
+		}
+	}
+
+	for _, p := range declared {
+		v, ok := params[p.Name]
+
+		switch {
+		case ok && v != nil:
+			value, err := paramValue(p, v)
+			if err != nil {
+				return nil, fmt.Errorf("%w: %v", services.ErrInvalidParams, err)
+			}
+
+			env[p.Name] = value
+		case p.Default != "":
+			env[p.Name] = p.Default
+		case p.Required:
+			return nil, fmt.Errorf("%w: parameter %s is required", services.ErrInvalidParams, p.Name)
+		}
+	}
+
+	return env, nil
+}
+
+// paramValue converts the value of the parameter to the string according to its type.
+// Numbers and booleans are accepted both as JSON values and as strings ...
+func paramValue(p models.Param, v any) (string, error) {
+	switch p.Type {
+	case models.ParamString:
+		if s, ok := v.(string); ok {
+			return s, nil
+		}
+	case models.ParamEnum:
+		if s, ok := v.(string); ok && slices.Contains(p.Values, s) {
+			return s, nil
+		}
+
+		return "", fmt.Errorf("parameter %s must be one of %v", p.Name, p.Values)
+	case models.ParamInt:
+		switch n := v.(type) {
+		case json.Number:
+			if i, err := n.Int64(); err == nil {
+				return strconv.FormatInt(i, 10), nil
+			}
+		case float64:
+			if n == math.Trunc(n) && math.Abs(n) <= 1<<53 {
+				return strconv.FormatInt(int64(n), 10), nil
+			}
+		case string:
+			if i, err := strconv.ParseInt(n, 10, 64); err == nil {
+				return strconv.FormatInt(i, 10), nil
+			}
+		}
+	case models.ParamBool:
+		switch b := v.(type) {
+		case bool:
+			return strconv.FormatBool(b), nil
+		case string:
+			if parsed, err := strconv.ParseBool(b); err == nil {
+				return strconv.FormatBool(parsed), nil
+			}
+		}
+	}
+
+	return "", fmt.Errorf("parameter %s must be %s", p.Name, p.Type)
+}
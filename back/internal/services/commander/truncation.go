@@ -0,0 +1,79 @@
+package commander
+
+import (
+	"github.com/enchik0reo/commandApi/internal/config"
+	"github.com/enchik0reo/commandApi/internal/models"
+)
+
+const (
+	policyStop     = "stop"
+	policyDrop     = "drop"
+	policyHeadTail = "head_tail"
+)
+
+// outputLimiter decides which lines of the command's output are saved.
+// Stop and drop policies keep the head of the output up to the limits.
+// Head and tail policy keeps the first half of the limits as the head
+// and the last lines fitting the second half in the tail ring, halves are rounded up ...
+type outputLimiter struct {
+	policy    string
+	maxBytes  int64
+	maxLines  int64
+	bytes     int64
+	lines     int64
+	truncated bool
+	tail      []models.Output
+	tailBytes int64
+}
+
+// newOutputLimiter creates limiter of one command's output, zero or negative limit means no limit ...
+func newOutputLimiter(l config.OutputLimits) *outputLimiter {
+	lim := &outputLimiter{policy: l.Policy, maxBytes: l.MaxBytes, maxLines: l.MaxLines}
+
+	if lim.policy == policyHeadTail {
+		lim.maxBytes, lim.maxLines = (lim.maxBytes+1)/2, (lim.maxLines+1)/2
+	}
+
+	return lim
+}
+
+// admit returns true if the line must be saved now.
+// The first line over the limits truncates the output, lines after it aren't admitted.
+// With head and tail policy they are kept in the tail ring instead ...
+func (l *outputLimiter) admit(output models.Output) bool {
+	size := int64(len(output.Text))
+
+	if !l.truncated && l.fits(l.bytes+size, l.lines+1) {
+		l.bytes += size
+		l.lines++
+
+		return true
+	}
+
+	l.truncated = true
+
+	if l.policy == policyHeadTail {
+		l.tail = append(l.tail, output)
+		l.tailBytes += size
+
+		for len(l.tail) > 0 && !l.fits(l.tailBytes, int64(len(l.tail))) {
+			l.tailBytes -= int64(len(l.tail[0].Text))
+			l.tail = l.tail[1:]
+		}
+	}
+
+	return false
+}
+
+// rest returns lines of the tail ring and empties it ...
+func (l *outputLimiter) rest() []models.Output {
+	tail := l.tail
+	l.tail, l.tailBytes = nil, 0
+
+	return tail
+}
+
+// fits checks the count of bytes and lines against the limits ...
+func (l *outputLimiter) fits(bytes, lines int64) bool {
+	return (l.maxBytes <= 0 || bytes <= l.maxBytes) && (l.maxLines <= 0 || lines <= l.maxLines)
+}
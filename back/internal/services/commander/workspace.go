@@ -0,0 +1,112 @@
+package commander
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/enchik0reo/commandApi/internal/config"
+	"github.com/enchik0reo/commandApi/internal/logs"
+)
+
+const workspacePrefix = "cmd-"
+
+// workspaces manages temporary directories of commands ...
+type workspaces struct {
+	root      string
+	retention time.Duration
+	log       *logs.CustomLog
+}
+
+// newWorkspaces creates a new instance of workspaces.
+// Empty root means the directory in the system temporary one ...
+func newWorkspaces(l *logs.CustomLog, cfg config.Workspace) *workspaces {
+	root := cfg.Root
+	if root == "" {
+		root = filepath.Join(os.TempDir(), "commandApi")
+	}
+
+	return &workspaces{root: root, retention: cfg.Retention, log: l}
+}
+
+// path returns the workspace's path of the command ...
+func (w *workspaces) path(id int64) string {
+	return filepath.Join(w.root, fmt.Sprintf("%s%d", workspacePrefix, id))
+}
+
+// create makes an empty workspace for the command.
+// The workspace left from the previous run of the command is removed ...
+func (w *workspaces) create(id int64) (string, error) {
+	const op = "commander.workspaces.create"
+
+	if err := os.MkdirAll(w.root, 0o700); err != nil {
+		return "", fmt.Errorf("can't create workspaces root: %s: %v", op, err)
+	}
+
+	dir := w.path(id)
+
+	if err := os.RemoveAll(dir); err != nil {
+		return "", fmt.Errorf("can't remove old workspace: %s: %v", op, err)
+	}
+
+	if err := os.Mkdir(dir, 0o700); err != nil {
+		return "", fmt.Errorf("can't create workspace: %s: %v", op, err)
+	}
+
+	return dir, nil
+}
+
+// release removes the workspace now or after the retention period ...
+func (w *workspaces) release(dir string) {
+	w.removeAfter(dir, w.retention)
+}
+
+// prune removes workspaces which were left after the previous run of the service.
+// Workspaces whose retention period isn't over yet are removed later ...
+func (w *workspaces) prune() error {
+	const op = "commander.workspaces.prune"
+
+	entries, err := os.ReadDir(w.root)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil
+		}
+
+		return fmt.Errorf("can't read workspaces root: %s: %v", op, err)
+	}
+
+	for _, entry := range entries {
+		if !entry.IsDir() || !strings.HasPrefix(entry.Name(), workspacePrefix) {
+			continue
+		}
+
+		info, err := entry.Info()
+		if err != nil {
+			continue
+		}
+
+		w.removeAfter(filepath.Join(w.root, entry.Name()), w.retention-time.Since(info.ModTime()))
+	}
+
+	return nil
+}
+
+// removeAfter removes the workspace after the delay, non-positive delay removes it at once ...
+func (w *workspaces) removeAfter(dir string, delay time.Duration) {
+	const op = "commander.workspaces.remove"
+
+	remove := func() {
+		if err := os.RemoveAll(dir); err != nil {
+			w.log.Error("can't remove workspace", w.log.Attr("op", op), w.log.Attr("error", err))
+		}
+	}
+
+	if delay <= 0 {
+		remove()
+		return
+	}
+
+	time.AfterFunc(delay, remove)
+}
@@ -0,0 +1,132 @@
+package script
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/enchik0reo/commandApi/internal/models"
+)
+
+const (
+	// removeAttempts is how many times the cgroup is removed while its killed processes exit.
+	removeAttempts = 50
+	removeInterval = 20 * time.Millisecond
+)
+
+// cgroup is the control group of cgroup v2 where the script runs.
+// Memory and processes limits of the script are applied by the cgroup ...
+type cgroup struct {
+	path string
+	dir  *os.File
+}
+
+// newCgroup creates the child cgroup of the parent one and applies the limits to it.
+// Memory and pids controllers must be enabled in the parent ...
+func newCgroup(parent string, l models.Limits) (*cgroup, error) {
+	path, err := os.MkdirTemp(parent, "script-")
+	if err != nil {
+		return nil, err
+	}
+
+	g := &cgroup{path: path}
+
+	if l.MemoryMB > 0 {
+		err = g.write("memory.max", strconv.FormatInt(l.MemoryMB<<20, 10))
+	}
+
+	if err == nil && l.Processes > 0 {
+		err = g.write("pids.max", strconv.FormatInt(l.Processes, 10))
+	}
+
+	if err == nil {
+		g.dir, err = os.Open(path)
+	}
+
+	if err != nil {
+		_ = os.Remove(path)
+		return nil, err
+	}
+
+	return g, nil
+}
+
+// exceeded returns the limit which was exceeded in the cgroup by its events.
+// It returns empty string if limits weren't exceeded ...
+func (g *cgroup) exceeded(l models.Limits) string {
+	switch {
+	case l.MemoryMB > 0 && g.event("memory.events", "oom_kill") > 0:
+		return models.LimitMemory
+	case l.Processes > 0 && g.event("pids.events", "max") > 0:
+		return models.LimitProcesses
+	default:
+		return ""
+	}
+}
+
+// remove kills processes left in the cgroup and removes it ...
+func (g *cgroup) remove() error {
+	_ = g.dir.Close()
+
+	// cgroup.kill is missing before Linux 5.14, the cgroup can be removed only after its processes exit then.
+	if err := g.write("cgroup.kill", "1"); err != nil && !errors.Is(err, os.ErrNotExist) {
+		return err
+	}
+
+	var err error
+
+	for i := 0; i < removeAttempts; i++ {
+		if err = os.Remove(g.path); err == nil || errors.Is(err, os.ErrNotExist) {
+			return nil
+		}
+
+		time.Sleep(removeInterval)
+	}
+
+	return err
+}
+
+// write writes the value to the control file of the cgroup ...
+func (g *cgroup) write(file, value string) error {
+	f, err := os.OpenFile(filepath.Join(g.path, file), os.O_WRONLY|os.O_TRUNC, 0)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+
+	if _, err = f.WriteString(value); err != nil {
+		return fmt.Errorf("can't write %s: %v", file, err)
+	}
+
+	return nil
+}
+
+// event returns the counter of the event from the events file of the cgroup.
+// It returns zero if the file can't be read ...
+func (g *cgroup) event(file, name string) int64 {
+	f, err := os.Open(filepath.Join(g.path, file))
+	if err != nil {
+		return 0
+	}
+	defer f.Close()
+
+	scanner := bufio.NewScanner(f)
+
+	for scanner.Scan() {
+		key, value, ok := strings.Cut(scanner.Text(), " ")
+		if !ok || key != name {
+			continue
+		}
+
+		n, _ := strconv.ParseInt(value, 10, 64)
+
+		return n
+	}
+
+	return 0
+}
@@ -0,0 +1,69 @@
+package script
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/enchik0reo/commandApi/internal/models"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestCgroup_exceeded(t *testing.T) {
+	tests := []struct {
+		name   string
+		limits models.Limits
+		events map[string]string
+		want   string
+	}{
+		{
+			name:   "test_1, memory limit",
+			limits: models.Limits{MemoryMB: 64, Processes: 16},
+			events: map[string]string{"memory.events": "low 0\nhigh 0\nmax 4\noom 1\noom_kill 1\n", "pids.events": "max 0\n"},
+			want:   models.LimitMemory,
+		},
+		{
+			name:   "test_2, processes limit",
+			limits: models.Limits{MemoryMB: 64, Processes: 16},
+			events: map[string]string{"memory.events": "max 4\noom 0\noom_kill 0\n", "pids.events": "max 3\n"},
+			want:   models.LimitProcesses,
+		},
+		{
+			name:   "test_3, limit isn't applied",
+			limits: models.Limits{MemoryMB: 64},
+			events: map[string]string{"pids.events": "max 3\n"},
+			want:   "",
+		},
+		{
+			name:   "test_4, no events",
+			limits: models.Limits{MemoryMB: 64, Processes: 16},
+			want:   "",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			g := &cgroup{path: t.TempDir()}
+
+			for file, events := range tt.events {
+				require.NoError(t, os.WriteFile(filepath.Join(g.path, file), []byte(events), 0o644))
+			}
+
+			require.Equal(t, tt.want, g.exceeded(tt.limits))
+		})
+	}
+}
+
+func TestCgroup_write(t *testing.T) {
+	g := &cgroup{path: t.TempDir()}
+
+	require.ErrorIs(t, g.write("pids.max", "16"), os.ErrNotExist)
+
+	require.NoError(t, os.WriteFile(filepath.Join(g.path, "pids.max"), []byte("max\n"), 0o644))
+	require.NoError(t, g.write("pids.max", "16"))
+
+	got, err := os.ReadFile(filepath.Join(g.path, "pids.max"))
+	require.NoError(t, err)
+	require.Equal(t, "16", string(got))
+}
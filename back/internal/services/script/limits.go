@@ -0,0 +1,137 @@
+package script
+
+import (
+	"fmt"
+	"math"
+	"os"
+	"strings"
+	"sync"
+	"syscall"
+
+	"github.com/enchik0reo/commandApi/internal/models"
+)
+
+// limitMessages are the signs of exceeded limits in the script's stderr.
+// Limits applied by rlimits fail system calls instead of sending signals,
+// so the messages are the best effort to find out such limits, programs may word them differently.
+var limitMessages = []struct {
+	limit string
+	text  []string
+}{
+	{limit: models.LimitCPUTime, text: []string{"cpu time limit exceeded"}},
+	{limit: models.LimitMemory, text: []string{"cannot allocate"}},
+	{limit: models.LimitMemory, text: []string{"out of memory"}},
+	{limit: models.LimitMemory, text: []string{"memoryerror"}},
+	{limit: models.LimitOpenFiles, text: []string{"too many open files"}},
+	{limit: models.LimitProcesses, text: []string{"fork", "resource temporarily unavailable"}},
+}
+
+// limitsPrologue returns the shell command which applies the limits to the shell
+// and all its descendants. It returns empty string if there's nothing to limit.
+// Hard CPU limit is a second more than the soft one so the script gets SIGXCPU first.
+// Processes limit isn't applied, RLIMIT_NPROC counts all processes of the user ...
+func limitsPrologue(l models.Limits) string {
+	cmds := []string{}
+
+	if l.CPUTime > 0 {
+		seconds := int64(math.Ceil(l.CPUTime.Seconds()))
+		cmds = append(cmds, fmt.Sprintf("ulimit -S -t %d", seconds), fmt.Sprintf("ulimit -H -t %d", seconds+1))
+	}
+
+	if l.MemoryMB > 0 {
+		cmds = append(cmds, fmt.Sprintf("ulimit -v %d", l.MemoryMB*1024))
+	}
+
+	if l.OpenFiles > 0 {
+		cmds = append(cmds, fmt.Sprintf("ulimit -n %d", l.OpenFiles))
+	}
+
+	return strings.Join(cmds, " && ")
+}
+
+// limitWatcher finds out which limit was exceeded by the script ...
+type limitWatcher struct {
+	limits models.Limits
+	cgroup *cgroup
+
+	mu    sync.Mutex
+	limit string
+}
+
+func newLimitWatcher(l models.Limits, g *cgroup) *limitWatcher {
+	return &limitWatcher{limits: l, cgroup: g}
+}
+
+// check looks for the sign of exceeded limit in the line of output.
+// Only the first exceeded limit is kept ...
+func (w *limitWatcher) check(line string) {
+	line = strings.ToLower(line)
+
+	for _, msg := range limitMessages {
+		if !w.isLimited(msg.limit) || !containsAll(line, msg.text) {
+			continue
+		}
+
+		w.mu.Lock()
+		if w.limit == "" {
+			w.limit = msg.limit
+		}
+		w.mu.Unlock()
+
+		return
+	}
+}
+
+// exceeded returns the limit which was exceeded by the script finished by the signal.
+// CPU limit is found out by the signal and the used CPU time, memory and processes limits
+// by events of the cgroup if the script runs in it. Messages of stderr are checked for the rest.
+// It returns empty string if limits weren't exceeded ...
+func (w *limitWatcher) exceeded(sig syscall.Signal, state *os.ProcessState) string {
+	if w.limits.CPUTime > 0 {
+		switch sig {
+		case syscall.SIGXCPU:
+			return models.LimitCPUTime
+		case syscall.SIGKILL:
+			if state.UserTime()+state.SystemTime() >= w.limits.CPUTime {
+				return models.LimitCPUTime
+			}
+		}
+	}
+
+	if w.cgroup != nil {
+		if limit := w.cgroup.exceeded(w.limits); limit != "" {
+			return limit
+		}
+	}
+
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	return w.limit
+}
+
+// isLimited reports whether the limit is applied to the script ...
+func (w *limitWatcher) isLimited(limit string) bool {
+	switch limit {
+	case models.LimitCPUTime:
+		return w.limits.CPUTime > 0
+	case models.LimitMemory:
+		return w.limits.MemoryMB > 0
+	case models.LimitOpenFiles:
+		return w.limits.OpenFiles > 0
+	case models.LimitProcesses:
+		return w.limits.Processes > 0
+	default:
+		return false
+	}
+}
+
+func containsAll(s string, substrs []string) bool {
+	for _, substr := range substrs {
+		if !strings.Contains(s, substr) {
+			return false
+		}
+	}
+
+	return true
+}
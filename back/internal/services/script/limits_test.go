@@ -0,0 +1,150 @@
+package script
+
+import (
+	"os"
+	"os/exec"
+	"path/filepath"
+	"syscall"
+	"testing"
+	"time"
+
+	"github.com/enchik0reo/commandApi/internal/models"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestLimitsPrologue(t *testing.T) {
+	tests := []struct {
+		name  string
+		input models.Limits
+		want  string
+	}{
+		{
+			name:  "test_1, no limits",
+			input: models.Limits{},
+			want:  "",
+		},
+		{
+			name:  "test_2, all limits",
+			input: models.Limits{CPUTime: 1500 * time.Millisecond, MemoryMB: 64, OpenFiles: 32, Processes: 16},
+			want:  "ulimit -S -t 2 && ulimit -H -t 3 && ulimit -v 65536 && ulimit -n 32",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := limitsPrologue(tt.input)
+
+			require.Equal(t, tt.want, got)
+		})
+	}
+}
+
+func TestLimitWatcher_check(t *testing.T) {
+	tests := []struct {
+		name   string
+		limits models.Limits
+		lines  []string
+		want   string
+	}{
+		{
+			name:   "test_1, memory limit",
+			limits: models.Limits{MemoryMB: 64},
+			lines:  []string{"some output", "/bin/bash: xmalloc: cannot allocate 1024 bytes"},
+			want:   models.LimitMemory,
+		},
+		{
+			name:   "test_2, processes limit",
+			limits: models.Limits{Processes: 16},
+			lines:  []string{"/bin/bash: fork: retry: Resource temporarily unavailable"},
+			want:   models.LimitProcesses,
+		},
+		{
+			name:   "test_3, first exceeded limit is kept",
+			limits: models.Limits{OpenFiles: 16, MemoryMB: 64},
+			lines:  []string{"OSError: [Errno 24] Too many open files", "MemoryError"},
+			want:   models.LimitOpenFiles,
+		},
+		{
+			name:   "test_4, limit isn't applied",
+			limits: models.Limits{},
+			lines:  []string{"MemoryError"},
+			want:   "",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			w := newLimitWatcher(tt.limits, nil)
+
+			for _, line := range tt.lines {
+				w.check(line)
+			}
+
+			require.Equal(t, tt.want, w.limit)
+		})
+	}
+}
+
+func TestLimitWatcher_exceeded(t *testing.T) {
+	cmd := exec.Command("true")
+	require.NoError(t, cmd.Run())
+
+	dir := t.TempDir()
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "pids.events"), []byte("max 2\n"), 0o644))
+
+	tests := []struct {
+		name   string
+		limits models.Limits
+		cgroup *cgroup
+		sig    syscall.Signal
+		lines  []string
+		want   string
+	}{
+		{
+			name:   "test_1, cpu limit by signal",
+			limits: models.Limits{CPUTime: time.Second},
+			sig:    syscall.SIGXCPU,
+			want:   models.LimitCPUTime,
+		},
+		{
+			name:   "test_2, killed before cpu limit",
+			limits: models.Limits{CPUTime: time.Second},
+			sig:    syscall.SIGKILL,
+			want:   "",
+		},
+		{
+			name:   "test_3, processes limit by cgroup events",
+			limits: models.Limits{Processes: 2},
+			cgroup: &cgroup{path: dir},
+			lines:  []string{"Too many open files"},
+			want:   models.LimitProcesses,
+		},
+		{
+			name:   "test_4, memory limit by stderr without cgroup events",
+			limits: models.Limits{MemoryMB: 64},
+			cgroup: &cgroup{path: dir},
+			lines:  []string{"MemoryError"},
+			want:   models.LimitMemory,
+		},
+		{
+			name:   "test_5, no limits",
+			limits: models.Limits{},
+			cgroup: &cgroup{path: dir},
+			sig:    syscall.SIGXCPU,
+			want:   "",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			w := newLimitWatcher(tt.limits, tt.cgroup)
+
+			for _, line := range tt.lines {
+				w.check(line)
+			}
+
+			require.Equal(t, tt.want, w.exceeded(tt.sig, cmd.ProcessState))
+		})
+	}
+}
@@ -0,0 +1,133 @@
+package script
+
+import (
+	"fmt"
+	"os"
+	"os/exec"
+	"strings"
+	"syscall"
+
+	"github.com/enchik0reo/commandApi/internal/config"
+	"github.com/enchik0reo/commandApi/internal/logs"
+	"github.com/enchik0reo/commandApi/internal/models"
+
+	"golang.org/x/sys/unix"
+)
+
+const (
+	// sandboxDir is the private temporary directory of the sandboxed script.
+	sandboxDir = "/tmp"
+	// sandboxWorkspace is the path where the script's workspace is mounted in the sandbox.
+	sandboxWorkspace = sandboxDir + "/workspace"
+	// dropPrivileges runs the command without capabilities and the ability to gain them,
+	// so the script can't change mounts of the sandbox even if it's run as root
+	dropPrivileges = "setpriv --no-new-privs --inh-caps=-all --ambient-caps=-all --bounding-set=-all --"
+)
+
+// NewSandboxExecutor creates a new instance of Executor which runs every script
+// in new mount, PID, UTS and IPC namespaces, optionally without network.
+// The script sees read-only root, private tmpfs temporary directory
+// and its writable workspace inside the temporary one.
+// The script runs without capabilities, so it can't mount anything ...
+func NewSandboxExecutor(log *logs.CustomLog, cfg *config.Executor) *Executor {
+	e := &Executor{log: log, cfg: cfg, exitStatus: sandboxExitStatus}
+
+	e.command = func(script models.Script) *exec.Cmd {
+		return sandboxCommand(script, &cfg.Sandbox)
+	}
+
+	return e
+}
+
+// sandboxCommand returns the command which runs the script in the sandbox.
+// The first process of the sandbox prepares mounts and waits for the script,
+// so the script doesn't become the init process of PID namespace
+// and handles signals as usual. Capabilities are dropped after mounts are prepared ...
+func sandboxCommand(script models.Script, cfg *config.Sandbox) *exec.Cmd {
+	prologue := []string{sandboxPrologue(script, cfg)}
+
+	if limits := limitsPrologue(script.Limits); limits != "" {
+		prologue = append(prologue, limits)
+	}
+
+	args := append([]string{"-c", strings.Join(prologue, " && ") + " && " + dropPrivileges + ` "$@"; exit $?`, "bash"}, scriptArgs(script)...)
+
+	cmd := exec.Command("/bin/bash", args...)
+
+	cmd.Dir = "/"
+	if script.WorkingDir != "" {
+		cmd.Dir = script.WorkingDir
+	}
+	cmd.SysProcAttr = &syscall.SysProcAttr{
+		Cloneflags: syscall.CLONE_NEWNS | syscall.CLONE_NEWPID | syscall.CLONE_NEWUTS | syscall.CLONE_NEWIPC,
+	}
+
+	if cfg.NoNetwork {
+		cmd.SysProcAttr.Cloneflags |= syscall.CLONE_NEWNET
+	}
+
+	if cfg.UserNamespace {
+		cmd.SysProcAttr.Cloneflags |= syscall.CLONE_NEWUSER
+		cmd.SysProcAttr.UidMappings = []syscall.SysProcIDMap{{ContainerID: 0, HostID: os.Getuid(), Size: 1}}
+		cmd.SysProcAttr.GidMappings = []syscall.SysProcIDMap{{ContainerID: 0, HostID: os.Getgid(), Size: 1}}
+	}
+
+	return cmd
+}
+
+// sandboxPrologue returns the shell command which prepares mounts of the sandbox:
+// private tmpfs temporary directory, read-only root and all other mounts
+// except the pseudo filesystems, and proc of the new PID namespace.
+// The workspace is opened before it's hidden by tmpfs and mounted back from the descriptor.
+// The script starts in the workspace unless its working directory is set ...
+func sandboxPrologue(script models.Script, cfg *config.Sandbox) string {
+	var steps []string
+
+	if script.Workspace != "" {
+		steps = append(steps, `exec 3<"$`+WorkspaceEnv+`"`)
+	}
+
+	steps = append(steps,
+		"mount --make-rprivate /",
+		fmt.Sprintf("mount -t tmpfs -o size=%s,mode=1777 tmpfs %s", cfg.TmpfsSize, sandboxDir),
+		"mount -o remount,bind,ro /",
+		`for m in $(awk '{print $2}' /proc/mounts); do case "$m" in /|/proc*|/sys*|/dev*|`+sandboxDir+
+			`) ;; *) mount -o remount,bind,ro "$m" 2>/dev/null ;; esac; done`,
+		"mount -t proc proc /proc",
+		"{ hostname sandbox 2>/dev/null; true; }",
+	)
+
+	dir := sandboxDir
+
+	if script.Workspace != "" {
+		dir = sandboxWorkspace
+
+		steps = append(steps,
+			"mkdir "+sandboxWorkspace,
+			"mount --no-canonicalize --bind /proc/self/fd/3 "+sandboxWorkspace,
+			"mount -o remount,bind,rw "+sandboxWorkspace,
+			"exec 3<&-",
+			"export "+WorkspaceEnv+"="+sandboxWorkspace,
+		)
+	}
+
+	if script.WorkingDir == "" {
+		steps = append(steps, "cd "+dir)
+	}
+
+	return strings.Join(steps, " && ")
+}
+
+// sandboxExitStatus returns exit code and terminating signal of the sandboxed script.
+// The first process of the sandbox isn't killed by the signals of the script,
+// it exits with 128 plus the number of the signal which killed the script like shells do.
+// Such code is reported as the signal, even if the script exits with it itself ...
+func sandboxExitStatus(state *os.ProcessState) (int, syscall.Signal) {
+	code, sig := exitStatus(state)
+
+	if sig == 0 && code > 128 && unix.SignalName(syscall.Signal(code-128)) != "" {
+		return -1, syscall.Signal(code - 128)
+	}
+
+	return code, sig
+}
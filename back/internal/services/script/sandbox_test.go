@@ -0,0 +1,151 @@
+package script
+
+import (
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"syscall"
+	"testing"
+	"time"
+
+	"github.com/enchik0reo/commandApi/internal/config"
+	"github.com/enchik0reo/commandApi/internal/logs"
+	"github.com/enchik0reo/commandApi/internal/models"
+	"github.com/enchik0reo/commandApi/internal/services"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestSandboxCommand(t *testing.T) {
+	namespaces := uintptr(syscall.CLONE_NEWNS | syscall.CLONE_NEWPID | syscall.CLONE_NEWUTS | syscall.CLONE_NEWIPC)
+
+	tests := []struct {
+		name      string
+		cfg       config.Sandbox
+		wantFlags uintptr
+		wantUsers bool
+	}{
+		{
+			name:      "test_1, with network",
+			cfg:       config.Sandbox{TmpfsSize: "16m"},
+			wantFlags: namespaces,
+		},
+		{
+			name:      "test_2, without network",
+			cfg:       config.Sandbox{TmpfsSize: "16m", NoNetwork: true},
+			wantFlags: namespaces | syscall.CLONE_NEWNET,
+		},
+		{
+			name:      "test_3, with user namespace",
+			cfg:       config.Sandbox{TmpfsSize: "16m", UserNamespace: true},
+			wantFlags: namespaces | syscall.CLONE_NEWUSER,
+			wantUsers: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cmd := sandboxCommand(models.Script{Body: "echo $1", Options: models.Options{Args: []string{"hi"}}}, &tt.cfg)
+
+			require.Equal(t, tt.wantFlags, cmd.SysProcAttr.Cloneflags)
+			require.Equal(t, tt.wantUsers, len(cmd.SysProcAttr.UidMappings) > 0)
+			require.Equal(t, []string{"/bin/bash", "-c", "echo $1", "bash", "hi"}, cmd.Args[len(cmd.Args)-5:])
+		})
+	}
+}
+
+func TestSandboxPrologue(t *testing.T) {
+	cfg := &config.Sandbox{TmpfsSize: "16m"}
+
+	got := sandboxPrologue(models.Script{Body: "ls"}, cfg)
+	require.True(t, strings.HasSuffix(got, " && cd /tmp"))
+
+	got = sandboxPrologue(models.Script{Body: "ls", Workspace: "/tmp/ws"}, cfg)
+	require.True(t, strings.HasPrefix(got, `exec 3<"$SCRIPT_WORKSPACE" && `))
+	require.Contains(t, got, "mount --no-canonicalize --bind /proc/self/fd/3 /tmp/workspace")
+	require.True(t, strings.HasSuffix(got, " && export SCRIPT_WORKSPACE=/tmp/workspace && cd /tmp/workspace"))
+
+	got = sandboxPrologue(models.Script{Body: "ls", Workspace: "/tmp/ws", Options: models.Options{WorkingDir: "/srv"}}, cfg)
+	require.NotContains(t, got, " && cd ")
+}
+
+func TestSandboxExecutor_RunScript(t *testing.T) {
+	if os.Geteuid() != 0 {
+		t.Skip("sandbox requires root")
+	}
+
+	if _, err := exec.LookPath("setpriv"); err != nil {
+		t.Skip("sandbox requires setpriv")
+	}
+
+	// Temporary directory is hidden in the sandbox, so the outside directory is created in /var/tmp.
+	outside, err := os.MkdirTemp("/var/tmp", "outside")
+	if err != nil {
+		t.Skipf("can't create outside directory: %v", err)
+	}
+	t.Cleanup(func() { os.RemoveAll(outside) })
+
+	outside, err = filepath.EvalSymlinks(outside)
+	require.NoError(t, err)
+
+	if strings.HasPrefix(outside+"/", sandboxDir+"/") {
+		t.Skipf("outside directory %s is hidden in the sandbox", outside)
+	}
+
+	e := NewSandboxExecutor(logs.NewDiscardLogger(),
+		&config.Executor{GracePeriod: time.Second, MaxLineBytes: 1024, Sandbox: config.Sandbox{TmpfsSize: "16m"}})
+
+	tests := []struct {
+		name     string
+		body     string
+		sig      syscall.Signal
+		want     []models.Output
+		wantErr  error
+		wantFile string
+	}{
+		{
+			name:    "test_1, write outside workspace fails",
+			body:    "echo x > " + outside + "/file",
+			want:    []models.Output{{Stream: models.StreamStderr, Text: "bash: line 1: " + outside + "/file: Read-only file system"}},
+			wantErr: &services.ExitError{Code: 1},
+		},
+		{
+			name:    "test_2, root can't be remounted",
+			body:    "mount -o remount,rw / 2>/dev/null || exit 3; echo x > " + outside + "/file",
+			wantErr: &services.ExitError{Code: 3},
+		},
+		{
+			name:     "test_3, write into workspace",
+			body:     `echo x > "$SCRIPT_WORKSPACE/file" && pwd`,
+			want:     []models.Output{{Stream: models.StreamStdout, Text: sandboxWorkspace}},
+			wantFile: "file",
+		},
+		{
+			name: "test_4, stopped script is terminated by signal",
+			body: "echo started; sleep 10",
+			sig:  syscall.SIGTERM,
+			want: []models.Output{
+				{Stream: models.StreamStdout, Text: "started"},
+				{Stream: models.StreamStderr, Text: "Terminated"},
+			},
+			wantErr: &services.ExitError{Code: -1, Signal: "SIGTERM", Err: services.ErrStoppedManually},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			workspace := t.TempDir()
+
+			got, err := runScript(e, models.Script{Body: tt.body, Workspace: workspace}, tt.sig)
+
+			require.Equal(t, tt.want, got)
+			require.Equal(t, tt.wantErr, err)
+			require.NoFileExists(t, filepath.Join(outside, "file"))
+
+			if tt.wantFile != "" {
+				require.FileExists(t, filepath.Join(workspace, tt.wantFile))
+			}
+		})
+	}
+}
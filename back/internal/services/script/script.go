@@ -2,30 +2,62 @@ package script
 
 import (
 	"bufio"
+	"bytes"
+	"encoding/base64"
+	"errors"
 	"fmt"
+	"io"
+	"os"
 	"os/exec"
+	"sort"
+	"sync"
 	"sync/atomic"
+	"syscall"
+	"time"
+	"unicode/utf8"
 
+	"github.com/enchik0reo/commandApi/internal/config"
 	"github.com/enchik0reo/commandApi/internal/logs"
+	"github.com/enchik0reo/commandApi/internal/models"
 	"github.com/enchik0reo/commandApi/internal/services"
+
+	"golang.org/x/sys/unix"
 )
 
+// WorkspaceEnv is the name of environment variable with the path of the script's workspace ...
+const WorkspaceEnv = "SCRIPT_WORKSPACE"
+
 type Executor struct {
-	log *logs.CustomLog
+	log        *logs.CustomLog
+	cfg        *config.Executor
+	command    func(models.Script) *exec.Cmd
+	exitStatus func(*os.ProcessState) (int, syscall.Signal)
+}
+
+// NewExecutor creates a new instance of Executor.
+// Scripts are run as the server's own user ...
+func NewExecutor(log *logs.CustomLog, cfg *config.Executor) *Executor {
+	return &Executor{log: log, cfg: cfg, command: scriptCommand, exitStatus: exitStatus}
 }
 
-// NewExecutor creates a new instance of Executor ...
-func NewExecutor(log *logs.CustomLog) *Executor {
-	return &Executor{log: log}
+// HasCgroup reports whether scripts run in their own cgroups which apply memory and processes limits ...
+func (e *Executor) HasCgroup() bool {
+	return e.cfg.Cgroup != ""
 }
 
 // RunScript executing script.
-// It returns channels for use in new gorutine ...
-func (e *Executor) RunScript(script, scriptName string, stop <-chan struct{}) (<-chan string, <-chan error) {
+// It returns channels for use in new gorutine.
+// Every line of stdout and stderr is tagged with the stream it came from.
+// Interactive script reads its stdin from the stdin channel, closing the channel sends EOF.
+// A signal from the stop channel is sent to the whole process group of the script,
+// the group is killed if it is still alive after the grace period.
+// If the cgroup is configured, the script runs in its own child cgroup
+// and processes left there are killed when the script ends ...
+func (e *Executor) RunScript(script models.Script, stop <-chan syscall.Signal, stdin <-chan []byte) (<-chan models.Output, <-chan error) {
 	const op = "script.StartScript"
 	var manualStopFlag int32 = 0
 
-	out := make(chan string)
+	out := make(chan models.Output)
 	errOut := make(chan error)
 
 	go func() {
@@ -34,7 +66,58 @@ func (e *Executor) RunScript(script, scriptName string, stop <-chan struct{}) (<
 			close(out)
 		}()
 
-		cmd := exec.Command("/bin/bash", "-c", script)
+		limits := script.Limits
+
+		var group *cgroup
+
+		if e.cfg.Cgroup != "" {
+			var err error
+
+			if group, err = newCgroup(e.cfg.Cgroup, limits); err != nil {
+				errOut <- fmt.Errorf("can't create cgroup: %s: %v", op, err)
+				return
+			}
+			defer func() {
+				if err := group.remove(); err != nil {
+					e.log.Error("can't remove cgroup", e.log.Attr("op", op), e.log.Attr("error", err))
+				}
+			}()
+
+			// The cgroup limits memory used by the script instead of its address space.
+			script.Limits.MemoryMB = 0
+		} else if limits.Processes > 0 {
+			errOut <- fmt.Errorf("can't limit processes without cgroup: %s", op)
+			return
+		} else if limits.MemoryMB > 0 && script.UnlimitedAddressSpace {
+			errOut <- fmt.Errorf("can't limit memory of unlimited address space without cgroup: %s", op)
+			return
+		}
+
+		cmd := e.command(script)
+		cmd.Env = scriptEnv(script)
+
+		if cmd.SysProcAttr == nil {
+			cmd.SysProcAttr = &syscall.SysProcAttr{}
+		}
+		// The script gets its own process group so that its children can be killed with it.
+		cmd.SysProcAttr.Setpgid = true
+
+		if group != nil {
+			cmd.SysProcAttr.UseCgroupFD = true
+			cmd.SysProcAttr.CgroupFD = int(group.dir.Fd())
+		}
+
+		var stdinPipe io.WriteCloser
+
+		if script.Interactive {
+			var err error
+
+			stdinPipe, err = cmd.StdinPipe()
+			if err != nil {
+				errOut <- fmt.Errorf("can't do stdin pipe: %s: %v", op, err)
+				return
+			}
+		}
 
 		stdout, err := cmd.StdoutPipe()
 		if err != nil {
@@ -42,49 +125,275 @@ func (e *Executor) RunScript(script, scriptName string, stop <-chan struct{}) (<
 			return
 		}
 
+		stderr, err := cmd.StderrPipe()
+		if err != nil {
+			errOut <- fmt.Errorf("can't do stderr pipe: %s: %v", op, err)
+			return
+		}
+
 		if err := cmd.Start(); err != nil {
 			errOut <- fmt.Errorf("can't start script: %s: %v", op, err)
 			return
 		}
 
-		scanner := bufio.NewScanner(stdout)
+		if stdinPipe != nil {
+			go writeInput(stdinPipe, stdin)
+		}
+
+		exited := make(chan struct{})
+
+		kill := func() {
+			if err := syscall.Kill(-cmd.Process.Pid, syscall.SIGKILL); err != nil && !errors.Is(err, syscall.ESRCH) {
+				e.log.Error("can't kill process group", e.log.Attr("op", op), e.log.Attr("error", err))
+			}
+
+			// Processes which left the group may still hold the pipes.
+			_ = stdout.Close()
+			_ = stderr.Close()
+		}
 
 		go func() {
-			for v := range stop {
-				if v == struct{}{} {
-					e.log.Debug("script stopped manually", e.log.Attr("op", op), e.log.Attr("script", scriptName))
+			for {
+				select {
+				case sig := <-stop:
+					e.log.Debug("script stopped manually", e.log.Attr("op", op),
+						e.log.Attr("script", script.Name), e.log.Attr("signal", unix.SignalName(sig)))
 
-					if err := stdout.Close(); err != nil {
-						errOut <- fmt.Errorf("can't close stdout pipe: %s: %v", op, err)
-					}
+					atomic.StoreInt32(&manualStopFlag, 1)
 
-					atomic.AddInt32(&manualStopFlag, 1)
+					if sig == syscall.SIGKILL {
+						kill()
+						continue
+					}
 
-					if err := cmd.Process.Kill(); err != nil {
-						errOut <- fmt.Errorf("can't kill process: %s: %v", op, err)
+					if err := syscall.Kill(-cmd.Process.Pid, sig); err != nil && !errors.Is(err, syscall.ESRCH) {
+						e.log.Error("can't signal process group", e.log.Attr("op", op), e.log.Attr("error", err))
 					}
+
+					go func() {
+						select {
+						case <-exited:
+						case <-time.After(e.cfg.GracePeriod):
+							e.log.Debug("grace period is over", e.log.Attr("op", op), e.log.Attr("script", script.Name))
+
+							kill()
+						}
+					}()
+				case <-exited:
+					return
 				}
 			}
 		}()
 
-		for scanner.Scan() {
-			out <- scanner.Text()
-		}
+		watcher := newLimitWatcher(limits, group)
 
-		process, err := cmd.Process.Wait()
-		if err != nil {
-			errOut <- fmt.Errorf("can't wait process: %v", err)
+		wg := &sync.WaitGroup{}
+		wg.Add(2)
+
+		go scanOutput(stdout, models.StreamStdout, e.cfg.MaxLineBytes, out, wg, nil)
+		go scanOutput(stderr, models.StreamStderr, e.cfg.MaxLineBytes, out, wg, watcher.check)
+
+		wg.Wait()
+
+		err = cmd.Wait()
+		close(exited)
+
+		if err != nil && cmd.ProcessState == nil {
+			errOut <- fmt.Errorf("can't wait process: %s: %v", op, err)
+			return
 		}
 
-		success := process.Success()
-		if !success {
-			if manualStopFlag == 1 {
-				errOut <- services.ErrStoppedManually
+		// The script may handle the signal and exit successfully, it is still stopped then.
+		if !cmd.ProcessState.Success() || atomic.LoadInt32(&manualStopFlag) == 1 {
+			code, sig := e.exitStatus(cmd.ProcessState)
+			exitErr := exitError(code, sig)
+
+			if atomic.LoadInt32(&manualStopFlag) == 1 {
+				exitErr.Err = services.ErrStoppedManually
 			} else {
-				errOut <- fmt.Errorf("can't execute script")
+				exitErr.Limit = watcher.exceeded(sig, cmd.ProcessState)
 			}
+
+			errOut <- exitErr
 		}
 	}()
 
 	return out, errOut
 }
+
+// scriptCommand returns the command which runs the script.
+// Resource limits are applied by the shell before the script starts ...
+func scriptCommand(script models.Script) *exec.Cmd {
+	args := scriptArgs(script)
+
+	if prologue := limitsPrologue(script.Limits); prologue != "" {
+		args = append([]string{"/bin/bash", "-c", prologue + ` && exec "$@"`, "bash"}, args...)
+	}
+
+	cmd := exec.Command(args[0], args[1:]...)
+
+	cmd.Dir = script.WorkingDir
+	if cmd.Dir == "" {
+		cmd.Dir = script.Workspace
+	}
+
+	return cmd
+}
+
+// scriptArgs returns the command line of the script with its positional arguments.
+// The script is run by bash if its invocation isn't set ...
+func scriptArgs(script models.Script) []string {
+	args := []string{"/bin/bash", "-c", script.Body, "bash"}
+
+	if len(script.Invocation) > 0 {
+		args = append([]string{}, script.Invocation...)
+	}
+
+	return append(args, script.Args...)
+}
+
+// scriptEnv returns the server's environment with the script's variables
+// and the path of its workspace added.
+// It returns nil if the script has no variables, the environment is inherited then ...
+func scriptEnv(script models.Script) []string {
+	if len(script.Env) == 0 && len(script.SecretEnv) == 0 && script.Workspace == "" {
+		return nil
+	}
+
+	env := os.Environ()
+
+	for _, vars := range []map[string]string{script.Env, script.SecretEnv} {
+		names := make([]string, 0, len(vars))
+
+		for name := range vars {
+			names = append(names, name)
+		}
+
+		sort.Strings(names)
+
+		for _, name := range names {
+			env = append(env, name+"="+vars[name])
+		}
+	}
+
+	if script.Workspace != "" {
+		env = append(env, WorkspaceEnv+"="+script.Workspace)
+	}
+
+	return env
+}
+
+// scanOutput reads lines from the pipe and sends them tagged with the stream name.
+// Lines longer than maxLine bytes are split into parts, binary lines are encoded with base64.
+// Every line is passed to the check function if it's set.
+// A read error is sent as the line of stderr and the rest of the pipe is discarded ...
+func scanOutput(pipe io.Reader, stream string, maxLine int, out chan<- models.Output, wg *sync.WaitGroup,
+	check func(string)) {
+	defer wg.Done()
+
+	if maxLine <= 0 {
+		maxLine = bufio.MaxScanTokenSize
+	}
+
+	partial := false
+
+	scanner := bufio.NewScanner(pipe)
+	scanner.Buffer(make([]byte, 0, 4096), maxLine+1)
+	scanner.Split(splitLines(maxLine, &partial))
+
+	for scanner.Scan() {
+		if check != nil {
+			check(scanner.Text())
+		}
+
+		out <- outputLine(stream, scanner.Bytes(), partial)
+	}
+
+	if err := scanner.Err(); err != nil && !errors.Is(err, os.ErrClosed) {
+		out <- models.Output{
+			Stream: models.StreamStderr,
+			Text:   fmt.Sprintf("Can't read %s of the script: %v", stream, err),
+		}
+
+		// The script mustn't block on writing to the pipe which isn't read.
+		_, _ = io.Copy(io.Discard, pipe)
+	}
+}
+
+// splitLines returns split function of lines without line endings.
+// A line longer than max bytes is cut on the boundary of UTF-8 character and the part is marked as partial ...
+func splitLines(max int, partial *bool) bufio.SplitFunc {
+	return func(data []byte, atEOF bool) (int, []byte, error) {
+		*partial = false
+
+		if i := bytes.IndexByte(data, '\n'); i >= 0 && i <= max {
+			return i + 1, bytes.TrimSuffix(data[:i], []byte("\r")), nil
+		}
+
+		if len(data) > max {
+			*partial = true
+
+			n := max
+			for i := max; i > max-utf8.UTFMax && i > 0; i-- {
+				if utf8.RuneStart(data[i]) {
+					n = i
+					break
+				}
+			}
+
+			return n, data[:n], nil
+		}
+
+		if atEOF && len(data) > 0 {
+			return len(data), bytes.TrimSuffix(data, []byte("\r")), nil
+		}
+
+		return 0, nil, nil
+	}
+}
+
+// outputLine returns the line of the output, text which isn't valid UTF-8 or has NUL bytes is encoded with base64 ...
+func outputLine(stream string, line []byte, partial bool) models.Output {
+	if utf8.Valid(line) && bytes.IndexByte(line, 0) < 0 {
+		return models.Output{Stream: stream, Text: string(line), Partial: partial}
+	}
+
+	return models.Output{
+		Stream:   stream,
+		Text:     base64.StdEncoding.EncodeToString(line),
+		Encoding: models.EncodingBase64,
+		Partial:  partial,
+	}
+}
+
+// writeInput forwards data to the script's stdin.
+// It closes stdin when the channel is closed ...
+func writeInput(pipe io.WriteCloser, stdin <-chan []byte) {
+	defer pipe.Close()
+
+	for data := range stdin {
+		// The script may exit or close its stdin, the rest of input is dropped then.
+		_, _ = pipe.Write(data)
+	}
+}
+
+// exitStatus returns exit code and terminating signal of the finished process,
+// the signal is zero if the process has exited ...
+func exitStatus(state *os.ProcessState) (int, syscall.Signal) {
+	if status, ok := state.Sys().(syscall.WaitStatus); ok && status.Signaled() {
+		return state.ExitCode(), status.Signal()
+	}
+
+	return state.ExitCode(), 0
+}
+
+// exitError returns the error with exit code and terminating signal of the script ...
+func exitError(code int, sig syscall.Signal) *services.ExitError {
+	exitErr := &services.ExitError{Code: code}
+
+	if sig != 0 {
+		exitErr.Signal = unix.SignalName(sig)
+	}
+
+	return exitErr
+}
@@ -0,0 +1,367 @@
+package script
+
+import (
+	"os"
+	"os/exec"
+	"slices"
+	"strconv"
+	"strings"
+	"sync"
+	"syscall"
+	"testing"
+	"testing/iotest"
+	"time"
+
+	"github.com/enchik0reo/commandApi/internal/config"
+	"github.com/enchik0reo/commandApi/internal/logs"
+	"github.com/enchik0reo/commandApi/internal/models"
+	"github.com/enchik0reo/commandApi/internal/services"
+
+	"github.com/ilyakaznacheev/cleanenv"
+	"github.com/stretchr/testify/require"
+)
+
+func TestScriptCommand(t *testing.T) {
+	tests := []struct {
+		name    string
+		script  models.Script
+		want    []string
+		wantDir string
+	}{
+		{
+			name:   "test_1, with args",
+			script: models.Script{Body: "echo $2", Options: models.Options{Args: []string{"a", "b"}}},
+			want:   []string{"/bin/bash", "-c", "echo $2", "bash", "a", "b"},
+		},
+		{
+			name:   "test_2, with limits",
+			script: models.Script{Body: "ls", Options: models.Options{Limits: models.Limits{CPUTime: time.Second}}},
+			want: []string{"/bin/bash", "-c", `ulimit -S -t 1 && ulimit -H -t 2 && exec "$@"`, "bash",
+				"/bin/bash", "-c", "ls", "bash"},
+		},
+		{
+			name: "test_3, with interpreter",
+			script: models.Script{Body: "print(1)", Invocation: []string{"/usr/bin/python3", "-c", "print(1)"},
+				Options: models.Options{Args: []string{"a"}}},
+			want: []string{"/usr/bin/python3", "-c", "print(1)", "a"},
+		},
+		{
+			name:    "test_4, in workspace",
+			script:  models.Script{Body: "ls", Workspace: "/tmp/ws"},
+			want:    []string{"/bin/bash", "-c", "ls", "bash"},
+			wantDir: "/tmp/ws",
+		},
+		{
+			name: "test_5, with working directory",
+			script: models.Script{Body: "ls", Workspace: "/tmp/ws",
+				Options: models.Options{WorkingDir: "/srv"}},
+			want:    []string{"/bin/bash", "-c", "ls", "bash"},
+			wantDir: "/srv",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := scriptCommand(tt.script)
+
+			require.Equal(t, tt.want, got.Args)
+			require.Equal(t, tt.wantDir, got.Dir)
+		})
+	}
+}
+
+func TestScriptEnv(t *testing.T) {
+	require.Nil(t, scriptEnv(models.Script{Body: "env"}))
+
+	got := scriptEnv(models.Script{
+		Body:      "env",
+		SecretEnv: map[string]string{"TOKEN": "secret"},
+		Workspace: "/tmp/ws",
+		Options:   models.Options{Env: map[string]string{"B": "2", "A": "1"}},
+	})
+
+	require.Equal(t, []string{"A=1", "B=2", "TOKEN=secret", "SCRIPT_WORKSPACE=/tmp/ws"}, got[len(os.Environ()):])
+}
+
+func TestScanOutput(t *testing.T) {
+	tests := []struct {
+		name    string
+		input   string
+		maxLine int
+		want    []models.Output
+	}{
+		{
+			name:    "test_1, short lines",
+			input:   "one\r\ntwo\nthree",
+			maxLine: 8,
+			want: []models.Output{
+				{Stream: models.StreamStdout, Text: "one"},
+				{Stream: models.StreamStdout, Text: "two"},
+				{Stream: models.StreamStdout, Text: "three"},
+			},
+		},
+		{
+			name:    "test_2, long line",
+			input:   "abcdefghij\nk\n",
+			maxLine: 4,
+			want: []models.Output{
+				{Stream: models.StreamStdout, Text: "abcd", Partial: true},
+				{Stream: models.StreamStdout, Text: "efgh", Partial: true},
+				{Stream: models.StreamStdout, Text: "ij"},
+				{Stream: models.StreamStdout, Text: "k"},
+			},
+		},
+		{
+			name:    "test_3, long line isn't cut inside character",
+			input:   "aaaяя\n",
+			maxLine: 4,
+			want: []models.Output{
+				{Stream: models.StreamStdout, Text: "aaa", Partial: true},
+				{Stream: models.StreamStdout, Text: "яя"},
+			},
+		},
+		{
+			name:    "test_4, binary line",
+			input:   "ok\n\x00\xff\x01\n",
+			maxLine: 8,
+			want: []models.Output{
+				{Stream: models.StreamStdout, Text: "ok"},
+				{Stream: models.StreamStdout, Text: "AP8B", Encoding: models.EncodingBase64},
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			out := make(chan models.Output, len(tt.want)+1)
+			wg := &sync.WaitGroup{}
+			wg.Add(1)
+
+			scanOutput(strings.NewReader(tt.input), models.StreamStdout, tt.maxLine, out, wg, nil)
+			close(out)
+
+			got := []models.Output{}
+
+			for output := range out {
+				got = append(got, output)
+			}
+
+			require.Equal(t, tt.want, got)
+		})
+	}
+}
+
+func TestScanOutputError(t *testing.T) {
+	out := make(chan models.Output, 2)
+	wg := &sync.WaitGroup{}
+	wg.Add(1)
+
+	scanOutput(iotest.TimeoutReader(strings.NewReader("one\n")), models.StreamStdout, 8, out, wg, nil)
+	close(out)
+
+	require.Equal(t, models.Output{Stream: models.StreamStdout, Text: "one"}, <-out)
+	require.Equal(t, models.Output{Stream: models.StreamStderr, Text: "Can't read stdout of the script: timeout"}, <-out)
+}
+
+func TestExecutor_RunScript(t *testing.T) {
+	e := NewExecutor(logs.NewDiscardLogger(), &config.Executor{GracePeriod: time.Second, MaxLineBytes: 1024})
+
+	tests := []struct {
+		name    string
+		script  models.Script
+		want    []models.Output
+		wantErr error
+	}{
+		{
+			name:   "test_1, stdout with args",
+			script: models.Script{Body: "echo $1; echo $2", Options: models.Options{Args: []string{"a", "b"}}},
+			want:   []models.Output{{Stream: models.StreamStdout, Text: "a"}, {Stream: models.StreamStdout, Text: "b"}},
+		},
+		{
+			name:   "test_2, stderr",
+			script: models.Script{Body: "echo x >&2"},
+			want:   []models.Output{{Stream: models.StreamStderr, Text: "x"}},
+		},
+		{
+			name:    "test_3, exit code",
+			script:  models.Script{Body: "echo failed >&2; exit 3"},
+			want:    []models.Output{{Stream: models.StreamStderr, Text: "failed"}},
+			wantErr: &services.ExitError{Code: 3},
+		},
+		{
+			name:    "test_4, cpu limit",
+			script:  models.Script{Body: "while :; do :; done", Options: models.Options{Limits: models.Limits{CPUTime: time.Second}}},
+			wantErr: &services.ExitError{Code: -1, Signal: "SIGXCPU", Limit: models.LimitCPUTime},
+		},
+		{
+			name: "test_5, open files limit",
+			script: models.Script{Body: `for i in $(seq 3 20); do eval "exec $i</dev/null" || exit 1; done`,
+				Options: models.Options{Limits: models.Limits{OpenFiles: 8}}},
+			want:    []models.Output{{Stream: models.StreamStderr, Text: "bash: line 1: /dev/null: Too many open files"}},
+			wantErr: &services.ExitError{Code: 1, Limit: models.LimitOpenFiles},
+		},
+		{
+			name:   "test_6, open files limit isn't exceeded by the script which succeeds",
+			script: models.Script{Body: "echo 'Too many open files' >&2", Options: models.Options{Limits: models.Limits{OpenFiles: 8}}},
+			want:   []models.Output{{Stream: models.StreamStderr, Text: "Too many open files"}},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := runScript(e, tt.script, 0)
+
+			require.Equal(t, tt.want, got)
+			require.Equal(t, tt.wantErr, err)
+		})
+	}
+}
+
+func TestExecutor_RunScriptStop(t *testing.T) {
+	e := NewExecutor(logs.NewDiscardLogger(), &config.Executor{GracePeriod: time.Second, MaxLineBytes: 1024})
+
+	tests := []struct {
+		name       string
+		body       string
+		sig        syscall.Signal
+		wantErr    error
+		minElapsed time.Duration
+	}{
+		{
+			name:    "test_1, background child is stopped with the script",
+			body:    "sleep 30 & echo $!; wait",
+			sig:     syscall.SIGTERM,
+			wantErr: &services.ExitError{Code: -1, Signal: "SIGTERM", Err: services.ErrStoppedManually},
+		},
+		{
+			name:    "test_2, script handles the signal",
+			body:    "trap 'exit 0' TERM; sleep 30 & echo $!; wait",
+			sig:     syscall.SIGTERM,
+			wantErr: &services.ExitError{Code: 0, Err: services.ErrStoppedManually},
+		},
+		{
+			name:       "test_3, group is killed after grace period",
+			body:       "trap '' TERM; sleep 30 & echo $!; wait",
+			sig:        syscall.SIGTERM,
+			wantErr:    &services.ExitError{Code: -1, Signal: "SIGKILL", Err: services.ErrStoppedManually},
+			minElapsed: time.Second,
+		},
+		{
+			name:    "test_4, group is killed at once",
+			body:    "trap '' TERM; sleep 30 & echo $!; wait",
+			sig:     syscall.SIGKILL,
+			wantErr: &services.ExitError{Code: -1, Signal: "SIGKILL", Err: services.ErrStoppedManually},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			start := time.Now()
+
+			got, err := runScript(e, models.Script{Body: tt.body}, tt.sig)
+
+			require.Equal(t, tt.wantErr, err)
+			require.GreaterOrEqual(t, time.Since(start), tt.minElapsed)
+			require.Len(t, got, 1)
+
+			child, err := strconv.Atoi(got[0].Text)
+			require.NoError(t, err)
+
+			require.Eventually(t, func() bool { return !alive(child) }, time.Second, 10*time.Millisecond)
+		})
+	}
+}
+
+func TestExecutor_RunScriptProcessesLimit(t *testing.T) {
+	e := NewExecutor(logs.NewDiscardLogger(), &config.Executor{GracePeriod: time.Second})
+
+	got, err := runScript(e, models.Script{Body: "echo x", Options: models.Options{Limits: models.Limits{Processes: 16}}}, 0)
+
+	require.Empty(t, got)
+	require.EqualError(t, err, "can't limit processes without cgroup: script.StartScript")
+}
+
+func TestExecutor_RunScriptUnlimitedAddressSpace(t *testing.T) {
+	e := NewExecutor(logs.NewDiscardLogger(), &config.Executor{GracePeriod: time.Second})
+
+	got, err := runScript(e, models.Script{Body: "echo x", UnlimitedAddressSpace: true,
+		Options: models.Options{Limits: models.Limits{MemoryMB: 512}}}, 0)
+
+	require.Empty(t, got)
+	require.EqualError(t, err, "can't limit memory of unlimited address space without cgroup: script.StartScript")
+}
+
+func TestExecutor_RunScriptNode(t *testing.T) {
+	if _, err := exec.LookPath("node"); err != nil {
+		t.Skip("node isn't installed")
+	}
+
+	cfg := &config.Config{}
+	require.NoError(t, cleanenv.ReadConfig("../../../configs/local.yaml", cfg))
+
+	body := `console.log("ok")`
+
+	invocation := []string{}
+	for _, arg := range cfg.Commander.Interpreters["node"] {
+		invocation = append(invocation, strings.ReplaceAll(arg, "{script}", body))
+	}
+
+	e := NewExecutor(logs.NewDiscardLogger(), &cfg.Executor)
+
+	got, err := runScript(e, models.Script{
+		Body:                  body,
+		Invocation:            invocation,
+		UnlimitedAddressSpace: slices.Contains(cfg.Commander.UnlimitedAddressSpace, "node"),
+		Options:               models.Options{Limits: models.Limits(cfg.Commander.DefaultLimits)},
+	}, 0)
+
+	require.NoError(t, err)
+	require.Equal(t, []models.Output{{Stream: models.StreamStdout, Text: "ok"}}, got)
+}
+
+// alive reports whether the process is running, zombie process isn't running ...
+func alive(pid int) bool {
+	stat, err := os.ReadFile("/proc/" + strconv.Itoa(pid) + "/stat")
+	if err != nil {
+		return false
+	}
+
+	_, state, _ := strings.Cut(string(stat), ") ")
+
+	return !strings.HasPrefix(state, "Z")
+}
+
+// runScript runs the script and returns its output and error.
+// The signal is sent to the script after its first line of output if it isn't zero ...
+func runScript(e *Executor, script models.Script, sig syscall.Signal) ([]models.Output, error) {
+	stop := make(chan syscall.Signal, 1)
+	out, errOut := e.RunScript(script, stop, nil)
+
+	var got []models.Output
+	var err error
+
+	for out != nil || errOut != nil {
+		select {
+		case line, ok := <-out:
+			if !ok {
+				out = nil
+				continue
+			}
+
+			if len(got) == 0 && sig != 0 {
+				stop <- sig
+			}
+
+			got = append(got, line)
+		case e, ok := <-errOut:
+			if !ok {
+				errOut = nil
+				continue
+			}
+
+			err = e
+		}
+	}
+
+	return got, err
+}
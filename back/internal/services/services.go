@@ -1,8 +1,48 @@
 package services
 
-import "errors"
+import (
+	"errors"
+	"fmt"
+)
 
 var (
 	ErrNoExecutingCommand = errors.New("there's no executing script")
 	ErrStoppedManually    = errors.New("script was stopped manually")
+	ErrNotInteractive     = errors.New("script doesn't accept input")
+	ErrInputClosed        = errors.New("script's input is closed")
+	ErrInvalidOptions     = errors.New("invalid script options")
+	ErrInvalidParams      = errors.New("invalid template parameters")
+	ErrTemplateNotFound   = errors.New("template not found")
+	ErrTemplateExists     = errors.New("template with this name already exists")
+	ErrInvalidSchedule    = errors.New("invalid schedule")
+	ErrScheduleNotFound   = errors.New("schedule not found")
+	ErrCommandNotFound    = errors.New("command not found")
+	ErrArtifactNotFound   = errors.New("artifact not found")
 )
+
+// ExitError describes an unsuccessful end of the script's process.
+// Err keeps the reason of the end if it's known,
+// Limit keeps the resource limit which was exceeded by the script ...
+type ExitError struct {
+	Code   int
+	Signal string
+	Limit  string
+	Err    error
+}
+
+func (e *ExitError) Error() string {
+	switch {
+	case e.Err != nil:
+		return e.Err.Error()
+	case e.Limit != "":
+		return fmt.Sprintf("script exceeded %s limit", e.Limit)
+	case e.Signal != "":
+		return fmt.Sprintf("script was terminated by signal %s", e.Signal)
+	default:
+		return fmt.Sprintf("script exited with code %d", e.Code)
+	}
+}
+
+func (e *ExitError) Unwrap() error {
+	return e.Err
+}
@@ -0,0 +1,102 @@
+package storage
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/enchik0reo/commandApi/internal/models"
+	"github.com/enchik0reo/commandApi/internal/services"
+)
+
+// CreateArtifact adds the artifact collected from the command's workspace to db ...
+func (c *CommandStoage) CreateArtifact(ctx context.Context, a models.Artifact) (int64, error) {
+	stmt, err := c.db.PrepareContext(ctx, `INSERT INTO artifacts (command_id, artifact_name, size, sha256)
+	VALUES ($1, $2, $3, $4) RETURNING artifact_id`)
+	if err != nil {
+		return 0, fmt.Errorf("can't prepare statement: %w", err)
+	}
+	defer stmt.Close()
+
+	var id int64
+
+	if err := stmt.QueryRowContext(ctx, a.CommandID, a.Name, a.Size, a.SHA256).Scan(&id); err != nil {
+		return 0, fmt.Errorf("can't insert artifact: %w", err)
+	}
+
+	return id, nil
+}
+
+// GetArtifacts returns artifacts of the command ordered by name.
+// Unknown command gives not found error ...
+func (c *CommandStoage) GetArtifacts(ctx context.Context, id int64) ([]models.Artifact, error) {
+	err := c.db.QueryRowContext(ctx, `SELECT command_id FROM commands WHERE command_id = $1`, id).Scan(&id)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, services.ErrCommandNotFound
+		}
+
+		return nil, fmt.Errorf("can't get command: %w", err)
+	}
+
+	rows, err := c.db.QueryContext(ctx, `SELECT artifact_id, command_id, artifact_name, size, sha256, created_at
+	FROM artifacts WHERE command_id = $1 ORDER BY artifact_name`, id)
+	if err != nil {
+		return nil, fmt.Errorf("can't get artifacts: %w", err)
+	}
+	defer rows.Close()
+
+	artifacts := []models.Artifact{}
+
+	for rows.Next() {
+		a, err := scanArtifact(rows)
+		if err != nil {
+			return nil, err
+		}
+
+		artifacts = append(artifacts, *a)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("can't read rows: %w", err)
+	}
+
+	return artifacts, nil
+}
+
+// GetArtifact returns the artifact of the command by artifact id ...
+func (c *CommandStoage) GetArtifact(ctx context.Context, id, artifactID int64) (*models.Artifact, error) {
+	row := c.db.QueryRowContext(ctx, `SELECT artifact_id, command_id, artifact_name, size, sha256, created_at
+	FROM artifacts WHERE command_id = $1 AND artifact_id = $2`, id, artifactID)
+
+	a, err := scanArtifact(row)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, services.ErrArtifactNotFound
+		}
+
+		return nil, err
+	}
+
+	return a, nil
+}
+
+// scanArtifact reads the artifact from the row ...
+func scanArtifact(row interface{ Scan(...any) error }) (*models.Artifact, error) {
+	var a models.Artifact
+	var created time.Time
+
+	if err := row.Scan(&a.ID, &a.CommandID, &a.Name, &a.Size, &a.SHA256, &created); err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, err
+		}
+
+		return nil, fmt.Errorf("can't scan artifact: %w", err)
+	}
+
+	a.CreatedAt = created.UTC().Format(time.StampMilli)
+
+	return &a, nil
+}
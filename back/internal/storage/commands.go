@@ -3,10 +3,18 @@ package storage
 import (
 	"context"
 	"database/sql"
+	"encoding/json"
+	"errors"
 	"fmt"
+	"slices"
+	"strconv"
+	"strings"
 	"time"
 
 	"github.com/enchik0reo/commandApi/internal/models"
+	"github.com/enchik0reo/commandApi/internal/services"
+
+	"github.com/lib/pq"
 )
 
 type CommandStoage struct {
@@ -17,15 +25,22 @@ func NewCommandStorage(db *sql.DB) *CommandStoage {
 	return &CommandStoage{db: db}
 }
 
-// CreateNew adds new command to db ...
-func (c *CommandStoage) CreateNew(ctx context.Context, comandName string) (int64, error) {
-	stmt, err := c.db.PrepareContext(ctx, "INSERT INTO commands (command_name) VALUES ($1) RETURNING command_id")
+// CreateNew adds new command with its script and options to db ...
+func (c *CommandStoage) CreateNew(ctx context.Context, cmd models.Command) (int64, error) {
+	stmt, err := c.db.PrepareContext(ctx, `INSERT INTO commands (command_name, script, script_hash, interpreter, status, options, template_id, 
+	parent_id) VALUES ($1, $2, $3, $4, $5, $6, NULLIF($7, 0), NULLIF($8, 0)) RETURNING command_id`)
 	if err != nil {
 		return 0, fmt.Errorf("can't prepare statement: %w", err)
 	}
 	defer stmt.Close()
 
-	row := stmt.QueryRowContext(ctx, comandName)
+	options, err := json.Marshal(cmd.Options)
+	if err != nil {
+		return 0, fmt.Errorf("can't marshal options: %w", err)
+	}
+
+	row := stmt.QueryRowContext(ctx, cmd.Name, cmd.Script, cmd.ScriptHash, cmd.Interpreter, cmd.Status, options, cmd.TemplateID,
+		cmd.ParentID)
 
 	if err := row.Err(); err != nil {
 		return 0, fmt.Errorf("can't insert source: %w", err)
@@ -40,16 +55,25 @@ func (c *CommandStoage) CreateNew(ctx context.Context, comandName string) (int64
 	return id, nil
 }
 
-// GetList returns n latest commands ...
-func (c *CommandStoage) GetList(ctx context.Context, n int64) ([]models.Command, error) {
-	stmt, err := c.db.PrepareContext(ctx, `SELECT command_id, command_name, started_at, is_working 
-	FROM commands ORDER BY command_id DESC LIMIT $1`)
+// GetList returns the page of latest commands matching the filter.
+// Search uses full-text indexes of scripts and outputs ...
+func (c *CommandStoage) GetList(ctx context.Context, f models.CommandFilter) ([]models.Command, error) {
+	where, args := listConditions(f)
+
+	args = append(args, f.Limit)
+
+	stmt, err := c.db.PrepareContext(ctx, `SELECT c.command_id, c.command_name, COALESCE(c.script_hash, ''), c.interpreter, 
+	COALESCE(c.template_id, 0), COALESCE(c.parent_id, 0), c.started_at, c.is_working, c.status, c.exit_code, c.signal, 
+	c.exceeded_limit, c.finished_at, c.truncated, 
+	CASE WHEN c.status = 'queued' THEN (SELECT COUNT(*) FROM commands q 
+		WHERE q.status = 'queued' AND q.command_id <= c.command_id) ELSE 0 END 
+	FROM commands c `+where+` ORDER BY c.command_id DESC LIMIT $`+strconv.Itoa(len(args)))
 	if err != nil {
 		return nil, fmt.Errorf("can't prepare statement: %w", err)
 	}
 	defer stmt.Close()
 
-	rows, err := stmt.QueryContext(ctx, n)
+	rows, err := stmt.QueryContext(ctx, args...)
 	if err != nil {
 		return nil, fmt.Errorf("can't get command's list: %w", err)
 	}
@@ -59,13 +83,16 @@ func (c *CommandStoage) GetList(ctx context.Context, n int64) ([]models.Command,
 
 	for rows.Next() {
 		cmd := models.Command{}
+		res := result{}
 		var created time.Time
 
-		if err := rows.Scan(&cmd.ID, &cmd.Name, &created, &cmd.IsWorking); err != nil {
+		if err := rows.Scan(&cmd.ID, &cmd.Name, &cmd.ScriptHash, &cmd.Interpreter, &cmd.TemplateID, &cmd.ParentID, &created, &cmd.IsWorking,
+			&cmd.Status, &res.exitCode, &res.signal, &res.limit, &res.finished, &cmd.Truncated, &cmd.QueuePosition); err != nil {
 			return nil, fmt.Errorf("can't scan row: %w", err)
 		}
 
 		cmd.StartedAt = created.UTC().Format(time.StampMilli)
+		res.fill(&cmd)
 
 		cmds = append(cmds, cmd)
 	}
@@ -73,47 +100,299 @@ func (c *CommandStoage) GetList(ctx context.Context, n int64) ([]models.Command,
 	return cmds, nil
 }
 
-// GetOne returns description of one command by command id ...
+// listConditions returns WHERE clause of the filter with its arguments ...
+func listConditions(f models.CommandFilter) (string, []any) {
+	conds := []string{}
+	args := []any{}
+
+	add := func(cond string, arg any) {
+		args = append(args, arg)
+		conds = append(conds, fmt.Sprintf(cond, len(args)))
+	}
+
+	if f.Cursor > 0 {
+		add("c.command_id < $%d", f.Cursor)
+	}
+
+	if len(f.Statuses) > 0 {
+		add("c.status = ANY($%d::command_status[])", pq.Array(f.Statuses))
+	}
+
+	if !f.From.IsZero() {
+		add("c.started_at >= $%d", f.From.UTC())
+	}
+
+	if !f.To.IsZero() {
+		add("c.started_at < $%d", f.To.UTC())
+	}
+
+	if f.IsWorking != nil {
+		add("c.is_working = $%d", *f.IsWorking)
+	}
+
+	if f.Query != "" {
+		// Output is indexed after the command finishes. Output of running commands isn't indexed,
+		// it's searched line by line within the same bounds as the index, which is slow for many running commands.
+		add(fmt.Sprintf(`(c.script_tsv @@ websearch_to_tsquery('simple', $%%[1]d) 
+		OR c.output_tsv @@ websearch_to_tsquery('simple', $%%[1]d) 
+		OR (c.is_working AND EXISTS (SELECT 1 FROM outputs o WHERE o.command_id = c.command_id AND o.line_no <= %d 
+		AND COALESCE(o.encoding, '') = '' AND to_tsvector('simple', left(o.output, %d)) @@ websearch_to_tsquery('simple', $%%[1]d))))`,
+			searchIndexLines, searchIndexLineChars), f.Query)
+	}
+
+	if len(conds) == 0 {
+		return "", args
+	}
+
+	return "WHERE " + strings.Join(conds, " AND "), args
+}
+
+// GetOne returns metadata of one command by command id without its output,
+// the output is read by ranges with GetOutput. Unknown command gives not found error ...
 func (c *CommandStoage) GetOne(ctx context.Context, id int64) (*models.Command, error) {
-	stmt, err := c.db.PrepareContext(ctx, `SELECT c.command_id, c.command_name, c.started_at, c.is_working, o.output 
+	stmt, err := c.db.PrepareContext(ctx, `SELECT c.command_id, c.command_name, COALESCE(c.script, ''), COALESCE(c.script_hash, ''), 
+	c.interpreter, COALESCE(c.template_id, 0), COALESCE(c.parent_id, 0), 
+	ARRAY(SELECT r.command_id FROM commands r WHERE r.parent_id = c.command_id ORDER BY r.command_id), 
+	c.options, c.started_at, c.is_working, c.status, c.exit_code, c.signal, c.exceeded_limit, c.finished_at, c.truncated 
 	FROM commands c 
-	INNER JOIN outputs o ON c.command_id = o.command_id 
 	WHERE c.command_id = $1`)
 	if err != nil {
 		return nil, fmt.Errorf("can't prepare statement: %w", err)
 	}
 	defer stmt.Close()
 
-	rows, err := stmt.QueryContext(ctx, id)
+	cmd := models.Command{}
+	res := result{}
+	var created time.Time
+	var options []byte
+
+	err = stmt.QueryRowContext(ctx, id).Scan(&cmd.ID, &cmd.Name, &cmd.Script, &cmd.ScriptHash, &cmd.Interpreter,
+		&cmd.TemplateID, &cmd.ParentID, pq.Array(&cmd.Reruns), &options, &created, &cmd.IsWorking, &cmd.Status,
+		&res.exitCode, &res.signal, &res.limit, &res.finished, &cmd.Truncated)
 	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, services.ErrCommandNotFound
+		}
+
 		return nil, fmt.Errorf("can't get command: %w", err)
 	}
+
+	cmd.StartedAt = created.UTC().Format(time.StampMilli)
+	res.fill(&cmd)
+
+	if options != nil {
+		opts := models.Options{}
+
+		if err := json.Unmarshal(options, &opts); err != nil {
+			return nil, fmt.Errorf("can't unmarshal options: %w", err)
+		}
+
+		cmd.Options = &opts
+		cmd.Env, cmd.Args, cmd.WorkingDir = opts.Env, opts.Args, opts.WorkingDir
+	}
+
+	return &cmd, nil
+}
+
+// Output text indexed for search is bounded in lines, characters of every line and characters of the whole text,
+// so its tsvector stays under the size limit and it's built from a bounded number of rows.
+const (
+	searchIndexLines     = 10000
+	searchIndexLineChars = 1024
+	searchIndexChars     = 256 << 10
+)
+
+// outputColumns are columns of the output line, elapsed time is counted from the start of the run ...
+const outputColumns = `o.output_id, o.line_no, o.stream, o.output, COALESCE(o.encoding, ''), o.partial, o.produced_at, 
+	ROUND(EXTRACT(EPOCH FROM o.produced_at - c.run_at)::numeric, 6)`
+
+// scanOutput reads the output line from the row ...
+func scanOutput(row interface{ Scan(...any) error }) (*models.Output, error) {
+	output := models.Output{}
+	var at sql.NullTime
+	var elapsed sql.NullFloat64
+
+	if err := row.Scan(&output.ID, &output.Line, &output.Stream, &output.Text, &output.Encoding, &output.Partial,
+		&at, &elapsed); err != nil {
+		return nil, fmt.Errorf("can't scan row: %w", err)
+	}
+
+	if at.Valid {
+		t := at.Time.UTC()
+		output.At = &t
+	}
+
+	if elapsed.Valid {
+		output.Elapsed = &elapsed.Float64
+	}
+
+	return &output, nil
+}
+
+// GetOutput returns the range of the command's output lines ordered by line number
+// and the number of the last saved line. Unknown command gives not found error ...
+func (c *CommandStoage) GetOutput(ctx context.Context, id int64, r models.OutputRange) ([]models.Output, int64, error) {
+	query := `SELECT ` + outputColumns + ` FROM outputs o JOIN commands c USING (command_id) 
+	WHERE o.command_id = $1 AND o.line_no >= $2 ORDER BY o.line_no LIMIT $3 OFFSET $4`
+	args := []any{id, r.Since, r.Limit, r.Offset}
+
+	if r.Tail > 0 {
+		query = `SELECT ` + outputColumns + ` FROM outputs o JOIN commands c USING (command_id) 
+		WHERE o.command_id = $1 AND o.line_no >= $2 ORDER BY o.line_no DESC LIMIT $3`
+		args = []any{id, r.Since, r.Tail}
+	}
+
+	stmt, err := c.db.PrepareContext(ctx, query)
+	if err != nil {
+		return nil, 0, fmt.Errorf("can't prepare statement: %w", err)
+	}
+	defer stmt.Close()
+
+	rows, err := stmt.QueryContext(ctx, args...)
+	if err != nil {
+		return nil, 0, fmt.Errorf("can't get output: %w", err)
+	}
 	defer rows.Close()
 
-	outputs := []string{}
-	cmd := models.Command{}
-	var created time.Time
+	outputs := []models.Output{}
+
+	for rows.Next() {
+		output, err := scanOutput(rows)
+		if err != nil {
+			return nil, 0, err
+		}
+
+		outputs = append(outputs, *output)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, 0, fmt.Errorf("can't read rows: %w", err)
+	}
+
+	if r.Tail > 0 {
+		slices.Reverse(outputs)
+	}
+
+	var last int64
+
+	err = c.db.QueryRowContext(ctx, `SELECT COALESCE((SELECT MAX(line_no) FROM outputs WHERE command_id = $1), 0) 
+	FROM commands WHERE command_id = $1`, id).Scan(&last)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, 0, services.ErrCommandNotFound
+		}
+
+		return nil, 0, fmt.Errorf("can't get last line: %w", err)
+	}
+
+	return outputs, last, nil
+}
+
+// EachOutput calls the function for every output line of the command ordered by line number.
+// Rows are read one by one, so the whole output isn't loaded in memory.
+// Unknown command gives not found error ...
+func (c *CommandStoage) EachOutput(ctx context.Context, id int64, each func(models.Output) error) error {
+	err := c.db.QueryRowContext(ctx, `SELECT command_id FROM commands WHERE command_id = $1`, id).Scan(&id)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return services.ErrCommandNotFound
+		}
+
+		return fmt.Errorf("can't get command: %w", err)
+	}
+
+	rows, err := c.db.QueryContext(ctx, `SELECT `+outputColumns+` FROM outputs o JOIN commands c USING (command_id) 
+	WHERE o.command_id = $1 ORDER BY o.line_no`, id)
+	if err != nil {
+		return fmt.Errorf("can't get output: %w", err)
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		output, err := scanOutput(rows)
+		if err != nil {
+			return err
+		}
+
+		if err := each(*output); err != nil {
+			return err
+		}
+	}
+
+	if err := rows.Err(); err != nil {
+		return fmt.Errorf("can't read rows: %w", err)
+	}
+
+	return nil
+}
+
+// GetQueued returns queued commands with their options in order of creation ...
+func (c *CommandStoage) GetQueued(ctx context.Context) ([]models.Command, error) {
+	stmt, err := c.db.PrepareContext(ctx, `SELECT command_id, command_name, COALESCE(script, ''), options 
+	FROM commands WHERE status = 'queued' ORDER BY command_id`)
+	if err != nil {
+		return nil, fmt.Errorf("can't prepare statement: %w", err)
+	}
+	defer stmt.Close()
+
+	rows, err := stmt.QueryContext(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("can't get queued commands: %w", err)
+	}
+	defer rows.Close()
+
+	cmds := []models.Command{}
 
 	for rows.Next() {
-		var output string
+		cmd := models.Command{Status: models.StatusQueued, IsWorking: true}
+		var options []byte
 
-		if err := rows.Scan(&cmd.ID, &cmd.Name, &created, &cmd.IsWorking, &output); err != nil {
+		if err := rows.Scan(&cmd.ID, &cmd.Name, &cmd.Script, &options); err != nil {
 			return nil, fmt.Errorf("can't scan row: %w", err)
 		}
 
-		cmd.StartedAt = created.UTC().Format(time.StampMilli)
+		cmd.Options = &models.Options{}
 
-		outputs = append(outputs, output)
+		if options != nil {
+			if err := json.Unmarshal(options, cmd.Options); err != nil {
+				return nil, fmt.Errorf("can't unmarshal options: %w", err)
+			}
+		}
+
+		cmds = append(cmds, cmd)
 	}
 
-	cmd.Output = outputs
+	return cmds, nil
+}
 
-	return &cmd, nil
+// StartOne marks the queued command as running by command id ...
+func (c *CommandStoage) StartOne(ctx context.Context, id int64) (int64, error) {
+	stmt, err := c.db.PrepareContext(ctx, `UPDATE commands SET status = 'running', run_at = NOW() 
+	WHERE command_id = $1 RETURNING command_id`)
+	if err != nil {
+		return 0, fmt.Errorf("can't prepare statement: %w", err)
+	}
+	defer stmt.Close()
+
+	row := stmt.QueryRowContext(ctx, id)
+
+	if err := row.Err(); err != nil {
+		return 0, fmt.Errorf("can't start command: %w", err)
+	}
+
+	if err := row.Scan(&id); err != nil {
+		return 0, fmt.Errorf("can't get started id: %w", err)
+	}
+
+	return id, nil
 }
 
-// StopOne stops the command by command id ...
+// StopOne stops the command by command id.
+// Status of the running or queued command becomes stopped ...
 func (c *CommandStoage) StopOne(ctx context.Context, id int64) (int64, error) {
-	stmt, err := c.db.PrepareContext(ctx, `UPDATE commands SET is_working = false
+	stmt, err := c.db.PrepareContext(ctx, `UPDATE commands SET is_working = false, 
+	status = CASE WHEN status IN ('running', 'queued') THEN 'stopped' ELSE status END, 
+	finished_at = COALESCE(finished_at, NOW()) 
 	WHERE command_id = $1 RETURNING command_id`)
 	if err != nil {
 		return 0, fmt.Errorf("can't prepare statement: %w", err)
@@ -133,23 +412,125 @@ func (c *CommandStoage) StopOne(ctx context.Context, id int64) (int64, error) {
 	return id, nil
 }
 
-// SaveOutput saves command's output by command id ...
-func (c *CommandStoage) SaveOutput(ctx context.Context, id int64, output string) (int64, error) {
-	stmt, err := c.db.PrepareContext(ctx, "INSERT INTO outputs (command_id, output) VALUES ($1, $2) RETURNING output_id")
+// TruncateOne marks the command's output as truncated by command id ...
+func (c *CommandStoage) TruncateOne(ctx context.Context, id int64) (int64, error) {
+	stmt, err := c.db.PrepareContext(ctx, `UPDATE commands SET truncated = true 
+	WHERE command_id = $1 RETURNING command_id`)
+	if err != nil {
+		return 0, fmt.Errorf("can't prepare statement: %w", err)
+	}
+	defer stmt.Close()
+
+	if err := stmt.QueryRowContext(ctx, id).Scan(&id); err != nil {
+		return 0, fmt.Errorf("can't mark output truncated: %w", err)
+	}
+
+	return id, nil
+}
+
+// FinishOne saves the result of command's execution by command id ...
+func (c *CommandStoage) FinishOne(ctx context.Context, id int64, res models.Result) (int64, error) {
+	stmt, err := c.db.PrepareContext(ctx, `UPDATE commands SET is_working = false, 
+	status = $2, exit_code = $3, signal = NULLIF($4, ''), exceeded_limit = NULLIF($5, ''), finished_at = NOW() 
+	WHERE command_id = $1 RETURNING command_id`)
 	if err != nil {
 		return 0, fmt.Errorf("can't prepare statement: %w", err)
 	}
 	defer stmt.Close()
 
-	row := stmt.QueryRowContext(ctx, id, output)
+	var exitCode sql.NullInt64
+
+	if res.ExitCode != nil {
+		exitCode = sql.NullInt64{Int64: int64(*res.ExitCode), Valid: true}
+	}
+
+	row := stmt.QueryRowContext(ctx, id, res.Status, exitCode, res.Signal, res.Limit)
 
 	if err := row.Err(); err != nil {
-		return 0, fmt.Errorf("can't insert source: %w", err)
+		return 0, fmt.Errorf("can't finish command: %w", err)
 	}
 
 	if err := row.Scan(&id); err != nil {
-		return 0, fmt.Errorf("can't get last insert id: %w", err)
+		return 0, fmt.Errorf("can't get finished id: %w", err)
 	}
 
 	return id, nil
 }
+
+// IndexOutput indexes the finished command's output for search by command id.
+// Only the first searchIndexLines text lines are indexed, every line and the whole text are cut,
+// so the index is built once from a bounded number of rows ...
+func (c *CommandStoage) IndexOutput(ctx context.Context, id int64) error {
+	stmt, err := c.db.PrepareContext(ctx, `UPDATE commands SET output_tsv = 
+	(SELECT strip(to_tsvector('simple', left(string_agg(l.output, ' ' ORDER BY l.line_no), $4))) 
+	FROM (SELECT o.line_no, left(o.output, $3) AS output FROM outputs o 
+	WHERE o.command_id = $1 AND COALESCE(o.encoding, '') = '' ORDER BY o.line_no LIMIT $2) l) 
+	WHERE command_id = $1`)
+	if err != nil {
+		return fmt.Errorf("can't prepare statement: %w", err)
+	}
+	defer stmt.Close()
+
+	if _, err := stmt.ExecContext(ctx, id, searchIndexLines, searchIndexLineChars, searchIndexChars); err != nil {
+		return fmt.Errorf("can't index output: %w", err)
+	}
+
+	return nil
+}
+
+// SaveOutputs saves command's output lines with their numbers and streams by command id.
+// Lines are copied in one transaction in the given order ...
+func (c *CommandStoage) SaveOutputs(ctx context.Context, id int64, outputs []models.Output) error {
+	tx, err := c.db.BeginTx(ctx, nil)
+	if err != nil {
+		return fmt.Errorf("can't begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	stmt, err := tx.PrepareContext(ctx, pq.CopyIn("outputs", "command_id", "line_no", "stream", "output", "encoding",
+		"partial", "produced_at"))
+	if err != nil {
+		return fmt.Errorf("can't prepare statement: %w", err)
+	}
+	defer stmt.Close()
+
+	for _, output := range outputs {
+		if _, err := stmt.ExecContext(ctx, id, output.Line, output.Stream, output.Text,
+			sql.NullString{String: output.Encoding, Valid: output.Encoding != ""}, output.Partial, output.At); err != nil {
+			return fmt.Errorf("can't copy output: %w", err)
+		}
+	}
+
+	if _, err := stmt.ExecContext(ctx); err != nil {
+		return fmt.Errorf("can't flush output: %w", err)
+	}
+
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("can't commit transaction: %w", err)
+	}
+
+	return nil
+}
+
+// result keeps nullable columns of the command's execution result ...
+type result struct {
+	exitCode sql.NullInt64
+	signal   sql.NullString
+	limit    sql.NullString
+	finished sql.NullTime
+}
+
+// fill sets the result of execution to the command ...
+func (r result) fill(cmd *models.Command) {
+	if r.exitCode.Valid {
+		code := int(r.exitCode.Int64)
+		cmd.ExitCode = &code
+	}
+
+	cmd.Signal = r.signal.String
+	cmd.Limit = r.limit.String
+
+	if r.finished.Valid {
+		cmd.FinishedAt = r.finished.Time.UTC().Format(time.StampMilli)
+	}
+}
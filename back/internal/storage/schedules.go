@@ -0,0 +1,206 @@
+package storage
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/enchik0reo/commandApi/internal/models"
+	"github.com/enchik0reo/commandApi/internal/services"
+
+	"github.com/lib/pq"
+)
+
+// foreignKeyViolation is the postgres error code of foreign key constraint violation.
+const foreignKeyViolation = "23503"
+
+// CreateSchedule adds new schedule of the script or the template to db ...
+func (c *CommandStoage) CreateSchedule(ctx context.Context, sch models.Schedule) (int64, error) {
+	stmt, err := c.db.PrepareContext(ctx, `INSERT INTO schedules (schedule_name, spec, script, template_id, params,
+	options, no_overlap, paused) VALUES ($1, $2, NULLIF($3, ''), NULLIF($4, 0), $5, $6, $7, $8) RETURNING schedule_id`)
+	if err != nil {
+		return 0, fmt.Errorf("can't prepare statement: %w", err)
+	}
+	defer stmt.Close()
+
+	params, options, err := marshalSchedule(sch)
+	if err != nil {
+		return 0, err
+	}
+
+	var id int64
+
+	err = stmt.QueryRowContext(ctx, sch.Name, sch.Spec, sch.Script, sch.TemplateID, params, options,
+		sch.NoOverlap, sch.Paused).Scan(&id)
+	if err != nil {
+		return 0, fmt.Errorf("can't insert schedule: %w", scheduleError(err))
+	}
+
+	return id, nil
+}
+
+// GetSchedules returns all schedules ordered by id ...
+func (c *CommandStoage) GetSchedules(ctx context.Context) ([]models.Schedule, error) {
+	stmt, err := c.db.PrepareContext(ctx, `SELECT schedule_id, schedule_name, spec, COALESCE(script, ''),
+	COALESCE(template_id, 0), params, options, no_overlap, paused, COALESCE(last_command_id, 0), created_at, updated_at
+	FROM schedules ORDER BY schedule_id`)
+	if err != nil {
+		return nil, fmt.Errorf("can't prepare statement: %w", err)
+	}
+	defer stmt.Close()
+
+	rows, err := stmt.QueryContext(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("can't get schedules: %w", err)
+	}
+	defer rows.Close()
+
+	schs := []models.Schedule{}
+
+	for rows.Next() {
+		sch, err := scanSchedule(rows)
+		if err != nil {
+			return nil, err
+		}
+
+		schs = append(schs, *sch)
+	}
+
+	return schs, nil
+}
+
+// GetSchedule returns the schedule by schedule id ...
+func (c *CommandStoage) GetSchedule(ctx context.Context, id int64) (*models.Schedule, error) {
+	stmt, err := c.db.PrepareContext(ctx, `SELECT schedule_id, schedule_name, spec, COALESCE(script, ''),
+	COALESCE(template_id, 0), params, options, no_overlap, paused, COALESCE(last_command_id, 0), created_at, updated_at
+	FROM schedules WHERE schedule_id = $1`)
+	if err != nil {
+		return nil, fmt.Errorf("can't prepare statement: %w", err)
+	}
+	defer stmt.Close()
+
+	sch, err := scanSchedule(stmt.QueryRowContext(ctx, id))
+	if err != nil {
+		return nil, scheduleError(err)
+	}
+
+	return sch, nil
+}
+
+// UpdateSchedule replaces the schedule by schedule id, the last command is kept ...
+func (c *CommandStoage) UpdateSchedule(ctx context.Context, sch models.Schedule) (int64, error) {
+	stmt, err := c.db.PrepareContext(ctx, `UPDATE schedules SET schedule_name = $2, spec = $3, script = NULLIF($4, ''),
+	template_id = NULLIF($5, 0), params = $6, options = $7, no_overlap = $8, paused = $9, updated_at = NOW()
+	WHERE schedule_id = $1 RETURNING schedule_id`)
+	if err != nil {
+		return 0, fmt.Errorf("can't prepare statement: %w", err)
+	}
+	defer stmt.Close()
+
+	params, options, err := marshalSchedule(sch)
+	if err != nil {
+		return 0, err
+	}
+
+	var id int64
+
+	err = stmt.QueryRowContext(ctx, sch.ID, sch.Name, sch.Spec, sch.Script, sch.TemplateID, params, options,
+		sch.NoOverlap, sch.Paused).Scan(&id)
+	if err != nil {
+		return 0, fmt.Errorf("can't update schedule: %w", scheduleError(err))
+	}
+
+	return id, nil
+}
+
+// DeleteSchedule deletes the schedule by schedule id ...
+func (c *CommandStoage) DeleteSchedule(ctx context.Context, id int64) (int64, error) {
+	stmt, err := c.db.PrepareContext(ctx, `DELETE FROM schedules WHERE schedule_id = $1 RETURNING schedule_id`)
+	if err != nil {
+		return 0, fmt.Errorf("can't prepare statement: %w", err)
+	}
+	defer stmt.Close()
+
+	if err := stmt.QueryRowContext(ctx, id).Scan(&id); err != nil {
+		return 0, fmt.Errorf("can't delete schedule: %w", scheduleError(err))
+	}
+
+	return id, nil
+}
+
+// SetLastCommand saves the id of the last command created by the schedule ...
+func (c *CommandStoage) SetLastCommand(ctx context.Context, id, commandID int64) (int64, error) {
+	stmt, err := c.db.PrepareContext(ctx, `UPDATE schedules SET last_command_id = $2
+	WHERE schedule_id = $1 RETURNING schedule_id`)
+	if err != nil {
+		return 0, fmt.Errorf("can't prepare statement: %w", err)
+	}
+	defer stmt.Close()
+
+	if err := stmt.QueryRowContext(ctx, id, commandID).Scan(&id); err != nil {
+		return 0, fmt.Errorf("can't set last command: %w", scheduleError(err))
+	}
+
+	return id, nil
+}
+
+// marshalSchedule returns JSON encoded parameters and options of the schedule ...
+func marshalSchedule(sch models.Schedule) ([]byte, []byte, error) {
+	if sch.Params == nil {
+		sch.Params = map[string]any{}
+	}
+
+	params, err := json.Marshal(sch.Params)
+	if err != nil {
+		return nil, nil, fmt.Errorf("can't marshal params: %w", err)
+	}
+
+	options, err := json.Marshal(sch.Options)
+	if err != nil {
+		return nil, nil, fmt.Errorf("can't marshal options: %w", err)
+	}
+
+	return params, options, nil
+}
+
+// scanSchedule reads the schedule from the row ...
+func scanSchedule(row interface{ Scan(...any) error }) (*models.Schedule, error) {
+	sch := models.Schedule{}
+	var params, options []byte
+	var created, updated time.Time
+
+	if err := row.Scan(&sch.ID, &sch.Name, &sch.Spec, &sch.Script, &sch.TemplateID, &params, &options,
+		&sch.NoOverlap, &sch.Paused, &sch.LastCommandID, &created, &updated); err != nil {
+		return nil, fmt.Errorf("can't scan row: %w", err)
+	}
+
+	if err := json.Unmarshal(params, &sch.Params); err != nil {
+		return nil, fmt.Errorf("can't unmarshal params: %w", err)
+	}
+
+	if err := json.Unmarshal(options, &sch.Options); err != nil {
+		return nil, fmt.Errorf("can't unmarshal options: %w", err)
+	}
+
+	sch.CreatedAt = created.UTC().Format(time.StampMilli)
+	sch.UpdatedAt = updated.UTC().Format(time.StampMilli)
+
+	return &sch, nil
+}
+
+// scheduleError converts errors of missing row and missing template to the service's ones ...
+func scheduleError(err error) error {
+	var pqErr *pq.Error
+
+	switch {
+	case errors.Is(err, sql.ErrNoRows):
+		return services.ErrScheduleNotFound
+	case errors.As(err, &pqErr) && pqErr.Code == foreignKeyViolation:
+		return services.ErrTemplateNotFound
+	default:
+		return err
+	}
+}
@@ -0,0 +1,186 @@
+package storage
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/enchik0reo/commandApi/internal/models"
+	"github.com/enchik0reo/commandApi/internal/services"
+
+	"github.com/lib/pq"
+)
+
+// uniqueViolation is the postgres error code of unique constraint violation.
+const uniqueViolation = "23505"
+
+// CreateTemplate adds new template with its parameters and options to db ...
+func (c *CommandStoage) CreateTemplate(ctx context.Context, tmpl models.Template) (int64, error) {
+	stmt, err := c.db.PrepareContext(ctx, `INSERT INTO templates (template_name, description, script, params, options)
+	VALUES ($1, $2, $3, $4, $5) RETURNING template_id`)
+	if err != nil {
+		return 0, fmt.Errorf("can't prepare statement: %w", err)
+	}
+	defer stmt.Close()
+
+	params, options, err := marshalTemplate(tmpl)
+	if err != nil {
+		return 0, err
+	}
+
+	var id int64
+
+	err = stmt.QueryRowContext(ctx, tmpl.Name, tmpl.Description, tmpl.Script, params, options).Scan(&id)
+	if err != nil {
+		return 0, fmt.Errorf("can't insert template: %w", templateError(err))
+	}
+
+	return id, nil
+}
+
+// GetTemplates returns all templates ordered by name ...
+func (c *CommandStoage) GetTemplates(ctx context.Context) ([]models.Template, error) {
+	stmt, err := c.db.PrepareContext(ctx, `SELECT template_id, template_name, description, script, params, options,
+	created_at, updated_at FROM templates ORDER BY template_name`)
+	if err != nil {
+		return nil, fmt.Errorf("can't prepare statement: %w", err)
+	}
+	defer stmt.Close()
+
+	rows, err := stmt.QueryContext(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("can't get templates: %w", err)
+	}
+	defer rows.Close()
+
+	tmpls := []models.Template{}
+
+	for rows.Next() {
+		tmpl, err := scanTemplate(rows)
+		if err != nil {
+			return nil, err
+		}
+
+		tmpls = append(tmpls, *tmpl)
+	}
+
+	return tmpls, nil
+}
+
+// GetTemplate returns the template by template id ...
+func (c *CommandStoage) GetTemplate(ctx context.Context, id int64) (*models.Template, error) {
+	stmt, err := c.db.PrepareContext(ctx, `SELECT template_id, template_name, description, script, params, options,
+	created_at, updated_at FROM templates WHERE template_id = $1`)
+	if err != nil {
+		return nil, fmt.Errorf("can't prepare statement: %w", err)
+	}
+	defer stmt.Close()
+
+	tmpl, err := scanTemplate(stmt.QueryRowContext(ctx, id))
+	if err != nil {
+		return nil, templateError(err)
+	}
+
+	return tmpl, nil
+}
+
+// UpdateTemplate replaces the template by template id ...
+func (c *CommandStoage) UpdateTemplate(ctx context.Context, tmpl models.Template) (int64, error) {
+	stmt, err := c.db.PrepareContext(ctx, `UPDATE templates SET template_name = $2, description = $3, script = $4,
+	params = $5, options = $6, updated_at = NOW() WHERE template_id = $1 RETURNING template_id`)
+	if err != nil {
+		return 0, fmt.Errorf("can't prepare statement: %w", err)
+	}
+	defer stmt.Close()
+
+	params, options, err := marshalTemplate(tmpl)
+	if err != nil {
+		return 0, err
+	}
+
+	var id int64
+
+	err = stmt.QueryRowContext(ctx, tmpl.ID, tmpl.Name, tmpl.Description, tmpl.Script, params, options).Scan(&id)
+	if err != nil {
+		return 0, fmt.Errorf("can't update template: %w", templateError(err))
+	}
+
+	return id, nil
+}
+
+// DeleteTemplate deletes the template by template id.
+// Commands made from the template are kept ...
+func (c *CommandStoage) DeleteTemplate(ctx context.Context, id int64) (int64, error) {
+	stmt, err := c.db.PrepareContext(ctx, `DELETE FROM templates WHERE template_id = $1 RETURNING template_id`)
+	if err != nil {
+		return 0, fmt.Errorf("can't prepare statement: %w", err)
+	}
+	defer stmt.Close()
+
+	if err := stmt.QueryRowContext(ctx, id).Scan(&id); err != nil {
+		return 0, fmt.Errorf("can't delete template: %w", templateError(err))
+	}
+
+	return id, nil
+}
+
+// marshalTemplate returns JSON encoded parameters and options of the template ...
+func marshalTemplate(tmpl models.Template) ([]byte, []byte, error) {
+	if tmpl.Params == nil {
+		tmpl.Params = []models.Param{}
+	}
+
+	params, err := json.Marshal(tmpl.Params)
+	if err != nil {
+		return nil, nil, fmt.Errorf("can't marshal params: %w", err)
+	}
+
+	options, err := json.Marshal(tmpl.Options)
+	if err != nil {
+		return nil, nil, fmt.Errorf("can't marshal options: %w", err)
+	}
+
+	return params, options, nil
+}
+
+// scanTemplate reads the template from the row ...
+func scanTemplate(row interface{ Scan(...any) error }) (*models.Template, error) {
+	tmpl := models.Template{}
+	var params, options []byte
+	var created, updated time.Time
+
+	if err := row.Scan(&tmpl.ID, &tmpl.Name, &tmpl.Description, &tmpl.Script, &params, &options,
+		&created, &updated); err != nil {
+		return nil, fmt.Errorf("can't scan row: %w", err)
+	}
+
+	if err := json.Unmarshal(params, &tmpl.Params); err != nil {
+		return nil, fmt.Errorf("can't unmarshal params: %w", err)
+	}
+
+	if err := json.Unmarshal(options, &tmpl.Options); err != nil {
+		return nil, fmt.Errorf("can't unmarshal options: %w", err)
+	}
+
+	tmpl.CreatedAt = created.UTC().Format(time.StampMilli)
+	tmpl.UpdatedAt = updated.UTC().Format(time.StampMilli)
+
+	return &tmpl, nil
+}
+
+// templateError converts errors of missing row and duplicated name to the service's ones ...
+func templateError(err error) error {
+	var pqErr *pq.Error
+
+	switch {
+	case errors.Is(err, sql.ErrNoRows):
+		return services.ErrTemplateNotFound
+	case errors.As(err, &pqErr) && pqErr.Code == uniqueViolation:
+		return services.ErrTemplateExists
+	default:
+		return err
+	}
+}
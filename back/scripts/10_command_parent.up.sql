@@ -0,0 +1,4 @@
+ALTER TABLE commands
+    ADD COLUMN IF NOT EXISTS parent_id INT REFERENCES commands (command_id) ON DELETE SET NULL;
+
+CREATE INDEX IF NOT EXISTS idx_commands_parent_id ON commands (parent_id) WHERE parent_id IS NOT NULL;
@@ -0,0 +1,13 @@
+ALTER TABLE commands
+    ADD COLUMN IF NOT EXISTS script_tsv tsvector GENERATED ALWAYS AS (to_tsvector('simple', COALESCE(script, ''))) STORED;
+
+ALTER TABLE outputs
+    ADD COLUMN IF NOT EXISTS output_tsv tsvector GENERATED ALWAYS AS (to_tsvector('simple', output)) STORED;
+
+CREATE INDEX IF NOT EXISTS idx_commands_script_tsv ON commands USING GIN (script_tsv);
+CREATE INDEX IF NOT EXISTS idx_outputs_output_tsv ON outputs USING GIN (output_tsv);
+
+CREATE INDEX IF NOT EXISTS idx_outputs_command_id ON outputs (command_id, output_id);
+CREATE INDEX IF NOT EXISTS idx_commands_status ON commands (status, command_id);
+CREATE INDEX IF NOT EXISTS idx_commands_started_at ON commands (started_at, command_id);
+CREATE INDEX IF NOT EXISTS idx_commands_working ON commands (command_id) WHERE is_working;
@@ -0,0 +1,11 @@
+ALTER TABLE outputs
+    ADD COLUMN IF NOT EXISTS line_no BIGINT;
+
+-- Lines saved before this migration are numbered in order of insertion.
+UPDATE outputs o SET line_no = n.rn
+FROM (SELECT output_id, ROW_NUMBER() OVER (PARTITION BY command_id ORDER BY output_id) AS rn FROM outputs) n
+WHERE o.output_id = n.output_id AND o.line_no IS NULL;
+
+ALTER TABLE outputs ALTER COLUMN line_no SET NOT NULL;
+
+CREATE UNIQUE INDEX IF NOT EXISTS idx_outputs_line_no ON outputs (command_id, line_no);
@@ -0,0 +1,6 @@
+ALTER TABLE outputs
+    ADD COLUMN IF NOT EXISTS produced_at TIMESTAMPTZ;
+
+-- Time when the command started running, queued commands don't have it yet.
+ALTER TABLE commands
+    ADD COLUMN IF NOT EXISTS run_at TIMESTAMPTZ;
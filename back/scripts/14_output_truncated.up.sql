@@ -0,0 +1,2 @@
+ALTER TABLE commands
+    ADD COLUMN IF NOT EXISTS truncated BOOLEAN NOT NULL DEFAULT false;
@@ -0,0 +1,3 @@
+ALTER TABLE outputs
+    ADD COLUMN IF NOT EXISTS encoding VARCHAR(8),
+    ADD COLUMN IF NOT EXISTS partial BOOLEAN NOT NULL DEFAULT false;
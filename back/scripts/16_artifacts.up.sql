@@ -0,0 +1,10 @@
+CREATE TABLE IF NOT EXISTS artifacts
+(
+    artifact_id INT GENERATED ALWAYS AS IDENTITY PRIMARY KEY,
+    command_id INT NOT NULL REFERENCES commands (command_id) ON DELETE CASCADE,
+    artifact_name TEXT NOT NULL,
+    size BIGINT NOT NULL,
+    sha256 VARCHAR(64) NOT NULL,
+    created_at TIMESTAMP NOT NULL DEFAULT NOW(),
+    UNIQUE (command_id, artifact_name)
+);
@@ -0,0 +1,3 @@
+-- New enum value can't be used in the transaction where it's added,
+-- so the index of queued commands is created after the queue migration.
+CREATE INDEX IF NOT EXISTS idx_commands_queued ON commands (command_id) WHERE status = 'queued';
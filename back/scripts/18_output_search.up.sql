@@ -0,0 +1,15 @@
+-- Output lines aren't indexed one by one, so saving output doesn't pay for tokenising and GIN maintenance.
+-- The output is indexed once per command when it finishes, only its first 262144 characters are indexed.
+DROP INDEX IF EXISTS idx_outputs_output_tsv;
+
+ALTER TABLE outputs
+    DROP COLUMN IF EXISTS output_tsv;
+
+ALTER TABLE commands
+    ADD COLUMN IF NOT EXISTS output_tsv tsvector;
+
+UPDATE commands c SET output_tsv = (SELECT strip(to_tsvector('simple', left(string_agg(o.output, ' ' ORDER BY o.line_no), 262144)))
+    FROM outputs o WHERE o.command_id = c.command_id AND COALESCE(o.encoding, '') = '')
+WHERE NOT c.is_working;
+
+CREATE INDEX IF NOT EXISTS idx_commands_output_tsv ON commands USING GIN (output_tsv);
@@ -0,0 +1 @@
+ALTER TABLE outputs ADD COLUMN IF NOT EXISTS stream VARCHAR(6) NOT NULL DEFAULT 'stdout';
@@ -0,0 +1,10 @@
+CREATE TYPE command_status AS ENUM ('running', 'succeeded', 'failed', 'stopped', 'timed_out');
+
+ALTER TABLE commands
+    ADD COLUMN IF NOT EXISTS status command_status NOT NULL DEFAULT 'running',
+    ADD COLUMN IF NOT EXISTS exit_code INT,
+    ADD COLUMN IF NOT EXISTS signal VARCHAR(10),
+    ADD COLUMN IF NOT EXISTS finished_at TIMESTAMP;
+
+-- Commands finished before this migration have no recorded outcome.
+UPDATE commands SET status = 'stopped' WHERE is_working = false;
@@ -0,0 +1,5 @@
+ALTER TABLE commands
+    ADD COLUMN IF NOT EXISTS script TEXT,
+    ADD COLUMN IF NOT EXISTS script_hash CHAR(64);
+
+CREATE INDEX IF NOT EXISTS idx_commands_script_hash ON commands (script_hash);
@@ -0,0 +1,4 @@
+ALTER TYPE command_status ADD VALUE IF NOT EXISTS 'limit_exceeded';
+
+ALTER TABLE commands
+    ADD COLUMN IF NOT EXISTS exceeded_limit VARCHAR(16);
@@ -0,0 +1,4 @@
+ALTER TYPE command_status ADD VALUE IF NOT EXISTS 'queued';
+
+ALTER TABLE commands
+    ADD COLUMN IF NOT EXISTS options JSONB;
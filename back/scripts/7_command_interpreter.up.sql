@@ -0,0 +1,2 @@
+ALTER TABLE commands
+    ADD COLUMN IF NOT EXISTS interpreter VARCHAR(32) NOT NULL DEFAULT 'bash';
@@ -0,0 +1,14 @@
+CREATE TABLE IF NOT EXISTS templates
+(
+    template_id INT GENERATED ALWAYS AS IDENTITY PRIMARY KEY,
+    template_name VARCHAR(64) NOT NULL UNIQUE,
+    description TEXT NOT NULL DEFAULT '',
+    script TEXT NOT NULL,
+    params JSONB NOT NULL DEFAULT '[]',
+    options JSONB NOT NULL DEFAULT '{}',
+    created_at TIMESTAMP NOT NULL DEFAULT NOW(),
+    updated_at TIMESTAMP NOT NULL DEFAULT NOW()
+);
+
+ALTER TABLE commands
+    ADD COLUMN IF NOT EXISTS template_id INT REFERENCES templates (template_id) ON DELETE SET NULL;
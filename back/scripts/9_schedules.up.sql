@@ -0,0 +1,16 @@
+CREATE TABLE IF NOT EXISTS schedules
+(
+    schedule_id INT GENERATED ALWAYS AS IDENTITY PRIMARY KEY,
+    schedule_name VARCHAR(64) NOT NULL,
+    spec VARCHAR(128) NOT NULL,
+    script TEXT,
+    template_id INT REFERENCES templates (template_id) ON DELETE CASCADE,
+    params JSONB NOT NULL DEFAULT '{}',
+    options JSONB NOT NULL DEFAULT '{}',
+    no_overlap BOOLEAN NOT NULL DEFAULT FALSE,
+    paused BOOLEAN NOT NULL DEFAULT FALSE,
+    last_command_id INT REFERENCES commands (command_id) ON DELETE SET NULL,
+    created_at TIMESTAMP NOT NULL DEFAULT NOW(),
+    updated_at TIMESTAMP NOT NULL DEFAULT NOW(),
+    CHECK ((script IS NULL) <> (template_id IS NULL))
+);
@@ -43,7 +43,7 @@ const Cmd = () => {
                 if (r.data.status === 500) {
                     toast.error(r.data.body.error)
                     setDataIsCorrect(false)
-                } else if (r.data.status === 400) {
+                } else if (r.data.status === 400 || r.data.status === 404) {
                     toast.warn(r.data.body.error)
                     setDataIsCorrect(false)
                 } else {
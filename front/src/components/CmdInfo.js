@@ -7,14 +7,26 @@ export default class CmdInfo extends React.Component {
             <div className="cmd">
                 <p className="cmd-id">Command id: {this.props.cmd.id}</p>
                 <p className="cmd-id">Short description: {this.props.cmd.command_name}</p>
+                {this.props.cmd.script && <pre className="cmd-id">{this.props.cmd.script}</pre>}
+                {this.props.cmd.interpreter && <p className="cmd-id">Interpreter: {this.props.cmd.interpreter}</p>}
+                {this.props.cmd.parent_id && <p className="cmd-id">Rerun of command: {this.props.cmd.parent_id}</p>}
+                {this.props.cmd.reruns && <p className="cmd-id">Reruns: {this.props.cmd.reruns.join(', ')}</p>}
                 <p className="cmd-id">Created at: {this.props.cmd.created_at}</p>
-                <p className="cmd-id">Status: {this.props.cmd.is_working ? "works" : "finished"}</p>
+                <p className="cmd-id">Status: {this.props.cmd.status}</p>
+                {this.props.cmd.finished_at && <p className="cmd-id">Finished at: {this.props.cmd.finished_at}</p>}
+                {this.props.cmd.exit_code !== undefined && <p className="cmd-id">Exit code: {this.props.cmd.exit_code}</p>}
+                {this.props.cmd.signal && <p className="cmd-id">Signal: {this.props.cmd.signal}</p>}
+                {this.props.cmd.exceeded_limit && <p className="cmd-id">Exceeded limit: {this.props.cmd.exceeded_limit}</p>}
+                {this.props.cmd.truncated && <p className="cmd-id">Output is truncated</p>}
+                <p className="cmd-id">
+                    Download output: <a href={`${process.env.REACT_APP_BACKEND_URL}/cmd/${this.props.cmd.id}/output.txt`}>txt</a>, <a href={`${process.env.REACT_APP_BACKEND_URL}/cmd/${this.props.cmd.id}/output.ndjson`}>ndjson</a>
+                </p>
                 <div className="cmd-wrapper">
                     Output history:
                     {this.props.cmd.output ?
                         this.props.cmd.output.map((row) => (
-                            <tr>
-                                <td>{row}</td>
+                            <tr className={row.stream === "stderr" ? "cmd-stderr" : ""}>
+                                <td>{row.text}</td>
                             </tr>
                         ))
                         :
@@ -132,7 +132,7 @@ const Commands = () => {
                                                 <td>{row.id}</td>
                                                 <td>{row.command_name}</td>
                                                 <td>{row.created_at}</td>
-                                                <td>{row.is_working ? "works" : "finished"}</td>
+                                                <td>{row.status}{row.queue_position ? ` #${row.queue_position}` : ""}</td>
                                             </tr>
                                         ))}
                                     </tbody>